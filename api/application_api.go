@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+
 	"github.com/SecurityDo/ingext_api/client"
 	"github.com/SecurityDo/ingext_api/model"
 )
@@ -8,6 +10,7 @@ import (
 // PlatformService provides helpers for calling platform_* endpoints.
 type ApplicationService struct {
 	client *client.IngextClient
+	ctx    context.Context
 }
 
 // NewPlatformService constructs a PlatformService instance backed by the provided client.
@@ -15,8 +18,15 @@ func NewApplicationService(client *client.IngextClient) *ApplicationService {
 	return &ApplicationService{client: client}
 }
 
+// WithContext returns a copy of the service whose calls are bound to ctx.
+func (s *ApplicationService) WithContext(ctx context.Context) *ApplicationService {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
 func (s *ApplicationService) call(function string, payload interface{}, out interface{}) error {
-	return ApiCall(s.client, function, payload, out)
+	return ApiCallContext(s.ctx, s.client, function, payload, out)
 }
 
 func (s *ApplicationService) ListAppTemplates() (*ListAppTemplateResponse, error) {
@@ -31,6 +41,10 @@ type ListAppTemplateResponse struct {
 	Entries []*model.ApplicationTemplateConfig `json:"entries"`
 }
 
+type AppTemplateEntryResponse struct {
+	Entry *model.AppTemplateEntry `json:"entry"`
+}
+
 type ListAppInstanceResponse struct {
 	Entries []*model.InstanceState `json:"entries"`
 }
@@ -83,6 +97,21 @@ func (s *ApplicationService) AddAppTemplate(content string) (id string, err erro
 	return resp.ID, nil
 }
 
+// GetAppTemplate retrieves an application template with its content.
+func (s *ApplicationService) GetAppTemplate(name string) (*model.AppTemplateEntry, error) {
+	request := &GenericDAORequest[model.AppTemplateEntry]{
+		Action: "get",
+		Args: &GenericDAORequestArgs[model.AppTemplateEntry]{
+			Id: name,
+		},
+	}
+	var resp AppTemplateEntryResponse
+	if err := s.call("platform_application_template_dao", request, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Entry, nil
+}
+
 func (s *ApplicationService) DeleteAppTemplate(name string) (err error) {
 	request := &GenericDAORequest[model.AppTemplateEntry]{
 		Action: "delete",
@@ -1,7 +1,7 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
 
@@ -11,6 +11,7 @@ import (
 
 type AuthService struct {
 	client *client.IngextClient
+	ctx    context.Context
 }
 
 func NewAuthService(client *client.IngextClient) *AuthService {
@@ -19,12 +20,19 @@ func NewAuthService(client *client.IngextClient) *AuthService {
 	}
 }
 
+// WithContext returns a copy of the service whose calls are bound to ctx.
+func (s *AuthService) WithContext(ctx context.Context) *AuthService {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
 type AddUserRequest struct {
 	User *model.UserEntry `json:"user"`
 }
 
 func (s *AuthService) AddUser(req *AddUserRequest) error {
-	_, err := s.client.GenericCall("api/auth", "userAdd", req)
+	_, err := s.client.GenericCallContext(contextOrBackground(s.ctx), "api/auth", "userAdd", req)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error adding user: %v\n", err.Error())
 		return err
@@ -38,17 +46,11 @@ type ListUserResponse struct {
 }
 
 func (s *AuthService) ListUser() (users []*model.UserEntry, err error) {
-	res, err := s.client.GenericCall("api/auth", "userList", nil)
+	result, err := client.CallTyped[any, ListUserResponse](contextOrBackground(s.ctx), s.client, "api/auth", "userList", nil)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error listing users: %v\n", err.Error())
 		return nil, err
 	}
-	var result ListUserResponse
-	err = json.Unmarshal(res.GetBytes(), &result)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing user list response: %v\n", err.Error())
-		return nil, err
-	}
 	return result.Users, nil
 }
 
@@ -65,17 +67,11 @@ func (s *AuthService) GetUser(username string) (*model.UserEntry, error) {
 		Username: username,
 	}
 
-	res, err := s.client.GenericCall("api/auth", "getUser", req)
+	result, err := client.CallTyped[*GetUserRequest, GetUserResponse](contextOrBackground(s.ctx), s.client, "api/auth", "getUser", req)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error getting user %s: %v\n", username, err.Error())
 		return nil, err
 	}
-	var result GetUserResponse
-	err = json.Unmarshal(res.GetBytes(), &result)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing get user response: %v\n", err.Error())
-		return nil, err
-	}
 	return result.User, nil
 }
 
@@ -88,7 +84,7 @@ func (s *AuthService) DeleteUser(username string) error {
 		Username: username,
 	}
 
-	_, err := s.client.GenericCall("api/auth", "userDelete", req)
+	_, err := s.client.GenericCallContext(contextOrBackground(s.ctx), "api/auth", "userDelete", req)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error deleting user %s: %v\n", username, err.Error())
 		return err
@@ -125,17 +121,11 @@ func (s *AuthService) AddToken(name, description, role string) (token string, er
 		},
 	}
 
-	res, err := s.client.GenericCall("api/auth", "api_token", req)
+	result, err := client.CallTyped[*tokenRequest, AddTokenResponse](contextOrBackground(s.ctx), s.client, "api/auth", "api_token", req)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error adding token %s: %v\n", name, err.Error())
 		return "", err
 	}
-	var result AddTokenResponse
-	err = json.Unmarshal(res.GetBytes(), &result)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing add token response: %v\n", err.Error())
-		return "", err
-	}
 	return result.Token, nil
 }
 
@@ -146,7 +136,7 @@ func (s *AuthService) DeleteToken(name string) (err error) {
 			Name: name,
 		},
 	}
-	_, err = s.client.GenericCall("api/auth", "api_token", req)
+	_, err = s.client.GenericCallContext(contextOrBackground(s.ctx), "api/auth", "api_token", req)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error deleting token %s: %v\n", name, err.Error())
 		return err
@@ -162,17 +152,11 @@ func (s *AuthService) ListToken() (tokens []*model.ApiTokenEntry, err error) {
 	req := &tokenRequest{
 		Action: "list",
 	}
-	res, err := s.client.GenericCall("api/auth", "api_token", req)
+	result, err := client.CallTyped[*tokenRequest, ListTokenResponse](contextOrBackground(s.ctx), s.client, "api/auth", "api_token", req)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error listing token: %v\n", err.Error())
 		return nil, err
 	}
-	var result ListTokenResponse
-	err = json.Unmarshal(res.GetBytes(), &result)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing list token response: %v\n", err.Error())
-		return nil, err
-	}
 	return result.Entries, nil
 }
 
@@ -187,7 +171,7 @@ func (s *AuthService) SetUserSitePolicy(username string, sitePolicy string) (err
 		PolicyName: sitePolicy,
 	}
 
-	_, err = s.client.GenericCall("api/auth", "setUserSitePolicy", req)
+	_, err = s.client.GenericCallContext(contextOrBackground(s.ctx), "api/auth", "setUserSitePolicy", req)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error setting site policy for user %s: %v\n", username, err.Error())
 		return err
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+
 	"github.com/SecurityDo/ingext_api/client"
 	"github.com/SecurityDo/ingext_api/model"
 )
@@ -8,6 +10,7 @@ import (
 // CollectorService provides helpers for calling collector endpoints.
 type CollectorService struct {
 	client *client.IngextClient
+	ctx    context.Context
 }
 
 // NewCollectorService constructs a CollectorService instance backed by the provided client.
@@ -15,8 +18,15 @@ func NewCollectorService(c *client.IngextClient) *CollectorService {
 	return &CollectorService{client: c}
 }
 
+// WithContext returns a copy of the service whose calls are bound to ctx.
+func (s *CollectorService) WithContext(ctx context.Context) *CollectorService {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
 func (s *CollectorService) call(function string, payload interface{}, out interface{}) error {
-	return ApiCall(s.client, function, payload, out)
+	return ApiCallContext(s.ctx, s.client, function, payload, out)
 }
 
 type CollectorListResponse struct {
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+
 	"github.com/SecurityDo/ingext_api/client"
 	"github.com/SecurityDo/ingext_api/model"
 )
@@ -8,6 +10,7 @@ import (
 // PlatformService provides helpers for calling platform_* endpoints.
 type DatalakeService struct {
 	client *client.IngextClient
+	ctx    context.Context
 }
 
 // NewPlatformService constructs a PlatformService instance backed by the provided client.
@@ -15,8 +18,15 @@ func NewDatalakeService(client *client.IngextClient) *DatalakeService {
 	return &DatalakeService{client: client}
 }
 
+// WithContext returns a copy of the service whose calls are bound to ctx.
+func (s *DatalakeService) WithContext(ctx context.Context) *DatalakeService {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
 func (s *DatalakeService) call(function string, payload interface{}, out interface{}) error {
-	return ApiCall(s.client, function, payload, out)
+	return ApiCallContext(s.ctx, s.client, function, payload, out)
 }
 
 func (s *DatalakeService) ListDatalake() (entries []*model.Datalake, err error) {
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+
 	"github.com/SecurityDo/ingext_api/client"
 	"github.com/SecurityDo/ingext_api/model"
 )
@@ -8,6 +10,7 @@ import (
 // EventWatchService provides helpers for calling eventwatch/overview endpoints.
 type EventWatchService struct {
 	client *client.IngextClient
+	ctx    context.Context
 }
 
 // NewEventWatchService constructs an EventWatchService instance backed by the provided client.
@@ -15,8 +18,15 @@ func NewEventWatchService(client *client.IngextClient) *EventWatchService {
 	return &EventWatchService{client: client}
 }
 
+// WithContext returns a copy of the service whose calls are bound to ctx.
+func (s *EventWatchService) WithContext(ctx context.Context) *EventWatchService {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
 func (s *EventWatchService) call(function string, payload interface{}, out interface{}) error {
-	return ApiCall(s.client, function, payload, out)
+	return ApiCallContext(s.ctx, s.client, function, payload, out)
 }
 
 // SummarySearch calls /api/ds/overview_summary_search with the given search string and time range.
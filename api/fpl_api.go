@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+
 	"github.com/SecurityDo/ingext_api/client"
 	"github.com/SecurityDo/ingext_api/model"
 )
@@ -8,6 +10,7 @@ import (
 // FPLService provides helpers for calling FPL report/task/result endpoints.
 type FPLService struct {
 	client *client.IngextClient
+	ctx    context.Context
 }
 
 // NewFPLService constructs an FPLService instance backed by the provided client.
@@ -15,8 +18,15 @@ func NewFPLService(client *client.IngextClient) *FPLService {
 	return &FPLService{client: client}
 }
 
+// WithContext returns a copy of the service whose calls are bound to ctx.
+func (s *FPLService) WithContext(ctx context.Context) *FPLService {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
 func (s *FPLService) call(function string, payload interface{}, out interface{}) error {
-	return ApiCall(s.client, function, payload, out)
+	return ApiCallContext(s.ctx, s.client, function, payload, out)
 }
 
 // FPLIDRequest is the kargs payload for get_fpl_task and get_fpl_result.
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+
 	"github.com/SecurityDo/ingext_api/client"
 	"github.com/SecurityDo/ingext_api/model"
 )
@@ -8,6 +10,7 @@ import (
 // PlatformService provides helpers for calling platform_* endpoints.
 type GridService struct {
 	client *client.IngextClient
+	ctx    context.Context
 }
 
 // NewGridService constructs a GridService instance backed by the provided client.
@@ -15,12 +18,19 @@ func NewGridService(client *client.IngextClient) *GridService {
 	return &GridService{client: client}
 }
 
+// WithContext returns a copy of the service whose calls are bound to ctx.
+func (s *GridService) WithContext(ctx context.Context) *GridService {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
 //func (s *GridService) call(function string, payload interface{}, out interface{}) error {
 //	return ApiCall(s.client, function, payload, out)
 //}
 
 func (s *GridService) gridCall(function string, payload interface{}, out interface{}) error {
-	return ApiCallWithPrefix(s.client, "api/grid", function, payload, out)
+	return ApiCallWithPrefixContext(s.ctx, s.client, "api/grid", function, payload, out)
 }
 
 func (s *GridService) ListAccount() (resp *model.ListFluencyAccountsResponse, err error) {
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+
 	"github.com/SecurityDo/ingext_api/client"
 	"github.com/SecurityDo/ingext_api/model"
 )
@@ -8,30 +10,42 @@ import (
 // PlatformService provides helpers for calling platform_* endpoints.
 type NotificationService struct {
 	client *client.IngextClient
+	ctx    context.Context
 }
 
 func NewNotificationService(client *client.IngextClient) *NotificationService {
 	return &NotificationService{client: client}
 }
 
+// WithContext returns a copy of the service whose calls are bound to ctx.
+func (s *NotificationService) WithContext(ctx context.Context) *NotificationService {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
 func (s *NotificationService) call(function string, payload interface{}, out interface{}) error {
-	return ApiCall(s.client, function, payload, out)
+	return ApiCallContext(s.ctx, s.client, function, payload, out)
 }
 
 func (s *NotificationService) AddEmail(name string, action string, to []string, cc []string) (id string, err error) {
+	return s.AddEndpoint(&model.EndpointConfig{
+		Name:        name,
+		Integration: "Email",
+		Action:      action,
+		Email: &model.EndpointEmailConfig{
+			To: to,
+			Cc: cc,
+		},
+	})
+}
+
+// AddEndpoint creates a notification endpoint of any integration.
+func (s *NotificationService) AddEndpoint(entry *model.EndpointConfig) (id string, err error) {
 	request := &GenericDAORequest[model.EndpointConfig]{
 		Action: "add",
 		Args: &GenericDAORequestArgs[model.EndpointConfig]{
-			//Id:    args.Id,
-			Entry: &model.EndpointConfig{
-				Name:        name,
-				Integration: "Email",
-				Action:      action,
-				Email: &model.EndpointEmailConfig{
-					To: to,
-					Cc: cc,
-				},
-			},
+			Entry: entry,
 		},
 	}
 	var resp GenericDaoAddResponse
@@ -41,6 +55,18 @@ func (s *NotificationService) AddEmail(name string, action string, to []string,
 	return resp.ID, nil
 }
 
+// UpdateEndpoint replaces the notification endpoint named entry.Name.
+func (s *NotificationService) UpdateEndpoint(entry *model.EndpointConfig) error {
+	request := &GenericDAORequest[model.EndpointConfig]{
+		Action: "update",
+		Args: &GenericDAORequestArgs[model.EndpointConfig]{
+			Id:    entry.Name,
+			Entry: entry,
+		},
+	}
+	return s.call("platform_notification_endpoint_dao", request, nil)
+}
+
 func (s *NotificationService) Delete(name string) (err error) {
 	request := &GenericDAORequest[model.EndpointConfig]{
 		Action: "delete",
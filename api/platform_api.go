@@ -1,7 +1,9 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -13,6 +15,7 @@ import (
 // PlatformService provides helpers for calling platform_* endpoints.
 type PlatformService struct {
 	client *client.IngextClient
+	ctx    context.Context
 }
 
 // NewPlatformService constructs a PlatformService instance backed by the provided client.
@@ -20,49 +23,53 @@ func NewPlatformService(client *client.IngextClient) *PlatformService {
 	return &PlatformService{client: client}
 }
 
+// WithContext returns a copy of the service whose calls are bound to ctx.
+func (s *PlatformService) WithContext(ctx context.Context) *PlatformService {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
 func ApiCallWithPrefix(client *client.IngextClient, prefix, function string, payload interface{}, out interface{}) error {
-	res, err := client.GenericCall(prefix, function, payload)
+	return ApiCallWithPrefixContext(context.Background(), client, prefix, function, payload, out)
+}
+
+// ApiCallWithPrefixContext calls prefix/function and decodes the response into out.
+// The response is decoded into out without an intermediate JNode.
+// The call is aborted when ctx is cancelled or its deadline expires.
+// Calls held back by a dry run (client.ErrDryRun) are not reported on stderr.
+func ApiCallWithPrefixContext(ctx context.Context, c *client.IngextClient, prefix, function string, payload interface{}, out interface{}) error {
+	err := c.GenericCallInto(contextOrBackground(ctx), prefix, function, payload, out)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error calling %s: %v\n", function, err.Error())
-		return err
-	}
-	if out == nil {
-		return nil
-	}
-	if res == nil {
-		err = fmt.Errorf("empty response from %s", function)
-		fmt.Fprintln(os.Stderr, err.Error())
-		return err
-	}
-	if err := json.Unmarshal(res.GetBytes(), out); err != nil {
-		fmt.Errorf("Error parsing %s response: %v\n", function, err.Error())
+		if !errors.Is(err, client.ErrDryRun) {
+			fmt.Fprintf(os.Stderr, "Error calling %s: %v\n", function, err.Error())
+		}
 		return err
 	}
 	return nil
 }
+
 func ApiCall(client *client.IngextClient, function string, payload interface{}, out interface{}) error {
-	res, err := client.GenericCall("api/ds", function, payload)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error calling %s: %v\n", function, err.Error())
-		return err
-	}
-	if out == nil {
-		return nil
-	}
-	if res == nil {
-		err = fmt.Errorf("empty response from %s", function)
-		fmt.Fprintln(os.Stderr, err.Error())
-		return err
-	}
-	if err := json.Unmarshal(res.GetBytes(), out); err != nil {
-		fmt.Errorf("Error parsing %s response: %v\n", function, err.Error())
-		return err
+	return ApiCallContext(context.Background(), client, function, payload, out)
+}
+
+// ApiCallContext calls an api/ds function and decodes the response into out.
+// The call is aborted when ctx is cancelled or its deadline expires.
+func ApiCallContext(ctx context.Context, client *client.IngextClient, function string, payload interface{}, out interface{}) error {
+	return ApiCallWithPrefixContext(ctx, client, "api/ds", function, payload, out)
+}
+
+// contextOrBackground returns ctx, or context.Background() for services that
+// were never bound with WithContext.
+func contextOrBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
 	}
-	return nil
+	return ctx
 }
 
 func (s *PlatformService) call(function string, payload interface{}, out interface{}) error {
-	return ApiCall(s.client, function, payload, out)
+	return ApiCallContext(s.ctx, s.client, function, payload, out)
 }
 
 // Data source and sink configuration structures.
@@ -386,9 +393,9 @@ type S3BucketConfig struct {
 	AccessKey string `json:"accessKey"`
 }
 
-type AWSUserSecret struct {
-	SecretKey string `json:"secretKey"`
-}
+// AWSUserSecret is the model type, so that its secret key is masked when
+// logged or printed.
+type AWSUserSecret = model.AWSUserSecret
 
 type AWSUserConfig struct {
 	AccessKey string `json:"accessKey"`
@@ -728,6 +735,18 @@ func (s *PlatformService) AddDataSource(entry *model.DataSourceConfig) (*AddData
 	return &resp, nil
 }
 
+// UpdateDataSource replaces a data source entry; entry.ID selects it.
+func (s *PlatformService) UpdateDataSource(entry *model.DataSourceConfig) error {
+	req := &GenericDAORequest[model.DataSourceConfig]{
+		Action: "update",
+		Args: &GenericDAORequestArgs[model.DataSourceConfig]{
+			Id:    entry.ID,
+			Entry: entry,
+		},
+	}
+	return s.call("platform_datasource_dao", req, nil)
+}
+
 // DeleteDataSource removes a data source by id.
 func (s *PlatformService) DeleteDataSource(id string) error {
 	req := &GenericDAORequest[model.DataSourceConfig]{
@@ -739,6 +758,23 @@ func (s *PlatformService) DeleteDataSource(id string) error {
 	return s.call("platform_datasource_dao", req, nil)
 }
 
+// DeleteDataSources deletes the data sources ids with one call each, run as
+// a batch; results are in the order of ids.
+func (s *PlatformService) DeleteDataSources(ids []string, opts *client.BatchOptions) []client.BatchResult {
+	calls := make([]client.BatchCall, len(ids))
+	for i, id := range ids {
+		calls[i] = client.BatchCall{
+			Prefix:   "api/ds",
+			Function: "platform_datasource_dao",
+			Kargs: &GenericDAORequest[model.DataSourceConfig]{
+				Action: "delete",
+				Args:   &GenericDAORequestArgs[model.DataSourceConfig]{Id: id},
+			},
+		}
+	}
+	return s.client.Batch(contextOrBackground(s.ctx), calls, opts)
+}
+
 func (s *PlatformService) ListDataSource() (entries []*model.DataSourceConfig, err error) {
 	req := &GenericDAORequest[model.DataSourceConfig]{
 		Action: "list",
@@ -781,6 +817,18 @@ func (s *PlatformService) AddDataSink(entry *model.DataSinkConfig) (*AddDataSink
 	return &resp, nil
 }
 
+// UpdateDataSink replaces a data sink entry; entry.ID selects it.
+func (s *PlatformService) UpdateDataSink(entry *model.DataSinkConfig) error {
+	req := &GenericDAORequest[model.DataSinkConfig]{
+		Action: "update",
+		Args: &GenericDAORequestArgs[model.DataSinkConfig]{
+			Id:    entry.ID,
+			Entry: entry,
+		},
+	}
+	return s.call("platform_datasink_dao", req, nil)
+}
+
 // DeleteDataSink removes a data sink by id.
 func (s *PlatformService) DeleteDataSink(id string) error {
 	req := &GenericDAORequest[model.DataSinkConfig]{
@@ -859,6 +907,19 @@ func (s *PlatformService) AddRouter(entry *model.RouterConfig) (*AddRouterRespon
 	return &resp, nil
 }
 
+// UpdateRouter replaces a router entry; entry.ID selects it. The entry
+// carries the router's pipeIDs, so start from the one returned by GetRouter.
+func (s *PlatformService) UpdateRouter(entry *model.RouterConfig) error {
+	req := &GenericDAORequest[model.RouterConfig]{
+		Action: "update",
+		Args: &GenericDAORequestArgs[model.RouterConfig]{
+			Id:    entry.ID,
+			Entry: entry,
+		},
+	}
+	return s.call("platform_router_dao", req, nil)
+}
+
 // DeleteRouter removes a router by id.
 func (s *PlatformService) DeleteRouter(id string) error {
 	req := &GenericDAORequest[model.RouterConfig]{
@@ -900,6 +961,18 @@ func (s *PlatformService) AddChannel(entry *model.ChannelConfig) (*AddChannelRes
 	return &resp, nil
 }
 
+// UpdateChannel replaces a channel entry; entry.ID selects it.
+func (s *PlatformService) UpdateChannel(entry *model.ChannelConfig) error {
+	req := &GenericDAORequest[model.ChannelConfig]{
+		Action: "update",
+		Args: &GenericDAORequestArgs[model.ChannelConfig]{
+			Id:    entry.ID,
+			Entry: entry,
+		},
+	}
+	return s.call("platform_channel_dao", req, nil)
+}
+
 // DeleteChannel removes the specified channel configuration.
 func (s *PlatformService) DeleteChannel(id string) error {
 	req := &GenericDAORequest[model.ChannelConfig]{
@@ -1070,6 +1143,24 @@ func (s *PlatformService) ComponentMetrics(req *ComponentMetricReq) (*PlatformMe
 	return &resp, nil
 }
 
+// ComponentMetricsBatch fetches the metrics of several components with one
+// call each, run as a batch. resps[i] holds the metrics of reqs[i] when
+// results[i].Err is nil.
+func (s *PlatformService) ComponentMetricsBatch(reqs []*ComponentMetricReq, opts *client.BatchOptions) (resps []*PlatformMetricsResponse, results []client.BatchResult) {
+	resps = make([]*PlatformMetricsResponse, len(reqs))
+	calls := make([]client.BatchCall, len(reqs))
+	for i, req := range reqs {
+		resps[i] = &PlatformMetricsResponse{}
+		calls[i] = client.BatchCall{
+			Prefix:   "api/ds",
+			Function: "platform_component_metrics",
+			Kargs:    req,
+			Out:      resps[i],
+		}
+	}
+	return resps, s.client.Batch(contextOrBackground(s.ctx), calls, opts)
+}
+
 // ProcessorMetrics fetches metrics scoped to a processor.
 func (s *PlatformService) ProcessorMetrics(req *ProcessorMetricReq) (*PlatformMetricsResponse, error) {
 	var resp PlatformMetricsResponse
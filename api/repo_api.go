@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+
 	"github.com/SecurityDo/ingext_api/client"
 	"github.com/SecurityDo/ingext_api/model"
 	"github.com/google/go-github/v64/github"
@@ -9,6 +11,7 @@ import (
 // PlatformService provides helpers for calling platform_* endpoints.
 type RepoService struct {
 	client *client.IngextClient
+	ctx    context.Context
 }
 
 // NewPlatformService constructs a PlatformService instance backed by the provided client.
@@ -16,8 +19,15 @@ func NewRepoService(client *client.IngextClient) *RepoService {
 	return &RepoService{client: client}
 }
 
+// WithContext returns a copy of the service whose calls are bound to ctx.
+func (s *RepoService) WithContext(ctx context.Context) *RepoService {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
 func (s *RepoService) call(function string, payload interface{}, out interface{}) error {
-	return ApiCall(s.client, function, payload, out)
+	return ApiCallContext(s.ctx, s.client, function, payload, out)
 }
 
 func (s *RepoService) ListRepos() (repos []*model.GithubRepo, err error) {
@@ -68,6 +78,21 @@ func (s *RepoService) ImportRepoProcessors(repoId string, filePaths []string) (e
 	return nil
 }
 
+// ImportRepoProcessorsBatch imports each of filePaths with its own call, run
+// as a batch, so that one bad processor does not fail the others; results are
+// in the order of filePaths.
+func (s *RepoService) ImportRepoProcessorsBatch(repoId string, filePaths []string, opts *client.BatchOptions) []client.BatchResult {
+	calls := make([]client.BatchCall, len(filePaths))
+	for i, path := range filePaths {
+		calls[i] = client.BatchCall{
+			Prefix:   "api/ds",
+			Function: "platform_import_processors",
+			Kargs:    &ImportRepoObjectsRequest{ID: repoId, Paths: []string{path}},
+		}
+	}
+	return s.client.Batch(contextOrBackground(s.ctx), calls, opts)
+}
+
 func (s *RepoService) ImportAppTemplates(repoId string, filePaths []string) (err error) {
 	req := &ImportRepoObjectsRequest{
 		ID:    repoId,
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+
 	"github.com/SecurityDo/ingext_api/client"
 	"github.com/SecurityDo/ingext_api/model"
 )
@@ -8,14 +10,22 @@ import (
 // PlatformService provides helpers for calling platform_* endpoints.
 type ResourceService struct {
 	client *client.IngextClient
+	ctx    context.Context
 }
 
 func NewResourceService(client *client.IngextClient) *ResourceService {
 	return &ResourceService{client: client}
 }
 
+// WithContext returns a copy of the service whose calls are bound to ctx.
+func (s *ResourceService) WithContext(ctx context.Context) *ResourceService {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
 func (s *ResourceService) call(function string, payload interface{}, out interface{}) error {
-	return ApiCall(s.client, function, payload, out)
+	return ApiCallContext(s.ctx, s.client, function, payload, out)
 }
 
 type ResourceSearchRequest struct {
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+
 	"github.com/SecurityDo/ingext_api/client"
 	kqlModel "github.com/SecurityDo/ingext_api/kql/model"
 )
@@ -8,6 +10,7 @@ import (
 // PlatformService provides helpers for calling platform_* endpoints.
 type SearchService struct {
 	client *client.IngextClient
+	ctx    context.Context
 }
 
 // NewPlatformService constructs a PlatformService instance backed by the provided client.
@@ -15,8 +18,15 @@ func NewSearchService(client *client.IngextClient) *SearchService {
 	return &SearchService{client: client}
 }
 
+// WithContext returns a copy of the service whose calls are bound to ctx.
+func (s *SearchService) WithContext(ctx context.Context) *SearchService {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
 func (s *SearchService) call(function string, payload interface{}, out interface{}) error {
-	return ApiCall(s.client, function, payload, out)
+	return ApiCallContext(s.ctx, s.client, function, payload, out)
 }
 
 type KQLSearchRequest struct {
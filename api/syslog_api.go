@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+
 	"github.com/SecurityDo/ingext_api/client"
 	"github.com/SecurityDo/ingext_api/model"
 )
@@ -8,14 +10,22 @@ import (
 // PlatformService provides helpers for calling platform_* endpoints.
 type SyslogService struct {
 	client *client.IngextClient
+	ctx    context.Context
 }
 
 func NewSyslogService(client *client.IngextClient) *SyslogService {
 	return &SyslogService{client: client}
 }
 
+// WithContext returns a copy of the service whose calls are bound to ctx.
+func (s *SyslogService) WithContext(ctx context.Context) *SyslogService {
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
 func (s *SyslogService) call(function string, payload interface{}, out interface{}) error {
-	return ApiCall(s.client, function, payload, out)
+	return ApiCallContext(s.ctx, s.client, function, payload, out)
 }
 
 func (s *SyslogService) Register(ports []string) (resp *model.GetSyslogConfigResponse, err error) {
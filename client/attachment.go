@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	fsb "github.com/SecurityDo/ingext_api/fsb"
+)
+
+// transfer holds the attachments of a call: local files sent with the
+// request and the directory receiving the files of the response.
+type transfer struct {
+	uploads     []*fsb.Attachment
+	uploadSize  int64
+	downloadDir string
+	// response is the envelope of the last successful attempt.
+	response *fsb.CallResponse
+}
+
+func (t *transfer) multipart() bool {
+	return t != nil && len(t.uploads) > 0
+}
+
+// CallWithAttachments is like CallContext but uploads the files of uploads
+// (attachments with a local Path; Name, Id, Type and Hash are filled in when
+// empty) in a multipart request, and saves the attachments of the response
+// in downloadDir after checking their hash. Saved attachments have their
+// Path set in the returned CallResponse. With an empty downloadDir the
+// response attachments are listed but their content is discarded.
+func (r *HTTPService) CallWithAttachments(ctx context.Context, prefix string, functionName string, input interface{}, uploads []*fsb.Attachment, downloadDir string) (*fsb.CallResponse, error) {
+	t := &transfer{uploads: uploads, downloadDir: downloadDir}
+	if len(uploads) > 0 {
+		size, err := fsb.PrepareAttachments(uploads)
+		if err != nil {
+			return nil, &RPCError{Prefix: prefix, Function: functionName, Message: "invalid attachment", Err: err}
+		}
+		t.uploadSize = size
+	}
+	if _, _, err := r.call(ctx, prefix, functionName, input, nil, t); err != nil {
+		return nil, err
+	}
+	return t.response, nil
+}
+
+// startMultipartBody returns a request body streaming the multipart form of
+// the envelope and uploads, its content type, and a function starting the
+// writer. start must be called right before sending the request, so that no
+// writer is left blocked if the call fails earlier.
+func startMultipartBody(envelope []byte, uploads []*fsb.Attachment) (body io.Reader, contentType string, start func()) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	start = func() {
+		go func() {
+			pw.CloseWithError(fsb.WriteMultipart(mw, fsb.RequestPart, envelope, uploads))
+		}()
+	}
+	return pr, mw.FormDataContentType(), start
+}
+
+// decodeEnvelope reads a CallResponse from body. See call for out and present.
+func decodeEnvelope(body io.Reader, out interface{}) (res *fsb.CallResponse, present bool, err error) {
+	if out != nil {
+		return decodeResponseInto(body, out)
+	}
+	res = new(fsb.CallResponse)
+	decoder := json.NewDecoder(body)
+	decoder.UseNumber()
+	if err := decoder.Decode(res); err != nil {
+		return nil, false, err
+	}
+	return res, res.Response != nil, nil
+}
+
+// multipartBoundary returns the boundary of a multipart/* response, or "".
+func multipartBoundary(resp *http.Response) string {
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return ""
+	}
+	return params["boundary"]
+}
+
+// decodeMultipartResponse reads a multipart response: the envelope part is
+// decoded from a reader bounded by limit, then each attachment part is saved
+// in t.downloadDir, or discarded if t has no download directory. n is the
+// size of the envelope.
+func decodeMultipartResponse(body io.Reader, boundary string, limit int64, out interface{}, t *transfer) (res *fsb.CallResponse, present bool, n int64, err error) {
+	mr := multipart.NewReader(body, boundary)
+	part, err := mr.NextPart()
+	if err != nil {
+		return nil, false, 0, err
+	}
+	if name := part.FormName(); name != fsb.ResponsePart {
+		return nil, false, 0, fmt.Errorf("unexpected first part %q, want %q", name, fsb.ResponsePart)
+	}
+	envelope := &limitedReader{r: part, limit: limit}
+	res, present, err = decodeEnvelope(envelope, out)
+	if err != nil {
+		return nil, false, envelope.n, err
+	}
+	// Path is local to each side; only files saved here may set it.
+	for _, a := range res.Attachments {
+		a.Path = ""
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, envelope.n, err
+		}
+		a := fsb.FindAttachment(res.Attachments, part.FormName())
+		if a == nil || t == nil || t.downloadDir == "" {
+			if _, err := io.Copy(io.Discard, part); err != nil {
+				return nil, false, envelope.n, err
+			}
+			continue
+		}
+		if a.Name == "" {
+			a.Name = part.FileName()
+		}
+		if err := fsb.SaveAttachment(part, t.downloadDir, a); err != nil {
+			return nil, false, envelope.n, err
+		}
+	}
+	return res, present, envelope.n, nil
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	fsb "github.com/SecurityDo/ingext_api/fsb"
+	"golang.org/x/time/rate"
+)
+
+// DefaultBatchConcurrency is the number of calls of a batch run at the same
+// time unless BatchOptions.Concurrency is set.
+const DefaultBatchConcurrency = 4
+
+// ErrBatchAborted is the error of the calls of a batch that were not sent
+// because an earlier call failed with BatchOptions.StopOnError set. It is
+// returned as is, not as an *RPCError: nothing was sent, so it has no kind.
+var ErrBatchAborted = errors.New("batch aborted after an earlier failure")
+
+// BatchCall is one call of a batch.
+type BatchCall struct {
+	Prefix   string
+	Function string
+	Kargs    interface{}
+	// Out, if not nil, receives the decoded response as with GenericCallInto;
+	// otherwise the response is returned in BatchResult.Response.
+	Out interface{}
+}
+
+// BatchResult is the outcome of the BatchCall with the same index.
+type BatchResult struct {
+	Index    int
+	Response *fsb.JNode
+	Err      error
+}
+
+// BatchOptions controls how a batch is run. The zero value runs
+// DefaultBatchConcurrency calls at a time without a rate limit.
+type BatchOptions struct {
+	// Concurrency is the number of workers (DefaultBatchConcurrency if <= 0).
+	Concurrency int
+	// Rate limits the batch to that many calls per second (no limit if <= 0).
+	Rate float64
+	// Burst is the number of calls allowed above Rate at once (1 if <= 0).
+	Burst int
+	// StopOnError skips the calls not yet started once a call fails; they
+	// get ErrBatchAborted.
+	StopOnError bool
+}
+
+// Batch runs calls with a bounded pool of workers and returns one result per
+// call, in the order of calls. Each call is retried on its own like any other
+// call. Cancelling ctx fails the calls not yet completed, with an RPCError
+// wrapping the context error.
+func (r *IngextClient) Batch(ctx context.Context, calls []BatchCall, opts *BatchOptions) []BatchResult {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = DefaultBatchConcurrency
+	}
+	if workers > len(calls) {
+		workers = len(calls)
+	}
+	var limiter *rate.Limiter
+	if opts.Rate > 0 {
+		burst := opts.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		limiter = rate.NewLimiter(rate.Limit(opts.Rate), burst)
+	}
+
+	// stop is closed on the first failure when StopOnError is set.
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+
+	results := make([]BatchResult, len(calls))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				select {
+				case <-stop:
+					results[i] = BatchResult{Index: i, Err: ErrBatchAborted}
+					continue
+				default:
+				}
+				results[i] = r.batchCall(ctx, i, &calls[i], limiter)
+				if results[i].Err != nil && opts.StopOnError {
+					stopOnce.Do(func() { close(stop) })
+				}
+			}
+		}()
+	}
+	sent := 0
+feed:
+	for ; sent < len(calls); sent++ {
+		select {
+		case next <- sent:
+		case <-stop:
+			break feed
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	for i := sent; i < len(calls); i++ {
+		if ctx.Err() != nil {
+			results[i] = abortedResult(i, &calls[i], ctx.Err())
+		} else {
+			results[i] = BatchResult{Index: i, Err: ErrBatchAborted}
+		}
+	}
+	return results
+}
+
+func abortedResult(i int, c *BatchCall, err error) BatchResult {
+	return BatchResult{Index: i, Err: &RPCError{Prefix: c.Prefix, Function: c.Function, Err: err}}
+}
+
+func (r *IngextClient) batchCall(ctx context.Context, i int, c *BatchCall, limiter *rate.Limiter) BatchResult {
+	res := BatchResult{Index: i}
+	if limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			return abortedResult(i, c, err)
+		}
+	}
+	if c.Out != nil {
+		res.Err = r.GenericCallInto(ctx, c.Prefix, c.Function, c.Kargs, c.Out)
+	} else {
+		res.Response, res.Err = r.GenericCallContext(ctx, c.Prefix, c.Function, c.Kargs)
+	}
+	return res
+}
+
+// BatchErrors joins the errors of results, each prefixed with its index, or
+// returns nil if every call succeeded.
+func BatchErrors(results []BatchResult) error {
+	var errs []error
+	for _, res := range results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("call %d: %w", res.Index, res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
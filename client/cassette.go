@@ -0,0 +1,308 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrNoInteraction is returned in replay mode when the cassette holds no
+// recorded interaction for a call.
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+const cassetteVersion = 1
+
+// Cassette is a recorded sequence of fsb calls, stored as JSON by WithRecord
+// and served back by WithReplay.
+type Cassette struct {
+	Version      int            `json:"version"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded HTTP round trip. Request and Response hold the
+// CallRequest and CallResponse envelopes with sensitive values masked by the
+// client's Redactor; the Authorization header is never recorded. Body holds
+// a response that is not plain JSON (e.g. a multipart response with
+// attachments), unredacted.
+type Interaction struct {
+	Prefix      string          `json:"prefix"`
+	Function    string          `json:"function"`
+	Request     json.RawMessage `json:"request,omitempty"`
+	Status      int             `json:"status"`
+	ContentType string          `json:"contentType,omitempty"`
+	Response    json.RawMessage `json:"response,omitempty"`
+	Body        []byte          `json:"body,omitempty"`
+}
+
+// LoadCassette reads a cassette written by WithRecord.
+func LoadCassette(path string) (*Cassette, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	if c.Version != cassetteVersion {
+		return nil, fmt.Errorf("unsupported cassette version %d in %s", c.Version, path)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to path, replacing it atomically.
+func (c *Cassette) Save(path string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// WithRecord records every call made by the client to the cassette file at
+// path, rewritten after each call so that it is complete even if the process
+// is interrupted. Secrets are masked with the client's Redactor
+// (DefaultRedactor unless set with WithRedactor).
+func WithRecord(path string) Option {
+	return func(o *options) { o.recordPath = path }
+}
+
+// WithReplay serves every call from the cassette file at path instead of the
+// network. A call is answered by the first unused interaction with the same
+// prefix, function and request, or else by the next unused interaction with
+// the same prefix and function (so that requests embedding the current time
+// still replay); calls without one fail with an error wrapping
+// ErrNoInteraction.
+func WithReplay(path string) Option {
+	return func(o *options) { o.replayPath = path }
+}
+
+// recorder is the RoundTripper installed by WithRecord.
+type recorder struct {
+	next     http.RoundTripper
+	path     string
+	redactor *Redactor
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+func newRecorder(next http.RoundTripper, path string, redactor *Redactor) *recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recorder{next: next, path: path, redactor: redactor, cassette: Cassette{Version: cassetteVersion}}
+}
+
+func (rec *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	envelope, err := requestEnvelope(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := rec.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := decodeContentEncoding(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+
+	prefix, function := splitPath(req.URL.Path)
+	it := &Interaction{
+		Prefix:      prefix,
+		Function:    function,
+		Request:     rec.scrub(envelope),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if json.Valid(body) {
+		it.Response = rec.scrub(body)
+	} else {
+		it.Body = body
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.cassette.Interactions = append(rec.cassette.Interactions, it)
+	if err := rec.cassette.Save(rec.path); err != nil {
+		return nil, fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return resp, nil
+}
+
+func (rec *recorder) scrub(b []byte) json.RawMessage {
+	if len(b) == 0 || !json.Valid(b) {
+		return nil
+	}
+	if rec.redactor != nil {
+		b = rec.redactor.RedactJSON(b)
+	}
+	return json.RawMessage(b)
+}
+
+// replayer is the RoundTripper installed by WithReplay.
+type replayer struct {
+	redactor *Redactor
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         []bool
+}
+
+func newReplayer(path string, redactor *Redactor) (*replayer, error) {
+	c, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return &replayer{redactor: redactor, interactions: c.Interactions, used: make([]bool, len(c.Interactions))}, nil
+}
+
+func (rp *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	envelope, err := requestEnvelope(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	if rp.redactor != nil {
+		envelope = rp.redactor.RedactJSON(envelope)
+	}
+	want := canonicalJSON(envelope)
+	prefix, function := splitPath(req.URL.Path)
+
+	rp.mu.Lock()
+	found := -1
+	for i, it := range rp.interactions {
+		if rp.used[i] || it.Prefix != prefix || it.Function != function {
+			continue
+		}
+		if canonicalJSON(it.Request) == want {
+			found = i
+			break
+		}
+		if found < 0 {
+			found = i
+		}
+	}
+	if found >= 0 {
+		rp.used[found] = true
+	}
+	rp.mu.Unlock()
+	if found < 0 {
+		return nil, fmt.Errorf("%w for %s/%s", ErrNoInteraction, prefix, function)
+	}
+
+	it := rp.interactions[found]
+	body := it.Body
+	if it.Response != nil {
+		body = it.Response
+	}
+	header := http.Header{}
+	if it.ContentType != "" {
+		header.Set("Content-Type", it.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", it.Status, http.StatusText(it.Status)),
+		StatusCode:    it.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// requestEnvelope returns the CallRequest JSON sent by req, undoing gzip and
+// taking the envelope part of a multipart body. The body of req is replaced
+// by a buffered copy so it can still be sent.
+func requestEnvelope(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	raw, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(raw))
+	req.ContentLength = int64(len(raw))
+
+	body := raw
+	if strings.EqualFold(req.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		if body, err = io.ReadAll(zr); err != nil {
+			return nil, err
+		}
+	}
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		part, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).NextPart()
+		if err != nil {
+			return nil, err
+		}
+		if body, err = io.ReadAll(part); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// canonicalJSON re-encodes b with sorted keys so that equal documents compare
+// equal; invalid JSON is returned as is.
+func canonicalJSON(b []byte) string {
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return string(b)
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
+
+// splitPath splits "/api/ds/kql_search" into ("api/ds", "kql_search").
+func splitPath(path string) (prefix, function string) {
+	path = strings.Trim(path, "/")
+	idx := strings.LastIndex(path, "/")
+	if idx < 0 {
+		return "", path
+	}
+	return path[:idx], path[idx+1:]
+}
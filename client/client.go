@@ -2,14 +2,14 @@ package client
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httputil"
-	"os"
+	"sync/atomic"
 	"time"
 
 	fsb "github.com/SecurityDo/ingext_api/fsb"
@@ -18,27 +18,46 @@ import (
 type HTTPService struct {
 	url       string
 	client    *http.Client
+	transport *http.Transport
 	DebugFlag bool
 	token     string
 	logger    *slog.Logger
+	retry     *RetryPolicy
+	userAgent string
+	headers   http.Header
+
+	interceptors    []Interceptor
+	redactor        *Redactor
+	maxResponseSize int64
+
+	// noCompression disables Accept-Encoding and request compression;
+	// gzipRequestsFrom gzips request bodies of at least that many bytes (0: never).
+	noCompression    bool
+	gzipRequestsFrom int
+
+	// throttle enforces the RateLimit, if any (see SetRateLimit). It is
+	// replaced while calls are in flight, hence atomic.
+	throttle atomic.Pointer[throttle]
 }
 
 func NewHTTPService(url string, logger *slog.Logger) *HTTPService {
+	// Cannot fail: no TLS files to load.
+	s, _ := newHTTPService(url, &options{logger: logger})
+	return s
+}
 
-	s := &HTTPService{
-		url:    url,
-		logger: logger,
-	}
-	tr := &http.Transport{
-		TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
-		TLSHandshakeTimeout: 10 * time.Second,
+// SetTLSConfig replaces the TLS settings used for new connections.
+func (r *HTTPService) SetTLSConfig(c *TLSConfig) error {
+	if r.transport == nil {
+		return errCustomTransport
 	}
-	timeout := time.Duration(600 * time.Second)
-	s.client = &http.Client{
-		Transport: tr,
-		Timeout:   timeout,
+	tlsConfig, err := c.Build()
+	if err != nil {
+		return err
 	}
-	return s
+	r.transport.TLSClientConfig = tlsConfig
+	r.transport.CloseIdleConnections()
+	return nil
 }
 
 func (r *HTTPService) Close() {
@@ -57,6 +76,42 @@ func (r *HTTPService) SetToken(token string) {
 }
 
 func (r *HTTPService) Call(prefix string, functionName string, input interface{}) (result *fsb.JNode, err error) {
+	return r.CallContext(context.Background(), prefix, functionName, input)
+}
+
+// CallContext is like Call but binds the HTTP request to ctx, so the call is
+// aborted as soon as ctx is cancelled or its deadline expires.
+func (r *HTTPService) CallContext(ctx context.Context, prefix string, functionName string, input interface{}) (result *fsb.JNode, err error) {
+	result, _, err = r.call(ctx, prefix, functionName, input, nil, nil)
+	return result, err
+}
+
+// CallInto is like CallContext but decodes the "response" field of the reply
+// directly into out (a pointer, as for json.Unmarshal) instead of returning
+// an intermediate JNode. The envelope is read as a stream, but the response
+// value is held in memory once while it is decoded.
+// It returns an error wrapping ErrEmptyResponse if the response is missing
+// or null.
+func (r *HTTPService) CallInto(ctx context.Context, prefix string, functionName string, input interface{}, out interface{}) error {
+	if out == nil {
+		_, _, err := r.call(ctx, prefix, functionName, input, nil, nil)
+		return err
+	}
+	_, present, err := r.call(ctx, prefix, functionName, input, out, nil)
+	if err == nil && !present {
+		return fmt.Errorf("empty response from %s/%s: %w", prefix, functionName, ErrEmptyResponse)
+	}
+	return err
+}
+
+// call sends the request with retries. When out is nil the response is
+// returned as a JNode, otherwise it is decoded into out and present reports
+// whether the reply carried a non-null response. t, if not nil, holds the
+// attachments of the call and receives the response envelope.
+func (r *HTTPService) call(ctx context.Context, prefix string, functionName string, input interface{}, out interface{}, t *transfer) (result *fsb.JNode, present bool, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	remoteReq := new(fsb.CallRequest)
 	remoteReq.Function = functionName
 
@@ -64,75 +119,234 @@ func (r *HTTPService) Call(prefix string, functionName string, input interface{}
 		remoteReq.Kargs = fsb.NewJNodeString("{}")
 	} else {
 		remoteReq.Kargs, _ = fsb.NewJNodeInterface(input)
+		// A typed nil (e.g. CallTyped with a nil *Req) marshals to null.
+		if remoteReq.Kargs != nil && string(remoteReq.Kargs.GetBytes()) == "null" {
+			remoteReq.Kargs = fsb.NewJNodeString("{}")
+		}
+	}
+	if t.multipart() {
+		remoteReq.Attachments = fsb.WireAttachments(t.uploads)
 	}
 
 	reqStr, err := json.Marshal(remoteReq)
 	if err != nil {
-		return nil, fmt.Errorf("args is not a valid json structure")
+		return nil, false, fmt.Errorf("args is not a valid json structure")
 	}
 
 	fullUrl := fmt.Sprintf("%s/%s/%s", r.url, prefix, functionName)
 	if prefix == "" {
 		fullUrl = fmt.Sprintf("%s/%s", r.url, functionName)
 	}
-	req, err := http.NewRequest("POST", fullUrl, bytes.NewBuffer(reqStr))
-	req.Header.Set("Content-Type", "application/json")
+
+	var gzipped []byte
+	// Multipart bodies are not compressed: attachments are usually compressed already.
+	if !t.multipart() && !r.noCompression && r.gzipRequestsFrom > 0 && len(reqStr) >= r.gzipRequestsFrom {
+		if gzipped, err = gzipBytes(reqStr); err != nil {
+			return nil, false, fmt.Errorf("failed to compress request: %w", err)
+		}
+	}
+
+	call := &Call{
+		Prefix:   prefix,
+		Function: functionName,
+		URL:      fullUrl,
+		Request:  remoteReq,
+		ReadOnly: IsReadOnlyCall(functionName, remoteReq.Kargs),
+	}
+	policy := r.retryPolicy()
+	canRetry := policy.shouldRetryCall(functionName, remoteReq.Kargs)
+	for attempt := 1; ; attempt++ {
+		call.Attempt = attempt
+		result, present, err := r.callOnce(ctx, call, reqStr, gzipped, out, t)
+		if err == nil || !canRetry || attempt >= policy.MaxAttempts || !policy.isTransient(err) {
+			return result, present, err
+		}
+		retryAfter := err.(*RPCError).retryAfter
+		delay, ok := policy.backoff(attempt, retryAfter)
+		if !ok {
+			r.logger.Warn("server requested a retry delay beyond the retry policy limit", "prefix", prefix, "functionName", functionName, "retryAfter", retryAfter)
+			return result, present, err
+		}
+		r.logger.Warn("transient RPC failure, retrying", "prefix", prefix, "functionName", functionName, "attempt", attempt, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, false, &RPCError{Prefix: prefix, Function: functionName, URL: fullUrl, Err: ctx.Err()}
+		case <-timer.C:
+		}
+	}
+}
+
+// callOnce performs a single HTTP round trip, running the interceptor chain
+// around it. RPC failures are returned as *RPCError so callers can inspect
+// them with errors.As / errors.Is; an interceptor error is returned as is.
+// gzipped, if not nil, is the compressed form of reqStr to send instead.
+// See call for the meaning of out, present and t.
+func (r *HTTPService) callOnce(ctx context.Context, call *Call, reqStr []byte, gzipped []byte, out interface{}, t *transfer) (result *fsb.JNode, present bool, err error) {
+	prefix, functionName, fullUrl := call.Prefix, call.Function, call.URL
+	release, err := r.throttle.Load().acquire(ctx)
+	if err != nil {
+		return nil, false, &RPCError{Prefix: prefix, Function: functionName, URL: fullUrl, Err: err}
+	}
+	defer release()
+	var reqBody io.Reader
+	contentType := "application/json"
+	startBody := func() {}
+	switch {
+	case t.multipart():
+		reqBody, contentType, startBody = startMultipartBody(reqStr, t.uploads)
+	case gzipped != nil:
+		reqBody = bytes.NewReader(gzipped)
+	default:
+		reqBody = bytes.NewReader(reqStr)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", fullUrl, reqBody)
+	if err != nil {
+		return nil, false, &RPCError{Prefix: prefix, Function: functionName, URL: fullUrl, Err: err}
+	}
+	for k, vs := range r.headers {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if r.userAgent != "" {
+		req.Header.Set("User-Agent", r.userAgent)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if gzipped != nil {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if !r.noCompression {
+		// Setting Accept-Encoding ourselves disables the transport's implicit
+		// gzip handling; decodeContentEncoding undoes gzip and deflate below.
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
 	if r.token != "" {
 		// bearer token
 		req.Header.Set("Authorization", "Bearer "+r.token)
 	}
 
+	call.Started = time.Now()
+	call.HTTPRequest = req
+	call.HTTPResponse = nil
+	call.RequestSize = int64(len(reqStr))
+	if t.multipart() {
+		call.RequestSize += t.uploadSize
+	}
+	call.ResponseSize = 0
+	for k, ic := range r.interceptors {
+		if err := ic.BeforeRequest(ctx, call); err != nil {
+			// Let the interceptors that already ran close the attempt.
+			for i := k - 1; i >= 0; i-- {
+				r.interceptors[i].AfterResponse(ctx, call, nil, err)
+			}
+			return nil, false, err
+		}
+	}
+	var res *fsb.CallResponse
+	defer func() {
+		for i := len(r.interceptors) - 1; i >= 0; i-- {
+			r.interceptors[i].AfterResponse(ctx, call, res, err)
+		}
+	}()
+
 	if r.DebugFlag {
 		if dump, err := httputil.DumpRequestOut(req, false); err == nil {
 			r.logger.Debug("Request:\n-----------------------------------------\n")
-			r.logger.Debug(string(dump))
-			pretty, _ := json.MarshalIndent(remoteReq, "", "   ")
-			r.logger.Debug(string(pretty))
+			r.debugDump(dump)
+			pretty, _ := json.MarshalIndent(call.Request, "", "   ")
+			r.debugDump(pretty)
 		}
 	}
+	startBody()
 	resp, err := r.client.Do(req)
 	if err != nil {
-		r.logger.Error("Failed to call local http service %s: %s\n", r.url, err.Error())
-		return result, fmt.Errorf("failed to call local http service %s: %s", r.url, err.Error())
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			r.logger.Debug("RPC call cancelled", "prefix", prefix, "functionName", functionName, "error", ctxErr)
+			return result, false, &RPCError{Prefix: prefix, Function: functionName, URL: fullUrl, Err: ctxErr}
+		}
+		r.logger.Error("Failed to call http service", "url", r.url, "error", err.Error())
+		return result, false, &RPCError{Prefix: prefix, Function: functionName, URL: fullUrl, Err: err}
 	}
 	defer resp.Body.Close()
+	call.HTTPResponse = resp
+	if err := decodeContentEncoding(resp); err != nil {
+		return result, false, &RPCError{Prefix: prefix, Function: functionName, URL: fullUrl, HTTPStatus: resp.StatusCode, Message: "invalid response body", Err: err}
+	}
 	if r.DebugFlag {
 		if dump, err := httputil.DumpResponse(resp, true); err == nil {
 			r.logger.Debug("Response:\n-----------------------------------------\n")
-			r.logger.Debug(string(dump))
+			r.debugDump(dump)
 		}
 	}
-	body, _ := io.ReadAll(resp.Body)
-	//fmt.Println("response Body:", string(body))
 	if resp.StatusCode != 200 {
-		r.logger.Error("HTTP ERROR from local http service %s: %s\n", r.url, resp.Status)
-		return result, fmt.Errorf("HTTP Error from Local HTTP service %s: %s", r.url, resp.Status)
-	}
-	var res fsb.CallResponse
-	//var obj map[string]interface{}
-	//err = json.Unmarshal(body,&res)
-	decoder := json.NewDecoder(bytes.NewReader(body))
-	decoder.UseNumber()
-	err = decoder.Decode(&res)
+		r.logger.Error("HTTP ERROR from http service", "url", r.url, "status", resp.Status)
+		return result, false, newHTTPStatusError(prefix, functionName, fullUrl, resp)
+	}
+	// Decode straight from the body, bounded by maxResponseSize. Only the
+	// envelope of a multipart response is bounded, not its attachments.
+	if boundary := multipartBoundary(resp); boundary != "" {
+		res, present, call.ResponseSize, err = decodeMultipartResponse(resp.Body, boundary, r.maxResponseSize, out, t)
+	} else {
+		body := &limitedReader{r: resp.Body, limit: r.maxResponseSize}
+		res, present, err = decodeEnvelope(body, out)
+		call.ResponseSize = body.n
+	}
 	if err != nil {
+		res = nil
 		r.logger.Error("Failed to parse response body -> ", "Error", err.Error())
-		return result, fmt.Errorf("HTTP Error from Local HTTP service: %s", resp.Status)
+		return result, false, &RPCError{Prefix: prefix, Function: functionName, URL: fullUrl, HTTPStatus: resp.StatusCode, Message: "invalid response body", Err: err}
 	}
 	if r.DebugFlag {
 		pretty, _ := json.MarshalIndent(res, "", "   ")
-		r.logger.Debug(string(pretty))
+		r.debugDump(pretty)
 	}
 
-	if res.Verdict == "ERROR" {
+	if res.Verdict == VerdictError {
 		r.logger.Error("RPC call return with ERROR", "prefix", prefix, "functionName", functionName, "Error", res.Error)
-		return result, fmt.Errorf("RPC call return with ERROR: %s", res.Error)
-	} else if res.Verdict == "EXCEPTION" {
+		return result, false, &RPCError{Prefix: prefix, Function: functionName, URL: fullUrl, HTTPStatus: resp.StatusCode, Verdict: res.Verdict, Message: res.Error}
+	} else if res.Verdict == VerdictException {
 		r.logger.Debug("RPC call return with EXCEPTION: ", "exception", res.Exception)
-		return result, fmt.Errorf("RPC call return with EXCEPTION: %s", res.Exception)
+		return result, false, &RPCError{Prefix: prefix, Function: functionName, URL: fullUrl, HTTPStatus: resp.StatusCode, Verdict: res.Verdict, Message: res.Exception}
+	}
+	if t != nil {
+		t.response = res
+	}
+
+	return res.Response, present, nil
+
+}
+
+// debugDump logs an HTTP dump at debug level after masking secrets and
+// passing it through every interceptor that implements DumpFilter.
+func (r *HTTPService) debugDump(dump []byte) {
+	if r.redactor != nil {
+		dump = r.redactor.FilterDump(dump)
+	}
+	for _, ic := range r.interceptors {
+		if f, ok := ic.(DumpFilter); ok {
+			dump = f.FilterDump(dump)
+		}
 	}
+	r.logger.Debug(string(dump))
+}
 
-	return res.Response, nil
+// Use appends interceptors to the chain run around every HTTP attempt.
+func (r *HTTPService) Use(interceptors ...Interceptor) {
+	r.interceptors = append(r.interceptors, interceptors...)
+}
 
+// SetRetryPolicy replaces the retry policy; nil restores DefaultRetryPolicy.
+func (r *HTTPService) SetRetryPolicy(p *RetryPolicy) {
+	r.retry = p
+}
+
+func (r *HTTPService) retryPolicy() *RetryPolicy {
+	if r.retry == nil {
+		return DefaultRetryPolicy()
+	}
+	return r.retry
 }
 
 type IngextClient struct {
@@ -140,33 +354,88 @@ type IngextClient struct {
 	logger        *slog.Logger
 }
 
+// NewIngextClient creates a client with the default transport. Use
+// NewIngextClientWithOptions to customize transport, timeout, headers or TLS.
 func NewIngextClient(siteURL string, token string, debugFlag bool, logger *slog.Logger) *IngextClient {
-	if logger == nil {
-		// Using os.Stderr by default is safe for libraries
-		logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
-	}
+	// Cannot fail: none of these options load files.
+	s, _ := NewIngextClientWithOptions(siteURL, token, WithDebug(debugFlag), WithLogger(logger))
+	return s
+}
 
-	s := &IngextClient{
-		serviceClient: NewHTTPService(siteURL, logger),
-		logger:        logger,
+func (r *IngextClient) SetDebug(debug bool) {
+	r.serviceClient.DebugFlag = debug
+}
+
+// SetTLSConfig configures server certificate verification and the optional
+// client certificate. TLS verification is on unless InsecureSkipVerify is set.
+func (r *IngextClient) SetTLSConfig(c *TLSConfig) error {
+	if c != nil && c.InsecureSkipVerify {
+		r.logger.Warn("TLS certificate verification is disabled", "url", r.serviceClient.GetUrl())
 	}
-	s.serviceClient.DebugFlag = debugFlag
-	s.serviceClient.SetToken(token)
+	return r.serviceClient.SetTLSConfig(c)
+}
 
-	return s
+// SetProxy replaces the proxy setting used for new connections; see WithProxy.
+func (r *IngextClient) SetProxy(rawURL string) error {
+	return r.serviceClient.SetProxy(rawURL)
+}
 
+// Use appends interceptors run around every HTTP attempt of every call; see
+// Interceptor.
+func (r *IngextClient) Use(interceptors ...Interceptor) {
+	r.serviceClient.Use(interceptors...)
 }
 
-func (r *IngextClient) SetDebug(debug bool) {
-	r.serviceClient.DebugFlag = debug
+// SetRetryPolicy configures how transient failures are retried; nil restores
+// DefaultRetryPolicy (read-only calls only).
+func (r *IngextClient) SetRetryPolicy(p *RetryPolicy) {
+	r.serviceClient.SetRetryPolicy(p)
 }
 
 func (r *IngextClient) GenericCall(prefix string, functionName string, x interface{}) (res *fsb.JNode, err error) {
-	res, err = r.serviceClient.Call(prefix, functionName, x)
+	return r.GenericCallContext(context.Background(), prefix, functionName, x)
+}
 
+// GenericCallInto calls prefix/functionName and decodes the response straight
+// into out, without an intermediate JNode; see HTTPService.CallInto.
+func (r *IngextClient) GenericCallInto(ctx context.Context, prefix string, functionName string, x interface{}, out interface{}) error {
+	err := r.serviceClient.CallInto(ctx, prefix, functionName, x, out)
 	if err != nil {
-		fmt.Printf("%s/%s/%s call failed", r.serviceClient.GetUrl(), prefix, functionName)
+		r.logger.Debug("call failed", "url", r.serviceClient.GetUrl(), "prefix", prefix, "functionName", functionName, "error", err)
+	}
+	return err
+}
+
+// CallTyped calls prefix/function with req as kargs and decodes the response
+// into a new Resp; numbers in interface{} values are json.Number. A null
+// response is reported as ErrEmptyResponse.
+//
+//	users, err := client.CallTyped[any, ListUserResponse](ctx, c, "api/auth", "userList", nil)
+func CallTyped[Req, Resp any](ctx context.Context, c *IngextClient, prefix, function string, req Req) (Resp, error) {
+	var out Resp
+	err := c.GenericCallInto(ctx, prefix, function, req, &out)
+	return out, err
+}
+
+// GenericCallContext is like GenericCall but aborts the call when ctx is done.
+func (r *IngextClient) GenericCallContext(ctx context.Context, prefix string, functionName string, x interface{}) (res *fsb.JNode, err error) {
+	res, err = r.serviceClient.CallContext(ctx, prefix, functionName, x)
+
+	if err != nil {
+		r.logger.Debug("call failed", "url", r.serviceClient.GetUrl(), "prefix", prefix, "functionName", functionName, "error", err)
 		return res, err
 	}
 	return res, err
 }
+
+// GenericCallWithAttachments uploads the local files of uploads with the call
+// and saves the attachments of the response in downloadDir. See
+// HTTPService.CallWithAttachments.
+func (r *IngextClient) GenericCallWithAttachments(ctx context.Context, prefix string, functionName string, x interface{}, uploads []*fsb.Attachment, downloadDir string) (*fsb.CallResponse, error) {
+	res, err := r.serviceClient.CallWithAttachments(ctx, prefix, functionName, x, uploads, downloadDir)
+	if err != nil {
+		r.logger.Debug("call failed", "url", r.serviceClient.GetUrl(), "prefix", prefix, "functionName", functionName, "error", err)
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,685 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	fsb "github.com/SecurityDo/ingext_api/fsb"
+)
+
+func TestHTTPService_CallContextCancelled(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(ts.Close)
+	t.Cleanup(func() { close(release) })
+
+	c := NewIngextClient(ts.URL, "", false, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.GenericCallContext(ctx, "api/ds", "kql_search", nil)
+	if err == nil {
+		t.Fatalf("expected error from cancelled call")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("call was not aborted promptly")
+	}
+}
+
+// flakyServer fails the first `failures` requests with status, then answers OK.
+func flakyServer(t *testing.T, failures int32, status int) (*httptest.Server, *int32) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"verdict":"OK","response":{"ok":true}}`))
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &calls
+}
+
+func fastRetryPolicy() *RetryPolicy {
+	p := DefaultRetryPolicy()
+	p.InitialBackoff = time.Millisecond
+	p.MaxBackoff = 5 * time.Millisecond
+	return p
+}
+
+func TestHTTPService_RetriesReadOnlyCalls(t *testing.T) {
+	ts, calls := flakyServer(t, 2, http.StatusServiceUnavailable)
+	c := NewIngextClient(ts.URL, "", false, nil)
+	c.SetRetryPolicy(fastRetryPolicy())
+
+	if _, err := c.GenericCall("api/ds", "platform_list_configs", nil); err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestHTTPService_DoesNotRetryMutatingCalls(t *testing.T) {
+	ts, calls := flakyServer(t, 1, http.StatusBadGateway)
+	c := NewIngextClient(ts.URL, "", false, nil)
+	c.SetRetryPolicy(fastRetryPolicy())
+
+	add := map[string]interface{}{"action": "add", "args": map[string]interface{}{}}
+	if _, err := c.GenericCall("api/ds", "platform_datasource_dao", add); err == nil {
+		t.Fatalf("expected mutating call to fail without retry")
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Fatalf("expected 1 attempt, got %d", got)
+	}
+
+	p := fastRetryPolicy()
+	p.RetryMutating = true
+	c.SetRetryPolicy(p)
+	if _, err := c.GenericCall("api/ds", "platform_datasource_dao", add); err != nil {
+		t.Fatalf("expected opt-in retry to succeed, got %v", err)
+	}
+}
+
+func TestIsReadOnlyCall(t *testing.T) {
+	list, _ := fsb.NewJNodeInterface(map[string]interface{}{"action": "list"})
+	del, _ := fsb.NewJNodeInterface(map[string]interface{}{"action": "delete"})
+	cases := []struct {
+		fn   string
+		args *fsb.JNode
+		want bool
+	}{
+		{"platform_list_datasource", nil, true},
+		{"kql_search", nil, true},
+		{"getUser", nil, true},
+		{"userList", nil, true},
+		{"platform_datasource_dao", list, true},
+		{"platform_datasource_dao", del, false},
+		{"userAdd", nil, false},
+		{"platform_router_add_pipe", nil, false},
+		{"platform_component_metrics", nil, true},
+		{"ingext_syslog_get_config", nil, true},
+		{"platform_set_router_state", nil, false},
+		{"update_status", nil, false},
+		{"save_info", nil, false},
+		{"platform_clear_component_error", nil, false},
+		{"platform_import_device_states", nil, true},
+		{"platform_import_processors", nil, false},
+		{"resetState", nil, false},
+	}
+	for _, tc := range cases {
+		if got := IsReadOnlyCall(tc.fn, tc.args); got != tc.want {
+			t.Errorf("IsReadOnlyCall(%q) = %v, want %v", tc.fn, got, tc.want)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := parseRetryAfter("7", now); got != 7*time.Second {
+		t.Errorf("seconds: got %v", got)
+	}
+	if got := parseRetryAfter(now.Add(3*time.Second).Format(http.TimeFormat), now); got != 3*time.Second {
+		t.Errorf("http-date: got %v", got)
+	}
+	if got := parseRetryAfter("soon", now); got != 0 {
+		t.Errorf("invalid: got %v", got)
+	}
+}
+
+func TestRPCError_Classification(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/ds/missing":
+			w.Write([]byte(`{"verdict":"ERROR","error":"datasource abc not found"}`))
+		case "/api/ds/boom":
+			w.Write([]byte(`{"verdict":"EXCEPTION","exception":"NullPointerException"}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	c := NewIngextClient(ts.URL, "", false, nil)
+
+	_, err := c.GenericCall("api/ds", "missing", nil)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("expected *RPCError, got %T", err)
+	}
+	if rpcErr.Verdict != VerdictError || rpcErr.Function != "missing" || rpcErr.Prefix != "api/ds" {
+		t.Errorf("unexpected error fields: %+v", rpcErr)
+	}
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrNotFound only, got kind %q", ErrorKindOf(err))
+	}
+
+	_, err = c.GenericCall("api/ds", "boom", nil)
+	if !errors.Is(err, ErrException) || !errors.Is(err, ErrServer) {
+		t.Errorf("expected exception, got kind %q", ErrorKindOf(err))
+	}
+
+	_, err = c.GenericCall("api/ds", "secret", nil)
+	var apiErr fsb.ApiError
+	if !errors.Is(err, ErrUnauthorized) || !errors.As(err, &apiErr) || apiErr.Code != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized HTTP error, got %v", err)
+	}
+}
+
+func TestHTTPService_TLSVerification(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"verdict":"OK","response":{}}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	c := NewIngextClient(ts.URL, "", false, nil)
+	c.SetRetryPolicy(NoRetryPolicy())
+	if _, err := c.GenericCall("api/ds", "platform_list_configs", nil); err == nil {
+		t.Fatalf("expected certificate verification failure for self-signed server")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetTLSConfig(&TLSConfig{CAFile: caFile}); err != nil {
+		t.Fatalf("SetTLSConfig: %v", err)
+	}
+	if _, err := c.GenericCall("api/ds", "platform_list_configs", nil); err != nil {
+		t.Fatalf("expected success with CA file, got %v", err)
+	}
+
+	if err := c.SetTLSConfig(&TLSConfig{CertFile: caFile}); err == nil {
+		t.Fatalf("expected error for client certificate without key")
+	}
+}
+
+type recordingTransport struct {
+	calls int
+	next  http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls++
+	return t.next.RoundTrip(req)
+}
+
+func TestNewIngextClientWithOptions(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{"verdict":"OK","response":{}}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	rt := &recordingTransport{next: http.DefaultTransport}
+	c, err := NewIngextClientWithOptions(ts.URL, "tok",
+		WithTransport(rt),
+		WithTimeout(5*time.Second),
+		WithUserAgent("ingext-test/1.0"),
+		WithHeaders(http.Header{"X-Tenant": []string{"acme"}}),
+	)
+	if err != nil {
+		t.Fatalf("NewIngextClientWithOptions: %v", err)
+	}
+	if _, err := c.GenericCall("api/ds", "platform_list_configs", nil); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if rt.calls != 1 {
+		t.Errorf("expected custom transport to be used once, got %d", rt.calls)
+	}
+	if got.Get("User-Agent") != "ingext-test/1.0" || got.Get("X-Tenant") != "acme" || got.Get("Authorization") != "Bearer tok" {
+		t.Errorf("unexpected request headers: %v", got)
+	}
+
+	if _, err := NewIngextClientWithOptions(ts.URL, "", WithTransport(rt), WithTLSConfig(&TLSConfig{})); err == nil {
+		t.Errorf("expected error applying TLS settings to a custom RoundTripper")
+	}
+}
+
+func TestHTTPService_Interceptors(t *testing.T) {
+	var gotID string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = r.Header.Get(RequestIDHeader)
+		w.Write([]byte(`{"verdict":"OK","response":{"id":"x1"}}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	var order []string
+	var wantErr error
+	trace := func(name string) Interceptor {
+		return InterceptorFuncs{
+			Before: func(ctx context.Context, call *Call) error {
+				order = append(order, "before "+name)
+				return nil
+			},
+			After: func(ctx context.Context, call *Call, resp *fsb.CallResponse, err error) {
+				if wantErr != nil {
+					if resp != nil || !errors.Is(err, wantErr) {
+						t.Errorf("%s: unexpected outcome resp=%v err=%v, want %v", name, resp, err, wantErr)
+					}
+				} else if resp == nil || !resp.IsOK() || err != nil {
+					t.Errorf("%s: unexpected outcome resp=%v err=%v", name, resp, err)
+				}
+				order = append(order, "after "+name)
+			},
+		}
+	}
+	c, _ := NewIngextClientWithOptions(ts.URL, "", WithInterceptors(NewRequestIDInterceptor(), trace("a"), trace("b")))
+	if _, err := c.GenericCall("api/ds", "platform_list_configs", nil); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	want := []string{"before a", "before b", "after b", "after a"}
+	if len(order) != len(want) {
+		t.Fatalf("interceptor order = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("interceptor order = %v, want %v", order, want)
+		}
+	}
+	if gotID == "" {
+		t.Errorf("expected %s header", RequestIDHeader)
+	}
+
+	denied := errors.New("read-only session")
+	c.Use(InterceptorFuncs{Before: func(ctx context.Context, call *Call) error {
+		if !call.ReadOnly {
+			return denied
+		}
+		return nil
+	}})
+	add := map[string]interface{}{"action": "add"}
+	order, wantErr = nil, denied
+	if _, err := c.GenericCall("api/ds", "platform_datasource_dao", add); !errors.Is(err, denied) {
+		t.Errorf("expected interceptor error, got %v", err)
+	}
+	// The interceptors before the failing one still get AfterResponse.
+	if want := []string{"before a", "before b", "after b", "after a"}; !slices.Equal(order, want) {
+		t.Errorf("interceptor order after an error = %v, want %v", order, want)
+	}
+
+	dry, _ := NewIngextClientWithOptions(ts.URL, "", WithInterceptors(NewDryRunInterceptor()))
+	if _, err := dry.GenericCall("api/ds", "platform_list_configs", nil); err != nil {
+		t.Errorf("dry run blocked a read-only call: %v", err)
+	}
+	_, err := dry.GenericCall("api/ds", "platform_datasource_dao", map[string]interface{}{"action": "add", "args": map[string]interface{}{"entry": map[string]string{"token": "abc"}}})
+	var dryErr *DryRunError
+	if !errors.Is(err, ErrDryRun) || !errors.As(err, &dryErr) || dryErr.Function != "platform_datasource_dao" {
+		t.Fatalf("expected a dry run error, got %v", err)
+	}
+	if strings.Contains(string(dryErr.Kargs), "abc") {
+		t.Errorf("dry run error leaks a secret: %s", dryErr.Kargs)
+	}
+}
+
+func TestRedactor_FilterDump(t *testing.T) {
+	dump := "POST /api/auth/api_token HTTP/1.1\r\n" +
+		"Authorization: Bearer abc.def.ghi\r\n" +
+		"Content-Type: application/json\r\n\r\n" +
+		`{"function":"api_token","kargs":{"name":"ci","token":"s3cr3t","secret":{"secretKey":"AKIA\"x"},"Password":"pw","accessKey":"AKIAID"}}`
+
+	got := string(DefaultRedactor().FilterDump([]byte(dump)))
+	for _, leak := range []string{"abc.def.ghi", "s3cr3t", `AKIA\"x`, `"pw"`} {
+		if strings.Contains(got, leak) {
+			t.Errorf("dump still contains %q:\n%s", leak, got)
+		}
+	}
+	for _, keep := range []string{"Authorization: Bearer " + RedactedMask, `"name":"ci"`, `"accessKey":"AKIAID"`, "Content-Type: application/json"} {
+		if !strings.Contains(got, keep) {
+			t.Errorf("dump is missing %q:\n%s", keep, got)
+		}
+	}
+}
+
+func TestHTTPService_CallInto(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/ds/empty":
+			w.Write([]byte(`{"verdict":"OK","response":null}`))
+		case "/api/ds/fail":
+			w.Write([]byte(`{"response":null,"verdict":"ERROR","error":"bad query"}`))
+		default:
+			w.Write([]byte(`{"response":{"rows":[{"n":1},{"n":2}],"extra":true},"verdict":"OK"}`))
+		}
+	}))
+	t.Cleanup(ts.Close)
+	c := NewIngextClient(ts.URL, "", false, nil)
+
+	var out struct {
+		Rows []struct {
+			N int `json:"n"`
+		} `json:"rows"`
+	}
+	if err := c.GenericCallInto(context.Background(), "api/ds", "kql_search", nil, &out); err != nil {
+		t.Fatalf("CallInto: %v", err)
+	}
+	if len(out.Rows) != 2 || out.Rows[1].N != 2 {
+		t.Errorf("unexpected decoded response: %+v", out)
+	}
+
+	if err := c.GenericCallInto(context.Background(), "api/ds", "empty", nil, &out); !errors.Is(err, ErrEmptyResponse) {
+		t.Errorf("expected ErrEmptyResponse, got %v", err)
+	}
+	if err := c.GenericCallInto(context.Background(), "api/ds", "fail", nil, &out); !errors.Is(err, ErrServer) {
+		t.Errorf("expected server error, got %v", err)
+	}
+
+	small, _ := NewIngextClientWithOptions(ts.URL, "", WithMaxResponseSize(16))
+	if err := small.GenericCallInto(context.Background(), "api/ds", "kql_search", nil, &out); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected ErrResponseTooLarge, got %v", err)
+	}
+	if _, err := small.GenericCall("api/ds", "kql_search", nil); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected ErrResponseTooLarge from buffered call, got %v", err)
+	}
+}
+
+func TestCallTyped(t *testing.T) {
+	var kargs string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req fsb.CallRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		kargs = req.Kargs.String()
+		w.Write([]byte(`{"verdict":"OK","response":{"id":"x1","meta":{"count":12345678901234567}}}`))
+	}))
+	t.Cleanup(ts.Close)
+	c := NewIngextClient(ts.URL, "", false, nil)
+
+	type getReq struct {
+		ID string `json:"id"`
+	}
+	type getResp struct {
+		ID   string                 `json:"id"`
+		Meta map[string]interface{} `json:"meta"`
+	}
+	resp, err := CallTyped[*getReq, getResp](context.Background(), c, "api/ds", "get", &getReq{ID: "x1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != "x1" || kargs != `{"id":"x1"}` {
+		t.Errorf("resp = %+v, kargs = %s", resp, kargs)
+	}
+	if n, ok := resp.Meta["count"].(json.Number); !ok || n.String() != "12345678901234567" {
+		t.Errorf("count = %#v, want json.Number", resp.Meta["count"])
+	}
+
+	if _, err := CallTyped[*getReq, getResp](context.Background(), c, "api/ds", "get", nil); err != nil || kargs != "{}" {
+		t.Errorf("nil request: err = %v, kargs = %s", err, kargs)
+	}
+}
+
+func TestHTTPService_Compression(t *testing.T) {
+	var gotAccept, gotEncoding string
+	var gotFunction string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAccept, gotEncoding = r.Header.Get("Accept-Encoding"), r.Header.Get("Content-Encoding")
+		body := io.Reader(r.Body)
+		if gotEncoding == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("request is not gzip: %v", err)
+				return
+			}
+			body = zr
+		}
+		var req fsb.CallRequest
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			t.Errorf("bad request body: %v", err)
+		}
+		gotFunction = req.Function
+
+		payload := []byte(`{"verdict":"OK","response":{"msg":"` + strings.Repeat("a", 1000) + `"}}`)
+		if strings.Contains(gotAccept, "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			zw := gzip.NewWriter(w)
+			zw.Write(payload)
+			zw.Close()
+			return
+		}
+		w.Write(payload)
+	}))
+	t.Cleanup(ts.Close)
+
+	var out struct {
+		Msg string `json:"msg"`
+	}
+	c, _ := NewIngextClientWithOptions(ts.URL, "", WithRequestCompression(10))
+	big := map[string]string{"script": strings.Repeat("x", 100)}
+	if err := c.GenericCallInto(context.Background(), "api/ds", "platform_processor_validate", big, &out); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if gotEncoding != "gzip" || gotAccept != acceptEncoding || gotFunction != "platform_processor_validate" || len(out.Msg) != 1000 {
+		t.Errorf("compressed round trip: accept=%q encoding=%q function=%q len=%d", gotAccept, gotEncoding, gotFunction, len(out.Msg))
+	}
+
+	plain, _ := NewIngextClientWithOptions(ts.URL, "", WithCompression(false), WithRequestCompression(10))
+	if err := plain.GenericCallInto(context.Background(), "api/ds", "platform_processor_validate", big, &out); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if gotEncoding != "" || gotAccept != "" {
+		t.Errorf("compression disabled: accept=%q encoding=%q", gotAccept, gotEncoding)
+	}
+}
+
+func TestIngextClient_Batch(t *testing.T) {
+	var inFlight, maxInFlight int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		var req struct {
+			Kargs struct{ N int } `json:"kargs"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Kargs.N == 3 {
+			w.Write([]byte(`{"verdict":"ERROR","error":"bad item"}`))
+			return
+		}
+		w.Write([]byte(`{"verdict":"OK","response":{"n":` + strconv.Itoa(req.Kargs.N*10) + `}}`))
+	}))
+	t.Cleanup(ts.Close)
+	c := NewIngextClient(ts.URL, "", false, nil)
+
+	calls := make([]BatchCall, 8)
+	outs := make([]struct{ N int }, len(calls))
+	for i := range calls {
+		calls[i] = BatchCall{Prefix: "api/ds", Function: "item", Kargs: map[string]int{"n": i}, Out: &outs[i]}
+	}
+	results := c.Batch(context.Background(), calls, &BatchOptions{Concurrency: 3})
+	for i, res := range results {
+		if res.Index != i {
+			t.Errorf("result %d has index %d", i, res.Index)
+		}
+		if i == 3 {
+			if ErrorKindOf(res.Err) != KindError {
+				t.Errorf("item 3: err = %v", res.Err)
+			}
+		} else if res.Err != nil || outs[i].N != i*10 {
+			t.Errorf("item %d: out = %d, err = %v", i, outs[i].N, res.Err)
+		}
+	}
+	if maxInFlight > 3 {
+		t.Errorf("max in flight = %d, want <= 3", maxInFlight)
+	}
+	if err := BatchErrors(results); err == nil || !strings.Contains(err.Error(), "call 3:") {
+		t.Errorf("BatchErrors = %v", err)
+	}
+
+	results = c.Batch(context.Background(), calls, &BatchOptions{Concurrency: 1, StopOnError: true})
+	for i, res := range results {
+		if i > 3 && (!errors.Is(res.Err, ErrBatchAborted) || ErrorKindOf(res.Err) != "" || errors.Is(res.Err, ErrTransport)) {
+			t.Errorf("item %d after failure: err = %v (kind %q)", i, res.Err, ErrorKindOf(res.Err))
+		}
+	}
+}
+
+func TestHTTPService_RateLimit(t *testing.T) {
+	var inFlight, maxInFlight int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		w.Write([]byte(`{"verdict":"OK","response":{}}`))
+	}))
+	t.Cleanup(ts.Close)
+	c, err := NewIngextClientWithOptions(ts.URL, "", WithRateLimit(&RateLimit{Rate: 50, Burst: 1, MaxInFlight: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := make([]BatchCall, 6)
+	for i := range calls {
+		calls[i] = BatchCall{Prefix: "api/ds", Function: "ping"}
+	}
+	start := time.Now()
+	if err := BatchErrors(c.Batch(context.Background(), calls, &BatchOptions{Concurrency: 6})); err != nil {
+		t.Fatal(err)
+	}
+	// 6 requests at 50/s with a burst of 1 need at least 100ms.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("batch took %v, rate limit not applied", elapsed)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("max in flight = %d, want <= 2", maxInFlight)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.GenericCallContext(ctx, "api/ds", "ping", nil); ErrorKindOf(err) != KindCancelled {
+		t.Errorf("cancelled call: %v", err)
+	}
+
+	// Replacing the limit while a batch runs is safe (go test -race).
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			c.SetRateLimit(&RateLimit{MaxInFlight: 1 + i%3})
+		}
+		c.SetRateLimit(nil)
+	}()
+	if err := BatchErrors(c.Batch(context.Background(), calls, &BatchOptions{Concurrency: 6})); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+}
+
+func TestHTTPService_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute URL of the target.
+		proxied = r.URL.String()
+		w.Write([]byte(`{"verdict":"OK","response":{}}`))
+	}))
+	t.Cleanup(proxy.Close)
+
+	t.Setenv("NO_PROXY", "direct.invalid")
+	c, err := NewIngextClientWithOptions("http://site.invalid", "", WithProxy(proxy.URL), WithRetryPolicy(NoRetryPolicy()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GenericCallContext(context.Background(), "api/ds", "ping", nil); err != nil {
+		t.Fatal(err)
+	}
+	if proxied != "http://site.invalid/api/ds/ping" {
+		t.Errorf("proxied URL = %q", proxied)
+	}
+
+	// Hosts in NO_PROXY bypass the proxy (and fail to resolve here).
+	proxied = ""
+	c, _ = NewIngextClientWithOptions("http://direct.invalid", "", WithProxy(proxy.URL), WithRetryPolicy(NoRetryPolicy()))
+	if _, err := c.GenericCallContext(context.Background(), "api/ds", "ping", nil); ErrorKindOf(err) != KindTransport || proxied != "" {
+		t.Errorf("NO_PROXY host: err = %v, proxied = %q", err, proxied)
+	}
+
+	for _, bad := range []string{"ftp://proxy:21", "socks5://", "://x"} {
+		if _, err := NewIngextClientWithOptions("http://site.invalid", "", WithProxy(bad)); err == nil {
+			t.Errorf("WithProxy(%q) accepted", bad)
+		}
+	}
+	if _, err := NewIngextClientWithOptions("http://site.invalid", "", WithProxy("socks5://user:pw@127.0.0.1:1080")); err != nil {
+		t.Errorf("socks5 proxy: %v", err)
+	}
+}
+
+func TestCassette_RecordReplay(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req fsb.CallRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Write([]byte(`{"verdict":"OK","response":{"function":"` + req.Function + `","password":"hunter2"}}`))
+	}))
+	path := filepath.Join(t.TempDir(), "calls.json")
+
+	rec, err := NewIngextClientWithOptions(ts.URL, "tok", WithRecord(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, fn := range []string{"first", "second"} {
+		if _, err := rec.GenericCallContext(ctx, "api/ds", fn, map[string]string{"secret": "s3cr3t"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts.Close()
+
+	raw, _ := os.ReadFile(path)
+	if strings.Contains(string(raw), "s3cr3t") || strings.Contains(string(raw), "hunter2") || strings.Contains(string(raw), "tok") {
+		t.Errorf("cassette leaks secrets:\n%s", raw)
+	}
+
+	// The server is gone: every answer comes from the cassette.
+	rp, err := NewIngextClientWithOptions(ts.URL, "", WithReplay(path), WithRetryPolicy(NoRetryPolicy()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := rp.GenericCallContext(ctx, "api/ds", "second", map[string]string{"secret": "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fn, _ := res.GetString("function"); fn != "second" {
+		t.Errorf("replayed %s", res.GetBytes())
+	}
+	if _, err := rp.GenericCallContext(ctx, "api/ds", "second", nil); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("second replay of a single interaction: %v", err)
+	}
+	if _, err := NewIngextClientWithOptions(ts.URL, "", WithReplay(filepath.Join(t.TempDir(), "missing.json"))); err == nil {
+		t.Error("missing cassette accepted")
+	}
+}
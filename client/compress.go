@@ -0,0 +1,73 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultRequestCompressionThreshold is the request body size from which
+// bodies are gzipped when request compression is enabled without an explicit
+// threshold.
+const DefaultRequestCompressionThreshold = 64 << 10 // 64 KiB
+
+// acceptEncoding is sent when response compression is enabled.
+const acceptEncoding = "gzip, deflate"
+
+// gzipBytes compresses b with gzip.
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeContentEncoding replaces a gzip or deflate encoded response body with
+// a decompressing reader, so dumps and decoders see plain JSON.
+func decodeContentEncoding(resp *http.Response) error {
+	var (
+		zr  io.ReadCloser
+		err error
+	)
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return nil
+	case "gzip", "x-gzip":
+		zr, err = gzip.NewReader(resp.Body)
+	case "deflate":
+		// HTTP "deflate" is zlib-wrapped deflate (RFC 9110).
+		zr, err = zlib.NewReader(resp.Body)
+	default:
+		return fmt.Errorf("unsupported Content-Encoding %q", resp.Header.Get("Content-Encoding"))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decompress response: %w", err)
+	}
+	resp.Body = &decompressedBody{Reader: zr, zr: zr, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// decompressedBody closes both the decompressor and the underlying body.
+type decompressedBody struct {
+	io.Reader
+	zr   io.Closer
+	body io.Closer
+}
+
+func (d *decompressedBody) Close() error {
+	d.zr.Close()
+	return d.body.Close()
+}
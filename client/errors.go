@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	fsb "github.com/SecurityDo/ingext_api/fsb"
+)
+
+// Verdicts returned in fsb.CallResponse.
+const (
+	VerdictOK        = "OK"
+	VerdictError     = "ERROR"
+	VerdictException = "EXCEPTION"
+)
+
+// ErrorKind classifies an RPCError.
+type ErrorKind string
+
+const (
+	KindTransport    ErrorKind = "transport"    // request never got an HTTP response
+	KindCancelled    ErrorKind = "cancelled"    // context cancelled or deadline exceeded
+	KindHTTP         ErrorKind = "http"         // non-200 HTTP status
+	KindDecode       ErrorKind = "decode"       // response body is not a valid fsb.CallResponse
+	KindUnauthorized ErrorKind = "unauthorized" // HTTP 401/403 or an authorization ERROR verdict
+	KindNotFound     ErrorKind = "not_found"    // HTTP 404 or a "not found" ERROR verdict
+	KindRateLimited  ErrorKind = "rate_limited" // HTTP 429
+	KindError        ErrorKind = "error"        // any other ERROR verdict
+	KindException    ErrorKind = "exception"    // EXCEPTION verdict (server side failure)
+)
+
+// Sentinel errors matched by errors.Is against an *RPCError of the same kind:
+//
+//	if errors.Is(err, client.ErrNotFound) { ... }
+var (
+	ErrTransport    = errors.New("transport error")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServer       = errors.New("server error")
+	ErrException    = errors.New("server exception")
+)
+
+// RPCError describes a failed fsb call. Use errors.As to inspect it:
+//
+//	var rpcErr *client.RPCError
+//	if errors.As(err, &rpcErr) && rpcErr.HTTPStatus == 503 { ... }
+type RPCError struct {
+	Prefix   string
+	Function string
+	URL      string
+	// Verdict is "ERROR" or "EXCEPTION" when the server answered with a
+	// failed verdict; empty for transport, HTTP and decode failures.
+	Verdict string
+	// HTTPStatus is the response status code, 0 if no response was received.
+	HTTPStatus int
+	// Message is the server supplied error/exception text or HTTP status line.
+	Message string
+	// Err is the underlying cause (transport error, context error or
+	// fsb.ApiError for HTTP failures), if any.
+	Err error
+
+	retryAfter time.Duration
+}
+
+func (e *RPCError) Error() string {
+	target := e.URL
+	if target == "" {
+		target = strings.Trim(e.Prefix+"/"+e.Function, "/")
+	}
+	switch {
+	case e.Verdict != "":
+		return fmt.Sprintf("RPC call %s returned %s: %s", target, e.Verdict, e.Message)
+	case e.Kind() == KindCancelled:
+		return fmt.Sprintf("call to %s cancelled: %v", target, e.Err)
+	case e.Kind() == KindDecode:
+		return fmt.Sprintf("invalid response from %s: %v", target, e.Err)
+	case e.HTTPStatus != 0:
+		return fmt.Sprintf("HTTP error from %s: %s", target, e.Message)
+	case e.Err != nil:
+		return fmt.Sprintf("call to %s failed: %v", target, e.Err)
+	}
+	return fmt.Sprintf("call to %s failed: %s", target, e.Message)
+}
+
+func (e *RPCError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, ErrNotFound) and friends work on RPC errors.
+func (e *RPCError) Is(target error) bool {
+	switch target {
+	case ErrTransport:
+		return e.Kind() == KindTransport
+	case ErrUnauthorized:
+		return e.Kind() == KindUnauthorized
+	case ErrNotFound:
+		return e.Kind() == KindNotFound
+	case ErrRateLimited:
+		return e.Kind() == KindRateLimited
+	case ErrServer:
+		k := e.Kind()
+		return k == KindError || k == KindException || (k == KindHTTP && e.HTTPStatus >= 500)
+	case ErrException:
+		return e.Kind() == KindException
+	}
+	return false
+}
+
+// Kind classifies the error from its verdict, HTTP status and message.
+func (e *RPCError) Kind() ErrorKind {
+	if errors.Is(e.Err, context.Canceled) || errors.Is(e.Err, context.DeadlineExceeded) {
+		return KindCancelled
+	}
+	switch e.HTTPStatus {
+	case 0, http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return KindUnauthorized
+	case http.StatusNotFound:
+		return KindNotFound
+	case http.StatusTooManyRequests:
+		return KindRateLimited
+	default:
+		return KindHTTP
+	}
+	switch e.Verdict {
+	case VerdictException:
+		return KindException
+	case VerdictError:
+		return classifyMessage(e.Message)
+	}
+	if e.HTTPStatus == http.StatusOK {
+		return KindDecode
+	}
+	return KindTransport
+}
+
+// classifyMessage maps the free text of an ERROR verdict to a kind. The
+// server does not return structured codes, so this matches common phrases.
+func classifyMessage(msg string) ErrorKind {
+	m := strings.ToLower(msg)
+	for _, p := range []string{"unauthorized", "forbidden", "permission denied", "access denied", "not authorized", "invalid token", "token expired"} {
+		if strings.Contains(m, p) {
+			return KindUnauthorized
+		}
+	}
+	for _, p := range []string{"not found", "does not exist", "doesn't exist", "no such", "not exist"} {
+		if strings.Contains(m, p) {
+			return KindNotFound
+		}
+	}
+	return KindError
+}
+
+// ErrorKindOf returns the kind of the first *RPCError in err's chain, or ""
+// when err is not an RPC error.
+func ErrorKindOf(err error) ErrorKind {
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Kind()
+	}
+	return ""
+}
+
+// newHTTPStatusError builds the error for a non-200 response.
+func newHTTPStatusError(prefix, functionName, url string, resp *http.Response) *RPCError {
+	return &RPCError{
+		Prefix:     prefix,
+		Function:   functionName,
+		URL:        url,
+		HTTPStatus: resp.StatusCode,
+		Message:    resp.Status,
+		Err:        fsb.ApiError{Code: resp.StatusCode, Info: resp.Status},
+		retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	fsb "github.com/SecurityDo/ingext_api/fsb"
+)
+
+// Call describes one RPC call as seen by interceptors.
+type Call struct {
+	Prefix   string
+	Function string
+	URL      string
+	// Request is the fsb envelope being sent. Interceptors must not modify it.
+	Request *fsb.CallRequest
+	// ReadOnly reports whether the call is classified as free of side effects
+	// (see IsReadOnlyCall).
+	ReadOnly bool
+	// Attempt is 1 for the first try and increases with every retry.
+	Attempt int
+	// Started is when the current attempt started.
+	Started time.Time
+	// HTTPRequest is the outgoing request of the current attempt; interceptors
+	// may add headers in BeforeRequest.
+	HTTPRequest *http.Request
+	// HTTPResponse is the response of the current attempt, nil if none was
+	// received. Its body has already been consumed.
+	HTTPResponse *http.Response
+	// RequestSize and ResponseSize are the body sizes in bytes of the current
+	// attempt (ResponseSize is 0 until a response body was read).
+	RequestSize  int64
+	ResponseSize int64
+
+	requestID string
+}
+
+// Interceptor hooks into every HTTP attempt of an RPC call. BeforeRequest runs
+// in registration order just before the request is sent; returning an error
+// aborts the call with that error, and only the interceptors whose
+// BeforeRequest already ran get AfterResponse, with that error. AfterResponse
+// runs in reverse order once the attempt completed, with the decoded response
+// (nil if none) and the attempt's error, so every successful BeforeRequest is
+// paired with one AfterResponse.
+type Interceptor interface {
+	BeforeRequest(ctx context.Context, call *Call) error
+	AfterResponse(ctx context.Context, call *Call, resp *fsb.CallResponse, err error)
+}
+
+// DumpFilter is implemented by interceptors that rewrite the HTTP dumps
+// logged when debugging is on (e.g. to mask secrets).
+type DumpFilter interface {
+	FilterDump(dump []byte) []byte
+}
+
+// InterceptorFuncs adapts plain functions to Interceptor; nil fields are skipped.
+type InterceptorFuncs struct {
+	Before func(ctx context.Context, call *Call) error
+	After  func(ctx context.Context, call *Call, resp *fsb.CallResponse, err error)
+}
+
+func (f InterceptorFuncs) BeforeRequest(ctx context.Context, call *Call) error {
+	if f.Before == nil {
+		return nil
+	}
+	return f.Before(ctx, call)
+}
+
+func (f InterceptorFuncs) AfterResponse(ctx context.Context, call *Call, resp *fsb.CallResponse, err error) {
+	if f.After != nil {
+		f.After(ctx, call, resp, err)
+	}
+}
+
+// RequestIDHeader is the header set by NewRequestIDInterceptor.
+const RequestIDHeader = "X-Request-Id"
+
+// NewRequestIDInterceptor tags every call with a random X-Request-Id header.
+// Retries of the same call reuse the ID.
+func NewRequestIDInterceptor() Interceptor {
+	return InterceptorFuncs{
+		Before: func(ctx context.Context, call *Call) error {
+			if call.HTTPRequest.Header.Get(RequestIDHeader) != "" {
+				return nil
+			}
+			if call.requestID == "" {
+				b := make([]byte, 8)
+				_, _ = rand.Read(b)
+				call.requestID = hex.EncodeToString(b)
+			}
+			call.HTTPRequest.Header.Set(RequestIDHeader, call.requestID)
+			return nil
+		},
+	}
+}
+
+// NewAuditInterceptor logs every mutating call (add, delete, update, ...) at
+// info level with its outcome and duration. Read-only calls are not logged.
+func NewAuditInterceptor(logger *slog.Logger) Interceptor {
+	return InterceptorFuncs{
+		After: func(ctx context.Context, call *Call, resp *fsb.CallResponse, err error) {
+			if call.ReadOnly {
+				return
+			}
+			attrs := []any{
+				"prefix", call.Prefix,
+				"functionName", call.Function,
+				"attempt", call.Attempt,
+				"duration", time.Since(call.Started),
+			}
+			if err != nil {
+				logger.Info("audit: mutating call failed", append(attrs, "error", err)...)
+				return
+			}
+			logger.Info("audit: mutating call succeeded", attrs...)
+		},
+	}
+}
+
+// ErrDryRun matches, with errors.Is, the *DryRunError of a call held back by
+// NewDryRunInterceptor.
+var ErrDryRun = errors.New("dry run")
+
+// DryRunError reports a mutating call that was not sent because dry run is on.
+type DryRunError struct {
+	Prefix   string
+	Function string
+	// Kargs are the call arguments, with secret values masked.
+	Kargs []byte
+}
+
+func (e *DryRunError) Error() string {
+	return fmt.Sprintf("dry run: not sent: %s/%s %s", e.Prefix, e.Function, e.Kargs)
+}
+
+func (e *DryRunError) Is(target error) bool { return target == ErrDryRun }
+
+// NewDryRunInterceptor fails every call not classified as read-only (see
+// IsReadOnlyCall) with a *DryRunError instead of sending it, so that a
+// command can be tried against a site without changing it.
+func NewDryRunInterceptor() Interceptor {
+	return InterceptorFuncs{
+		Before: func(ctx context.Context, call *Call) error {
+			if call.ReadOnly {
+				return nil
+			}
+			var kargs []byte
+			if call.Request.Kargs != nil {
+				kargs = DefaultRedactor().RedactJSON(call.Request.Kargs.GetBytes())
+			}
+			return &DryRunError{Prefix: call.Prefix, Function: call.Function, Kargs: kargs}
+		},
+	}
+}
@@ -0,0 +1,272 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// DefaultTimeout is the overall HTTP timeout used when WithTimeout is not given.
+const DefaultTimeout = 600 * time.Second
+
+// DefaultUserAgent is sent when WithUserAgent is not given.
+const DefaultUserAgent = "ingext-api-go"
+
+// Option configures an IngextClient built by NewIngextClientWithOptions.
+type Option func(*options)
+
+type options struct {
+	httpClient   *http.Client
+	transport    http.RoundTripper
+	timeout      time.Duration
+	userAgent    string
+	headers      http.Header
+	logger       *slog.Logger
+	debug        bool
+	tlsConfig    *TLSConfig
+	retryPolicy  *RetryPolicy
+	interceptors []Interceptor
+	redactor     *Redactor
+	redactorSet  bool
+	maxResponse  int64
+	rateLimit    *RateLimit
+	proxy        string
+	recordPath   string
+	replayPath   string
+
+	noCompression    bool
+	gzipRequestsFrom int
+}
+
+// WithHTTPClient uses hc to send requests. The client is copied, so options
+// such as WithTimeout or WithTransport do not modify the caller's value.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *options) { o.httpClient = hc }
+}
+
+// WithTransport sends requests through rt (e.g. a proxying or instrumented
+// RoundTripper). TLS options are only applied when rt is an *http.Transport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) { o.transport = rt }
+}
+
+// WithTimeout sets the overall timeout of a single HTTP request.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithUserAgent sets the User-Agent header sent with every call.
+func WithUserAgent(ua string) Option {
+	return func(o *options) { o.userAgent = ua }
+}
+
+// WithHeaders adds headers to every call. Content-Type and Authorization are
+// always set by the client and cannot be overridden here.
+func WithHeaders(h http.Header) Option {
+	return func(o *options) {
+		if o.headers == nil {
+			o.headers = http.Header{}
+		}
+		for k, vs := range h {
+			for _, v := range vs {
+				o.headers.Add(k, v)
+			}
+		}
+	}
+}
+
+// WithLogger sets the logger; by default the client logs text to stderr.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// WithDebug enables HTTP request/response dumps at debug level.
+func WithDebug(debug bool) Option {
+	return func(o *options) { o.debug = debug }
+}
+
+// WithTLSConfig sets server verification and client certificate settings.
+func WithTLSConfig(c *TLSConfig) Option {
+	return func(o *options) { o.tlsConfig = c }
+}
+
+// WithRetryPolicy sets the retry policy; see DefaultRetryPolicy.
+func WithRetryPolicy(p *RetryPolicy) Option {
+	return func(o *options) { o.retryPolicy = p }
+}
+
+// WithInterceptors appends interceptors to the chain run around every call.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(o *options) { o.interceptors = append(o.interceptors, interceptors...) }
+}
+
+// WithRedactor sets the Redactor applied to debug dumps. By default
+// DefaultRedactor masks bearer tokens and secret fields; nil disables
+// redaction entirely.
+func WithRedactor(r *Redactor) Option {
+	return func(o *options) {
+		o.redactor = r
+		o.redactorSet = true
+	}
+}
+
+// WithMaxResponseSize bounds the size of a response body; larger responses
+// fail with an error wrapping ErrResponseTooLarge. n <= 0 removes the limit.
+// The default is DefaultMaxResponseSize.
+func WithMaxResponseSize(n int64) Option {
+	return func(o *options) {
+		if n <= 0 {
+			n = -1
+		}
+		o.maxResponse = n
+	}
+}
+
+// WithCompression turns compression on or off. It is on by default: the client
+// asks for gzip/deflate responses and decompresses them transparently. Turn it
+// off for servers or proxies that mishandle compressed traffic; this also
+// disables request compression.
+func WithCompression(enabled bool) Option {
+	return func(o *options) { o.noCompression = !enabled }
+}
+
+// WithRequestCompression gzips request bodies of at least threshold bytes
+// (DefaultRequestCompressionThreshold if threshold <= 0). The server must
+// accept Content-Encoding: gzip; request compression is off by default.
+func WithRequestCompression(threshold int) Option {
+	return func(o *options) {
+		if threshold <= 0 {
+			threshold = DefaultRequestCompressionThreshold
+		}
+		o.gzipRequestsFrom = threshold
+	}
+}
+
+// NewIngextClientWithOptions creates a client for siteURL authenticated with
+// token. It fails only when an option cannot be applied (e.g. an unreadable
+// CA file).
+func NewIngextClientWithOptions(siteURL string, token string, opts ...Option) (*IngextClient, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.logger == nil {
+		// Using os.Stderr by default is safe for libraries
+		o.logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	}
+
+	serviceClient, err := newHTTPService(siteURL, o)
+	if err != nil {
+		return nil, err
+	}
+	s := &IngextClient{
+		serviceClient: serviceClient,
+		logger:        o.logger,
+	}
+	s.serviceClient.SetToken(token)
+	if o.tlsConfig != nil && o.tlsConfig.InsecureSkipVerify {
+		s.logger.Warn("TLS certificate verification is disabled", "url", siteURL)
+	}
+	return s, nil
+}
+
+// newHTTPService builds the HTTPService for the given options.
+func newHTTPService(url string, o *options) (*HTTPService, error) {
+	s := &HTTPService{
+		url:       url,
+		logger:    o.logger,
+		DebugFlag: o.debug,
+		userAgent: o.userAgent,
+		headers:   o.headers,
+		retry:     o.retryPolicy,
+
+		interceptors: o.interceptors,
+		redactor:     o.redactor,
+
+		noCompression:    o.noCompression,
+		gzipRequestsFrom: o.gzipRequestsFrom,
+	}
+	s.throttle.Store(newThrottle(o.rateLimit))
+	if !o.redactorSet {
+		s.redactor = DefaultRedactor()
+	}
+	switch {
+	case o.maxResponse == 0:
+		s.maxResponseSize = DefaultMaxResponseSize
+	case o.maxResponse > 0:
+		s.maxResponseSize = o.maxResponse
+	}
+	if s.userAgent == "" {
+		s.userAgent = DefaultUserAgent
+	}
+
+	if o.httpClient != nil {
+		hc := *o.httpClient
+		s.client = &hc
+	} else {
+		s.client = &http.Client{Timeout: DefaultTimeout}
+	}
+	if o.timeout > 0 {
+		s.client.Timeout = o.timeout
+	}
+
+	rt := o.transport
+	if rt == nil {
+		rt = s.client.Transport
+	}
+	switch t := rt.(type) {
+	case nil:
+		// Verify the site certificate by default; see SetTLSConfig. Honor
+		// HTTPS_PROXY/NO_PROXY unless WithProxy says otherwise.
+		tlsConfig, _ := (*TLSConfig)(nil).Build()
+		s.transport = &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     tlsConfig,
+			TLSHandshakeTimeout: 10 * time.Second,
+		}
+		s.client.Transport = s.transport
+	case *http.Transport:
+		// Clone so TLS settings never leak into the caller's transport.
+		s.transport = t.Clone()
+		s.client.Transport = s.transport
+	default:
+		s.client.Transport = rt
+	}
+
+	if s.noCompression && s.transport != nil {
+		// Also stop the transport from adding its own Accept-Encoding: gzip.
+		s.transport.DisableCompression = true
+	}
+
+	if o.tlsConfig != nil {
+		if err := s.SetTLSConfig(o.tlsConfig); err != nil {
+			return nil, err
+		}
+	}
+	if o.proxy != "" {
+		if err := s.SetProxy(o.proxy); err != nil {
+			return nil, err
+		}
+	}
+
+	// Cassettes sit between the client and the network; TLS and proxy
+	// settings still apply to the recorded traffic.
+	switch {
+	case o.replayPath != "":
+		rp, err := newReplayer(o.replayPath, s.redactor)
+		if err != nil {
+			return nil, fmt.Errorf("replay: %w", err)
+		}
+		s.client.Transport = rp
+	case o.recordPath != "":
+		s.client.Transport = newRecorder(s.client.Transport, o.recordPath, s.redactor)
+	}
+	return s, nil
+}
+
+// errCustomTransport is returned when TLS or proxy settings cannot be applied
+// because the caller supplied a RoundTripper that is not an *http.Transport.
+var errCustomTransport = errors.New("TLS and proxy settings require an *http.Transport; configure them on the custom transport instead")
@@ -0,0 +1,195 @@
+// Package otelclient instruments client.IngextClient with OpenTelemetry spans
+// and metrics. It lives in its own package so that programs which do not use
+// OpenTelemetry do not depend on it:
+//
+//	cli, err := client.NewIngextClientWithOptions(siteURL, token,
+//		otelclient.WithOpenTelemetry())
+package otelclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/SecurityDo/ingext_api/client"
+	fsb "github.com/SecurityDo/ingext_api/fsb"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the tracer and meter.
+const ScopeName = "github.com/SecurityDo/ingext_api/client/otelclient"
+
+// Attribute keys recorded on spans and metrics.
+const (
+	PrefixKey       = attribute.Key("ingext.rpc.prefix")
+	FunctionKey     = attribute.Key("ingext.rpc.function")
+	VerdictKey      = attribute.Key("ingext.rpc.verdict")
+	AttemptKey      = attribute.Key("ingext.rpc.attempt")
+	ReadOnlyKey     = attribute.Key("ingext.rpc.read_only")
+	RequestSizeKey  = attribute.Key("ingext.rpc.request.size")
+	ResponseSizeKey = attribute.Key("ingext.rpc.response.size")
+	HTTPStatusKey   = attribute.Key("http.response.status_code")
+	ServerKey       = attribute.Key("server.address")
+	ErrorTypeKey    = attribute.Key("error.type")
+)
+
+// Option configures the interceptor.
+type Option func(*config)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagator     propagation.TextMapPropagator
+}
+
+// WithTracerProvider uses tp instead of the global tracer provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) { c.tracerProvider = tp }
+}
+
+// WithMeterProvider uses mp instead of the global meter provider.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) { c.meterProvider = mp }
+}
+
+// WithPropagator uses p instead of the global propagator to inject the trace
+// context into outgoing requests.
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(c *config) { c.propagator = p }
+}
+
+// Interceptor records one client span per HTTP attempt and the metrics
+//
+//	ingext.rpc.client.calls     counter   calls by prefix, function, verdict, error.type
+//	ingext.rpc.client.duration  histogram attempt latency in seconds
+//	ingext.rpc.client.request.size / response.size  histograms in bytes
+type Interceptor struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+
+	calls        metric.Int64Counter
+	duration     metric.Float64Histogram
+	requestSize  metric.Int64Histogram
+	responseSize metric.Int64Histogram
+
+	// spans maps an in-flight call to its current attempt span; attempts of
+	// one call never overlap.
+	spans sync.Map
+}
+
+// NewInterceptor creates the interceptor. Register it with
+// client.WithInterceptors or IngextClient.Use.
+func NewInterceptor(opts ...Option) (*Interceptor, error) {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.tracerProvider == nil {
+		c.tracerProvider = otel.GetTracerProvider()
+	}
+	if c.meterProvider == nil {
+		c.meterProvider = otel.GetMeterProvider()
+	}
+	if c.propagator == nil {
+		c.propagator = otel.GetTextMapPropagator()
+	}
+
+	i := &Interceptor{
+		tracer:     c.tracerProvider.Tracer(ScopeName),
+		propagator: c.propagator,
+	}
+	meter := c.meterProvider.Meter(ScopeName)
+	var err error
+	if i.calls, err = meter.Int64Counter("ingext.rpc.client.calls",
+		metric.WithDescription("Number of RPC call attempts"), metric.WithUnit("{call}")); err != nil {
+		return nil, err
+	}
+	if i.duration, err = meter.Float64Histogram("ingext.rpc.client.duration",
+		metric.WithDescription("Duration of RPC call attempts"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if i.requestSize, err = meter.Int64Histogram("ingext.rpc.client.request.size",
+		metric.WithDescription("Size of RPC request bodies"), metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+	if i.responseSize, err = meter.Int64Histogram("ingext.rpc.client.response.size",
+		metric.WithDescription("Size of RPC response bodies"), metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// WithOpenTelemetry returns a client option that installs the interceptor.
+// Instrument creation errors are reported through otel.Handle and leave the
+// client uninstrumented.
+func WithOpenTelemetry(opts ...Option) client.Option {
+	i, err := NewInterceptor(opts...)
+	if err != nil {
+		otel.Handle(err)
+		return client.WithInterceptors()
+	}
+	return client.WithInterceptors(i)
+}
+
+func (i *Interceptor) BeforeRequest(ctx context.Context, call *client.Call) error {
+	ctx, span := i.tracer.Start(ctx, call.Prefix+"/"+call.Function,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			PrefixKey.String(call.Prefix),
+			FunctionKey.String(call.Function),
+			AttemptKey.Int(call.Attempt),
+			ReadOnlyKey.Bool(call.ReadOnly),
+			RequestSizeKey.Int64(call.RequestSize),
+			ServerKey.String(call.HTTPRequest.URL.Host),
+		),
+	)
+	i.propagator.Inject(ctx, propagation.HeaderCarrier(call.HTTPRequest.Header))
+	i.spans.Store(call, span)
+	return nil
+}
+
+func (i *Interceptor) AfterResponse(ctx context.Context, call *client.Call, resp *fsb.CallResponse, err error) {
+	attrs := []attribute.KeyValue{
+		PrefixKey.String(call.Prefix),
+		FunctionKey.String(call.Function),
+	}
+	if resp != nil {
+		attrs = append(attrs, VerdictKey.String(resp.Verdict))
+	}
+	if err != nil {
+		errType := string(client.ErrorKindOf(err))
+		if errType == "" {
+			errType = "other"
+		}
+		attrs = append(attrs, ErrorTypeKey.String(errType))
+	}
+	set := metric.WithAttributes(attrs...)
+	i.calls.Add(ctx, 1, set)
+	i.duration.Record(ctx, time.Since(call.Started).Seconds(), set)
+	i.requestSize.Record(ctx, call.RequestSize, set)
+	if call.HTTPResponse != nil {
+		i.responseSize.Record(ctx, call.ResponseSize, set)
+	}
+
+	v, ok := i.spans.LoadAndDelete(call)
+	if !ok {
+		return
+	}
+	span := v.(trace.Span)
+	span.SetAttributes(attrs...)
+	span.SetAttributes(ResponseSizeKey.Int64(call.ResponseSize))
+	if call.HTTPResponse != nil {
+		span.SetAttributes(HTTPStatusKey.Int(call.HTTPResponse.StatusCode))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,111 @@
+package otelclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SecurityDo/ingext_api/client"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInterceptor_RecordsSpansAndMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/ds/boom" {
+			w.Write([]byte(`{"verdict":"EXCEPTION","exception":"boom"}`))
+			return
+		}
+		w.Write([]byte(`{"verdict":"OK","response":{}}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	c, err := client.NewIngextClientWithOptions(ts.URL, "",
+		WithOpenTelemetry(WithTracerProvider(tp), WithMeterProvider(mp)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GenericCall("api/ds", "kql_search", nil); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if _, err := c.GenericCall("api/ds", "boom", nil); err == nil {
+		t.Fatalf("expected exception")
+	}
+
+	spans := sr.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].Name() != "api/ds/kql_search" {
+		t.Errorf("unexpected span name %q", spans[0].Name())
+	}
+	attrs := map[string]string{}
+	for _, kv := range spans[1].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs[string(VerdictKey)] != "EXCEPTION" || attrs[string(HTTPStatusKey)] != "200" || attrs[string(ErrorTypeKey)] != "exception" {
+		t.Errorf("unexpected span attributes: %v", attrs)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "ingext.rpc.client.calls" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				total += dp.Value
+			}
+		}
+	}
+	if total != 2 {
+		t.Errorf("expected 2 recorded calls, got %d", total)
+	}
+}
+
+func TestInterceptor_EndsSpansOfCallsHeldBack(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"verdict":"OK","response":{}}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	i, err := NewInterceptor(WithTracerProvider(tp), WithMeterProvider(sdkmetric.NewMeterProvider()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.NewIngextClientWithOptions(ts.URL, "",
+		client.WithInterceptors(i, client.NewDryRunInterceptor()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GenericCall("api/ds", "platform_add_datasource", nil); !errors.Is(err, client.ErrDryRun) {
+		t.Fatalf("expected a dry run error, got %v", err)
+	}
+	if _, err := c.GenericCall("api/ds", "platform_list_datasource", nil); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+
+	if started, ended := len(sr.Started()), len(sr.Ended()); started != 2 || ended != started {
+		t.Errorf("%d spans started, %d ended", started, ended)
+	}
+	i.spans.Range(func(k, _ any) bool {
+		t.Errorf("span of %v left in flight", k)
+		return true
+	})
+}
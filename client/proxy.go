@@ -0,0 +1,79 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ProxyDirect is the proxy setting that disables proxies, including those
+// from the environment.
+const ProxyDirect = "direct"
+
+// WithProxy sends requests through the proxy at rawURL: an http://,
+// https://, socks5:// or socks5h:// URL, optionally with user:password. Hosts
+// listed in NO_PROXY are still reached directly. ProxyDirect disables
+// proxies; "" keeps the default, the HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+// environment variables. Like TLS settings, it requires an *http.Transport.
+func WithProxy(rawURL string) Option {
+	return func(o *options) { o.proxy = rawURL }
+}
+
+// ProxyFunc returns the http.Transport.Proxy function for a proxy setting
+// as accepted by WithProxy.
+func ProxyFunc(rawURL string) (func(*http.Request) (*url.URL, error), error) {
+	switch strings.ToLower(strings.TrimSpace(rawURL)) {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case ProxyDirect, "none":
+		return nil, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %s: scheme must be http, https, socks5 or socks5h", u.Redacted())
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %s: missing host", u.Redacted())
+	}
+	cfg := &httpproxy.Config{
+		HTTPProxy:  rawURL,
+		HTTPSProxy: rawURL,
+		NoProxy:    getenvAny("NO_PROXY", "no_proxy"),
+	}
+	proxy := cfg.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}, nil
+}
+
+// SetProxy replaces the proxy setting (see WithProxy) used for new connections.
+func (r *HTTPService) SetProxy(rawURL string) error {
+	if r.transport == nil {
+		return errCustomTransport
+	}
+	proxy, err := ProxyFunc(rawURL)
+	if err != nil {
+		return err
+	}
+	r.transport.Proxy = proxy
+	r.transport.CloseIdleConnections()
+	return nil
+}
+
+func getenvAny(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"math"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimit throttles the HTTP requests of a client, e.g. to stay under the
+// server throttle of a token shared by several jobs. Every attempt of a call,
+// retries included, counts as a request. The limit is held by the client's
+// HTTPService, so it is shared by every service built on the same
+// IngextClient and by the workers of a Batch.
+type RateLimit struct {
+	// Rate is the sustained number of requests per second (no limit if <= 0).
+	Rate float64
+	// Burst is the number of requests allowed at once above Rate (Rate
+	// rounded up, at least 1, if <= 0).
+	Burst int
+	// MaxInFlight caps the number of requests in progress (no cap if <= 0).
+	MaxInFlight int
+}
+
+// WithRateLimit throttles the client's requests; see RateLimit.
+func WithRateLimit(l *RateLimit) Option {
+	return func(o *options) { o.rateLimit = l }
+}
+
+// throttle enforces a RateLimit.
+type throttle struct {
+	limiter  *rate.Limiter
+	inFlight chan struct{}
+}
+
+func newThrottle(l *RateLimit) *throttle {
+	if l == nil || (l.Rate <= 0 && l.MaxInFlight <= 0) {
+		return nil
+	}
+	t := &throttle{}
+	if l.Rate > 0 {
+		burst := l.Burst
+		if burst <= 0 {
+			burst = int(math.Max(1, math.Ceil(l.Rate)))
+		}
+		t.limiter = rate.NewLimiter(rate.Limit(l.Rate), burst)
+	}
+	if l.MaxInFlight > 0 {
+		t.inFlight = make(chan struct{}, l.MaxInFlight)
+	}
+	return t
+}
+
+// acquire waits for a request slot and returns the function releasing it.
+func (t *throttle) acquire(ctx context.Context) (release func(), err error) {
+	if t == nil {
+		return func() {}, nil
+	}
+	if t.inFlight != nil {
+		select {
+		case t.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release = func() {
+		if t.inFlight != nil {
+			<-t.inFlight
+		}
+	}
+	if t.limiter != nil {
+		if err := t.limiter.Wait(ctx); err != nil {
+			release()
+			// Wait fails early when ctx would expire before a token is available.
+			if err = ctx.Err(); err == nil {
+				err = context.DeadlineExceeded
+			}
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// SetRateLimit replaces the rate limit of the client's requests; nil removes
+// it. Calls already waiting keep the previous limit.
+func (r *HTTPService) SetRateLimit(l *RateLimit) {
+	r.throttle.Store(newThrottle(l))
+}
+
+// SetRateLimit replaces the rate limit shared by every service using r.
+func (r *IngextClient) SetRateLimit(l *RateLimit) {
+	r.serviceClient.SetRateLimit(l)
+}
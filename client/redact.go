@@ -0,0 +1,107 @@
+package client
+
+import (
+	"regexp"
+	"strings"
+
+	fsb "github.com/SecurityDo/ingext_api/fsb"
+)
+
+// RedactedMask replaces secret values in redacted output.
+const RedactedMask = "***REDACTED***"
+
+// DefaultSensitiveKeys are the JSON field names (case-insensitive) whose
+// values are masked by DefaultRedactor. Any key containing "secret" or
+// "password" is masked as well.
+var DefaultSensitiveKeys = []string{
+	"token",
+	"apiToken",
+	"accessToken",
+	"refreshToken",
+	"securityToken",
+	"password",
+	"secret",
+	"secretKey",
+	"clientSecret",
+	"privateKey",
+	"apiKey",
+	"applicationKey",
+	"integrationKey",
+}
+
+// DefaultSensitiveHeaders are the HTTP headers masked in dumps.
+var DefaultSensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// Redactor masks credentials in HTTP dumps and JSON payloads while leaving the
+// rest of the text untouched. HTTPService runs every debug dump through its
+// Redactor (DefaultRedactor unless changed with WithRedactor).
+type Redactor struct {
+	keys      map[string]bool
+	headerRe  *regexp.Regexp
+	jsonKeyRe *regexp.Regexp
+}
+
+// NewRedactor returns a Redactor masking the given JSON keys and HTTP headers.
+func NewRedactor(keys []string, headers []string) *Redactor {
+	r := &Redactor{keys: map[string]bool{}}
+	for _, k := range keys {
+		r.keys[strings.ToLower(k)] = true
+	}
+	quoted := make([]string, 0, len(headers))
+	for _, h := range headers {
+		quoted = append(quoted, regexp.QuoteMeta(h))
+	}
+	if len(quoted) > 0 {
+		// Keep the auth scheme ("Bearer", "Basic") so dumps stay readable.
+		r.headerRe = regexp.MustCompile(`(?im)^((?:` + strings.Join(quoted, "|") + `):[ \t]*(?:(?:Bearer|Basic|Token)[ \t]+)?)[^\r\n]+`)
+	}
+	// "key" : "string value" (handles escaped quotes inside the value)
+	r.jsonKeyRe = regexp.MustCompile(`"([A-Za-z0-9_\-]+)"(\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	return r
+}
+
+// DefaultRedactor masks DefaultSensitiveKeys and DefaultSensitiveHeaders.
+func DefaultRedactor() *Redactor {
+	return NewRedactor(DefaultSensitiveKeys, DefaultSensitiveHeaders)
+}
+
+// IsSensitiveKey reports whether a JSON field with this name is masked.
+func (r *Redactor) IsSensitiveKey(key string) bool {
+	k := strings.ToLower(key)
+	return r.keys[k] || strings.Contains(k, "secret") || strings.Contains(k, "password")
+}
+
+// RedactJSON masks the string values of sensitive keys in a JSON document
+// (or any text embedding JSON) without reformatting it.
+func (r *Redactor) RedactJSON(b []byte) []byte {
+	return r.jsonKeyRe.ReplaceAllFunc(b, func(m []byte) []byte {
+		sub := r.jsonKeyRe.FindSubmatch(m)
+		if !r.IsSensitiveKey(string(sub[1])) {
+			return m
+		}
+		out := make([]byte, 0, len(sub[1])+len(sub[2])+len(RedactedMask)+4)
+		out = append(out, '"')
+		out = append(out, sub[1]...)
+		out = append(out, '"')
+		out = append(out, sub[2]...)
+		out = append(out, '"')
+		out = append(out, RedactedMask...)
+		return append(out, '"')
+	})
+}
+
+// RedactJNode returns a copy of n with sensitive values masked.
+func (r *Redactor) RedactJNode(n *fsb.JNode) *fsb.JNode {
+	if n == nil {
+		return nil
+	}
+	return fsb.NewJNodeByte(r.RedactJSON(n.GetBytes()))
+}
+
+// FilterDump masks sensitive headers and JSON values in an HTTP dump.
+func (r *Redactor) FilterDump(dump []byte) []byte {
+	if r.headerRe != nil {
+		dump = r.headerRe.ReplaceAll(dump, []byte("${1}"+RedactedMask))
+	}
+	return r.RedactJSON(dump)
+}
@@ -0,0 +1,240 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+
+	fsb "github.com/SecurityDo/ingext_api/fsb"
+)
+
+// RetryPolicy controls how HTTPService retries RPC calls that fail with a
+// transient error (HTTP 429/502/503/504, connection reset/refused).
+//
+// Only read-style calls are retried unless RetryMutating is set: retrying an
+// "add" whose response was lost could create the entry twice.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values <= 1 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry; it doubles on every
+	// subsequent retry up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter randomizes each delay by up to this fraction (0.2 = ±20%).
+	Jitter float64
+	// MaxRetryAfter caps how long a server supplied Retry-After header may
+	// delay the next attempt. Longer requests abort the retry loop.
+	MaxRetryAfter time.Duration
+	// RetryStatuses lists the HTTP status codes that are considered transient.
+	RetryStatuses []int
+	// RetryMutating allows retrying calls that are not classified as
+	// read-only (see IsReadOnlyCall). Off by default.
+	RetryMutating bool
+}
+
+// DefaultRetryPolicy returns the policy used by new clients: up to 3 attempts
+// for read-only calls, never retrying mutating calls.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Jitter:         0.2,
+		MaxRetryAfter:  time.Minute,
+		RetryStatuses: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// NoRetryPolicy returns a policy that makes exactly one attempt per call.
+func NoRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{MaxAttempts: 1}
+}
+
+// shouldRetryCall reports whether the call may be retried at all.
+func (p *RetryPolicy) shouldRetryCall(functionName string, kargs *fsb.JNode) bool {
+	if p == nil || p.MaxAttempts <= 1 {
+		return false
+	}
+	return p.RetryMutating || IsReadOnlyCall(functionName, kargs)
+}
+
+// isTransient reports whether err (as returned by callOnce) may succeed on retry.
+func (p *RetryPolicy) isTransient(err error) bool {
+	rpcErr, ok := err.(*RPCError)
+	if !ok || rpcErr.Verdict != "" {
+		return false
+	}
+	if rpcErr.HTTPStatus == 0 {
+		return rpcErr.Kind() == KindTransport && isTransientNetError(rpcErr.Err)
+	}
+	for _, c := range p.RetryStatuses {
+		if c == rpcErr.HTTPStatus {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before retry number attempt (1-based), honoring
+// retryAfter when the server sent one. ok is false when the server asked us
+// to wait longer than MaxRetryAfter.
+func (p *RetryPolicy) backoff(attempt int, retryAfter time.Duration) (delay time.Duration, ok bool) {
+	delay = p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if p.Jitter > 0 && delay > 0 {
+		delta := (rand.Float64()*2 - 1) * p.Jitter * float64(delay)
+		delay += time.Duration(delta)
+	}
+	if retryAfter > 0 {
+		if p.MaxRetryAfter > 0 && retryAfter > p.MaxRetryAfter {
+			return 0, false
+		}
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+	}
+	return delay, true
+}
+
+// callVerbs are the verbs that can name the action of an RPC function, read
+// (true) or not (false). The first of them in a function name is its verb,
+// e.g. "list" in platform_list_datasource and userList, "update" in
+// update_status.
+var callVerbs = map[string]bool{
+	"list":   true,
+	"get":    true,
+	"search": true,
+	"tail":   true,
+
+	"add":       false,
+	"create":    false,
+	"update":    false,
+	"set":       false,
+	"save":      false,
+	"put":       false,
+	"delete":    false,
+	"remove":    false,
+	"clear":     false,
+	"register":  false,
+	"install":   false,
+	"uninstall": false,
+	"import":    false,
+	"reload":    false,
+	"run":       false,
+	"test":      false,
+}
+
+// readOnlyFunctions are the read functions whose name has no read verb.
+var readOnlyFunctions = map[string]bool{
+	"kql_validate":                   true,
+	"platform_processor_validate":    true,
+	"platform_component_metrics":     true,
+	"platform_processor_metrics":     true,
+	"platform_processor_pipes":       true,
+	"platform_profile_component":     true,
+	"platform_profile_total":         true,
+	"platform_import_device_metrics": true,
+	"platform_import_device_search":  true,
+	"platform_import_device_states":  true,
+}
+
+// IsReadOnlyCall reports whether an RPC function is safe to retry: it is a
+// known read function, its verb (see callVerbs) is a read verb (list, get,
+// search, tail), or it is a DAO call (e.g. platform_datasource_dao) whose
+// action is "get" or "list".
+func IsReadOnlyCall(functionName string, kargs *fsb.JNode) bool {
+	if readOnlyFunctions[functionName] {
+		return true
+	}
+	for _, w := range splitFunctionName(functionName) {
+		if read, ok := callVerbs[w]; ok {
+			return read
+		}
+	}
+	if kargs == nil {
+		return false
+	}
+	var dao struct {
+		Action string `json:"action"`
+	}
+	if err := json.Unmarshal(kargs.GetBytes(), &dao); err != nil {
+		return false
+	}
+	switch strings.ToLower(dao.Action) {
+	case "get", "list":
+		return true
+	}
+	return false
+}
+
+// splitFunctionName splits snake_case and camelCase names into lower-case words.
+func splitFunctionName(name string) []string {
+	var words []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			words = append(words, strings.ToLower(cur.String()))
+			cur.Reset()
+		}
+	}
+	for _, r := range name {
+		switch {
+		case r == '_' || r == '-' || r == '.':
+			flush()
+		case unicode.IsUpper(r):
+			flush()
+			cur.WriteRune(r)
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return words
+}
+
+// isTransientNetError reports whether a transport error is worth retrying.
+func isTransientNetError(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// parseRetryAfter parses a Retry-After header (delay-seconds or HTTP-date).
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	fsb "github.com/SecurityDo/ingext_api/fsb"
+)
+
+// DefaultMaxResponseSize bounds the size of a response body read by
+// HTTPService unless changed with WithMaxResponseSize.
+const DefaultMaxResponseSize = 1 << 30 // 1 GiB
+
+var (
+	// ErrResponseTooLarge is wrapped by the RPCError returned when a response
+	// body exceeds the configured maximum size.
+	ErrResponseTooLarge = errors.New("response exceeds maximum size")
+	// ErrEmptyResponse is returned by CallInto when the reply carries no response.
+	ErrEmptyResponse = errors.New("empty response")
+)
+
+// limitedReader counts the bytes read and fails with ErrResponseTooLarge
+// once more than limit bytes were read (limit <= 0 means no limit).
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	n     int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.limit > 0 && l.n > l.limit {
+		return 0, fmt.Errorf("%w (%d bytes)", ErrResponseTooLarge, l.limit)
+	}
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.limit > 0 && l.n > l.limit {
+		// Drop the bytes past the limit so the decoder cannot complete a value.
+		n -= int(l.n - l.limit)
+		return n, fmt.Errorf("%w (%d bytes)", ErrResponseTooLarge, l.limit)
+	}
+	return n, err
+}
+
+// responseTarget decodes the "response" field into out and records whether it
+// was null. encoding/json reads the whole value into the decoder's buffer
+// before calling UnmarshalJSON, which gets a slice of that buffer, so the
+// value is held in memory once but decoded into out without another copy.
+// Numbers held in interface{} values are decoded as json.Number, as with
+// JNode.GetMap.
+type responseTarget struct {
+	out     interface{}
+	present bool
+}
+
+func (t *responseTarget) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	t.present = true
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	return decoder.Decode(t.out)
+}
+
+// decodeResponseInto reads an fsb.CallResponse envelope from body, decoding
+// its "response" field into out instead of keeping it as a JNode. The
+// envelope is read as a stream, field by field; the response value itself is
+// buffered by the decoder (see responseTarget). The returned CallResponse has
+// a nil Response.
+func decodeResponseInto(body io.Reader, out interface{}) (res *fsb.CallResponse, present bool, err error) {
+	dec := json.NewDecoder(body)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, false, err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return nil, false, fmt.Errorf("expected JSON object, got %v", tok)
+	}
+
+	res = new(fsb.CallResponse)
+	target := &responseTarget{out: out}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, false, err
+		}
+		key, _ := tok.(string)
+		switch key {
+		case "verdict":
+			err = dec.Decode(&res.Verdict)
+		case "error":
+			err = dec.Decode(&res.Error)
+		case "exception":
+			err = dec.Decode(&res.Exception)
+		case "attachments":
+			err = dec.Decode(&res.Attachments)
+		case "response":
+			err = dec.Decode(target)
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, false, err
+	}
+	return res, target.present, nil
+}
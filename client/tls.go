@@ -0,0 +1,59 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSConfig describes how HTTPService verifies the site certificate and
+// whether it presents a client certificate (mTLS). The zero value verifies
+// the server against the system root CAs.
+type TLSConfig struct {
+	// CAFile is a PEM bundle of additional trusted CAs (e.g. a private CA).
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and key for mTLS.
+	// Both must be set together.
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify disables server certificate verification. Only use
+	// it for local testing.
+	InsecureSkipVerify bool
+}
+
+// Build loads the referenced files and returns the equivalent *tls.Config.
+func (c *TLSConfig) Build() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c == nil {
+		return cfg, nil
+	}
+	cfg.InsecureSkipVerify = c.InsecureSkipVerify
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file %q: %w", c.CAFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in CA file %q", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, fmt.Errorf("client certificate and key must be set together")
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate %q: %w", c.CertFile, err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
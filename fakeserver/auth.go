@@ -0,0 +1,99 @@
+package fakeserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	fsb "github.com/SecurityDo/ingext_api/fsb"
+)
+
+func (s *Server) registerAuth() {
+	s.handleFunc(authPrefix, "userAdd", s.addUser)
+	s.handleFunc(authPrefix, "userList", func(*fsb.JNode) (interface{}, error) {
+		return map[string]interface{}{"users": s.collections[Users].list()}, nil
+	})
+	s.handleFunc(authPrefix, "getUser", s.getUser)
+	s.handleFunc(authPrefix, "userDelete", s.deleteUser)
+	s.handleFunc(authPrefix, "setUserSitePolicy", s.setUserSitePolicy)
+	s.handleFunc(authPrefix, "api_token", s.apiToken)
+}
+
+type usernameRequest struct {
+	Username   string `json:"username"`
+	PolicyName string `json:"policyName"`
+}
+
+func (s *Server) addUser(kargs *fsb.JNode) (interface{}, error) {
+	var req struct {
+		User map[string]interface{} `json:"user"`
+	}
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	if req.User == nil || req.User["username"] == nil || req.User["username"] == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+	_, err := s.collections[Users].add(req.User, "")
+	return nil, err
+}
+
+func (s *Server) getUser(kargs *fsb.JNode) (interface{}, error) {
+	var req usernameRequest
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	user, err := s.collections[Users].get(req.Username)
+	if err != nil {
+		return nil, fmt.Errorf("user %w", err)
+	}
+	return map[string]interface{}{"user": user}, nil
+}
+
+func (s *Server) deleteUser(kargs *fsb.JNode) (interface{}, error) {
+	var req usernameRequest
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	if err := s.collections[Users].delete(req.Username); err != nil {
+		return nil, fmt.Errorf("user %w", err)
+	}
+	return nil, nil
+}
+
+func (s *Server) setUserSitePolicy(kargs *fsb.JNode) (interface{}, error) {
+	var req usernameRequest
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	user, err := s.collections[Users].get(req.Username)
+	if err != nil {
+		return nil, fmt.Errorf("user %w", err)
+	}
+	user["sitePolicy"] = req.PolicyName
+	return nil, nil
+}
+
+// apiToken is the token DAO, keyed by token name. add replies with a
+// generated token value, which is not stored.
+func (s *Server) apiToken(kargs *fsb.JNode) (interface{}, error) {
+	var req daoRequest
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	if req.Action != "add" {
+		return s.dao(Tokens)(kargs)
+	}
+	if req.Args.Entry == nil || req.Args.Entry["name"] == nil || req.Args.Entry["name"] == "" {
+		return nil, fmt.Errorf("token name is required")
+	}
+	req.Args.Entry["id"] = s.newID(Tokens)
+	if _, err := s.collections[Tokens].add(req.Args.Entry, ""); err != nil {
+		return nil, err
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return map[string]string{"token": "fake-" + hex.EncodeToString(b)}, nil
+}
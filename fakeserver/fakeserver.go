@@ -0,0 +1,347 @@
+// Package fakeserver is an in-memory stand-in for an Ingext site. It speaks
+// the fsb protocol on the api/ds and api/auth prefixes and keeps data
+// sources, sinks, routers, pipes, processors, integrations, users, tokens,
+// schemas, the syslog config and the other DAO collections in memory, so the client, the api
+// services and the CLI can be exercised without a live site.
+//
+// From Go tests:
+//
+//	srv, cli := fakeserver.NewTest(t)
+//	platform := api.NewPlatformService(cli)
+//
+// From the command line: ingext dev serve.
+package fakeserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	fsb "github.com/SecurityDo/ingext_api/fsb"
+	kqlModel "github.com/SecurityDo/ingext_api/kql/model"
+)
+
+// Collection names accepted by Add, Get, List and Len.
+const (
+	Sources               = "sources"
+	Sinks                 = "sinks"
+	Routers               = "routers"
+	Pipes                 = "pipes"
+	Channels              = "channels"
+	Processors            = "processors"
+	Integrations          = "integrations"
+	InstanceRoles         = "instanceRoles"
+	NotificationEndpoints = "notificationEndpoints"
+	AppTemplates          = "appTemplates"
+	ImportDevices         = "importDevices"
+	Repos                 = "repos"
+	Datalakes             = "datalakes"
+	DatalakeIndexes       = "datalakeIndexes"
+	Schemas               = "schemas"
+	Users                 = "users"
+	Tokens                = "tokens"
+)
+
+// collectionKeys maps each collection to the entry field holding its ID.
+var collectionKeys = map[string]string{
+	Sources:               "id",
+	Sinks:                 "id",
+	Routers:               "id",
+	Pipes:                 "id",
+	Channels:              "id",
+	Processors:            "name",
+	Integrations:          "id",
+	InstanceRoles:         "id",
+	NotificationEndpoints: "name",
+	AppTemplates:          "name",
+	ImportDevices:         "name",
+	Repos:                 "id",
+	Datalakes:             "name",
+	DatalakeIndexes:       "id",
+	Schemas:               "name",
+	Users:                 "username",
+	Tokens:                "name",
+}
+
+// Server is the fake site. Its embedded fsb.Server can be used to override
+// or add functions with Handle, or to add middleware with Use.
+type Server struct {
+	*fsb.Server
+
+	// Token, when set, must be sent as "Authorization: Bearer <Token>";
+	// other calls fail with HTTP 401.
+	Token string
+
+	mu          sync.Mutex
+	collections map[string]*collection
+	connections map[string]string // data source ID -> router ID
+	syslog      map[string]interface{}
+	kqlResults  map[string]*kqlModel.KQLSearchResponse
+	defaultKQL  *kqlModel.KQLSearchResponse
+	calls       []string
+	nextID      int
+}
+
+// New returns an empty fake site.
+func New() *Server {
+	s := &Server{
+		Server:      fsb.NewServer(),
+		collections: map[string]*collection{},
+		connections: map[string]string{},
+		kqlResults:  map[string]*kqlModel.KQLSearchResponse{},
+		defaultKQL:  DefaultKQLResponse(),
+	}
+	for name, key := range collectionKeys {
+		s.collections[name] = &collection{key: key, items: map[string]map[string]interface{}{}}
+	}
+	s.Server.Use(s.authenticate, s.record)
+	s.registerPlatform()
+	s.registerAuth()
+	s.registerKQL()
+	return s
+}
+
+// Calls returns the "<prefix>/<function>" names called so far, in order.
+func (s *Server) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+// Add stores entry (any JSON-encodable value) in the named collection and
+// returns its ID, generating one when the entry has none.
+func (s *Server) Add(name string, entry interface{}) (string, error) {
+	m, err := toMap(entry)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.collection(name)
+	if err != nil {
+		return "", err
+	}
+	return c.add(m, s.newID(name))
+}
+
+// Get decodes the entry with the given ID into out and reports whether it exists.
+func (s *Server) Get(name, id string, out interface{}) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.collection(name)
+	if err != nil {
+		return false, err
+	}
+	m, ok := c.items[id]
+	if !ok {
+		return false, nil
+	}
+	return true, fromMap(m, out)
+}
+
+// List decodes all entries of the named collection, in insertion order, into
+// out, which must point to a slice.
+func (s *Server) List(name string, out interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.collection(name)
+	if err != nil {
+		return err
+	}
+	return fromMap(c.list(), out)
+}
+
+// Len returns the number of entries in the named collection.
+func (s *Server) Len(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c := s.collections[name]; c != nil {
+		return len(c.order)
+	}
+	return 0
+}
+
+func (s *Server) collection(name string) (*collection, error) {
+	c := s.collections[name]
+	if c == nil {
+		return nil, fmt.Errorf("unknown collection %q", name)
+	}
+	return c, nil
+}
+
+// newID returns a fresh ID such as "sources-3".
+func (s *Server) newID(name string) string {
+	s.nextID++
+	return name + "-" + strconv.Itoa(s.nextID)
+}
+
+// authenticate rejects calls without the configured bearer token.
+func (s *Server) authenticate(next fsb.ServiceAction) fsb.ServiceAction {
+	return func(kargs *fsb.JNode, attachments []*fsb.Attachment, context interface{}) (*fsb.CallResponse, error) {
+		if s.Token != "" {
+			rc := context.(*fsb.RequestContext)
+			if rc.Request.Header.Get("Authorization") != "Bearer "+s.Token {
+				return nil, fsb.ApiError{Code: http.StatusUnauthorized, Info: "unauthorized"}
+			}
+		}
+		return next(kargs, attachments, context)
+	}
+}
+
+// record appends the call to Calls.
+func (s *Server) record(next fsb.ServiceAction) fsb.ServiceAction {
+	return func(kargs *fsb.JNode, attachments []*fsb.Attachment, context interface{}) (*fsb.CallResponse, error) {
+		rc := context.(*fsb.RequestContext)
+		s.mu.Lock()
+		s.calls = append(s.calls, rc.Prefix+"/"+rc.Function)
+		s.mu.Unlock()
+		return next(kargs, attachments, context)
+	}
+}
+
+// handleFunc registers fn under prefix/function. fn runs with s.mu held and
+// its result is sent as the OK response.
+func (s *Server) handleFunc(prefix, function string, fn func(kargs *fsb.JNode) (interface{}, error)) {
+	s.Handle(prefix, function, func(kargs *fsb.JNode, _ []*fsb.Attachment, _ interface{}) (*fsb.CallResponse, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		res, err := fn(kargs)
+		if err != nil {
+			return nil, err
+		}
+		if res == nil {
+			res = struct{}{}
+		}
+		// Encode while holding the lock: res may share maps with the store.
+		return fsb.NewOKInterfaceResponse(res)
+	})
+}
+
+// collection is an ordered set of JSON objects keyed by the key field.
+type collection struct {
+	key   string
+	order []string
+	items map[string]map[string]interface{}
+}
+
+func (c *collection) add(entry map[string]interface{}, newID string) (string, error) {
+	id, _ := entry[c.key].(string)
+	if id == "" {
+		id = newID
+		entry[c.key] = id
+	}
+	if _, exists := c.items[id]; exists {
+		return "", fmt.Errorf("%s %q already exists", c.key, id)
+	}
+	c.items[id] = entry
+	c.order = append(c.order, id)
+	return id, nil
+}
+
+func (c *collection) get(id string) (map[string]interface{}, error) {
+	entry, ok := c.items[id]
+	if !ok {
+		return nil, fmt.Errorf("%q not found", id)
+	}
+	return entry, nil
+}
+
+func (c *collection) update(id string, entry map[string]interface{}) error {
+	if id == "" {
+		id, _ = entry[c.key].(string)
+	}
+	if _, ok := c.items[id]; !ok {
+		return fmt.Errorf("%q not found", id)
+	}
+	entry[c.key] = id
+	c.items[id] = entry
+	return nil
+}
+
+func (c *collection) delete(id string) error {
+	if _, ok := c.items[id]; !ok {
+		return fmt.Errorf("%q not found", id)
+	}
+	delete(c.items, id)
+	for i, v := range c.order {
+		if v == id {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (c *collection) list() []map[string]interface{} {
+	entries := make([]map[string]interface{}, 0, len(c.order))
+	for _, id := range c.order {
+		entries = append(entries, c.items[id])
+	}
+	return entries
+}
+
+// find returns the entries whose field equals value.
+func (c *collection) find(field string, value interface{}) []map[string]interface{} {
+	entries := []map[string]interface{}{}
+	for _, entry := range c.list() {
+		if entry[field] == value {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func toMap(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	if m == nil {
+		m = map[string]interface{}{}
+	}
+	return m, nil
+}
+
+func fromMap(v interface{}, out interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func decodeKargs(kargs *fsb.JNode, v interface{}) error {
+	if err := kargs.Decode(v); err != nil {
+		return fmt.Errorf("invalid kargs: %w", err)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stringsOf returns the string elements of a decoded JSON array.
+func stringsOf(v interface{}) []string {
+	items, _ := v.([]interface{})
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
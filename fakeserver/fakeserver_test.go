@@ -0,0 +1,174 @@
+package fakeserver_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SecurityDo/ingext_api/api"
+	"github.com/SecurityDo/ingext_api/client"
+	"github.com/SecurityDo/ingext_api/fakeserver"
+	"github.com/SecurityDo/ingext_api/model"
+)
+
+func TestPlatformCRUD(t *testing.T) {
+	srv, cli := fakeserver.NewTest(t)
+	platform := api.NewPlatformService(cli)
+
+	added, err := platform.AddDataSource(&model.DataSourceConfig{Name: "hec-in"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := platform.GetDataSource(added.ID)
+	if err != nil || src.Name != "hec-in" || src.ID != added.ID {
+		t.Fatalf("GetDataSource = %+v, %v", src, err)
+	}
+	src.Format = "json"
+	if err := platform.UpdateDataSource(src); err != nil {
+		t.Fatal(err)
+	}
+	if src, err := platform.GetDataSource(added.ID); err != nil || src.Format != "json" {
+		t.Fatalf("GetDataSource after update = %+v, %v", src, err)
+	}
+
+	if err := platform.AddProcessor(&model.FPLScript{Name: "parse"}); err != nil {
+		t.Fatal(err)
+	}
+	routerID, err := platform.AddSimpleRouter("parse", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := platform.SetDataSourceRouter(&api.SourceSetRouterReq{RouterID: routerID, DataSourceID: added.ID}); err != nil {
+		t.Fatal(err)
+	}
+	pipe, err := platform.AddRouterPipe(&api.RouterAddPipeReq{RouterID: routerID, PipeConfig: &model.StreamPipeConfig{Name: "second"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router, err := platform.GetRouter(routerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if router.Entry.Name != "parse" || len(router.Pipes) != 2 || router.Pipes[1].ID != pipe.ID {
+		t.Fatalf("GetRouter = %+v", router)
+	}
+	if router.Pipes[0].ProcessorNames[0] != "parse" {
+		t.Errorf("simple router pipe processors = %v", router.Pipes[0].ProcessorNames)
+	}
+
+	configs, err := platform.ListConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs.Sources) != 1 || len(configs.Routers) != 1 || len(configs.Pipes) != 2 || len(configs.Connections) != 1 {
+		t.Errorf("ListConfigs = %+v", configs)
+	}
+
+	if err := platform.DeleteRouter(routerID); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Len(fakeserver.Pipes); n != 0 {
+		t.Errorf("pipes left after router delete: %d", n)
+	}
+	_, err = platform.GetDataSink("missing")
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetDataSink(missing) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAppTemplatesAndSyslog(t *testing.T) {
+	_, cli := fakeserver.NewTest(t)
+	apps := api.NewApplicationService(cli)
+
+	content := "application:\n  metadata:\n    name: demo\n  spec:\n    displayName: Demo\n"
+	if _, err := apps.AddAppTemplate(content); err != nil {
+		t.Fatal(err)
+	}
+	list, err := apps.ListAppTemplates()
+	if err != nil || len(list.Entries) != 1 || list.Entries[0].Name != "demo" || list.Entries[0].DisplayName != "Demo" {
+		t.Fatalf("ListAppTemplates = %+v, %v", list, err)
+	}
+	entry, err := apps.GetAppTemplate("demo")
+	if err != nil || entry.Content != content {
+		t.Fatalf("GetAppTemplate = %+v, %v", entry, err)
+	}
+
+	syslog := api.NewSyslogService(cli)
+	if resp, err := syslog.Get(); err != nil || resp.Config != nil {
+		t.Fatalf("Get before register = %+v, %v", resp, err)
+	}
+	if _, err := syslog.Register([]string{"udp"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := syslog.Update([]string{"tcp", "tls"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := syslog.Get()
+	if err != nil || resp.Config == nil || resp.Config.SyslogUDP || !resp.Config.SyslogTCP || !resp.Config.SyslogTLS {
+		t.Fatalf("Get after update = %+v, %v", resp, err)
+	}
+}
+
+func TestComponentMetricsBatch(t *testing.T) {
+	srv, cli := fakeserver.NewTest(t)
+	platform := api.NewPlatformService(cli)
+
+	reqs := []*api.ComponentMetricReq{
+		{Component: "source", ID: "sources-1", From: "2026-01-01T00:00:00Z", To: "2026-01-01T00:15:00Z", Interval: "1m"},
+		{Component: "sink", ID: "sinks-1", From: "2026-01-01T00:00:00Z", To: "2026-01-01T00:15:00Z", Interval: "1m"},
+	}
+	resps, results := platform.ComponentMetricsBatch(reqs, &client.BatchOptions{Concurrency: 2})
+	if err := client.BatchErrors(results); err != nil {
+		t.Fatal(err)
+	}
+	for i, resp := range resps {
+		if resp == nil || resp.Metrics == nil || len(resp.Metrics) != 0 {
+			t.Errorf("resps[%d] = %+v", i, resp)
+		}
+	}
+	if calls := srv.Calls(); len(calls) != 2 {
+		t.Errorf("Calls = %v", calls)
+	}
+}
+
+func TestAuthAndKQL(t *testing.T) {
+	srv, cli := fakeserver.NewTest(t)
+	auth := api.NewAuthService(cli)
+
+	if err := auth.AddUser(&api.AddUserRequest{User: &model.UserEntry{Username: "alice"}}); err != nil {
+		t.Fatal(err)
+	}
+	user, err := auth.GetUser("alice")
+	if err != nil || user.Username != "alice" {
+		t.Fatalf("GetUser = %+v, %v", user, err)
+	}
+	token, err := auth.AddToken("ci", "", "admin")
+	if err != nil || token == "" {
+		t.Fatalf("AddToken = %q, %v", token, err)
+	}
+	tokens, err := auth.ListToken()
+	if err != nil || len(tokens) != 1 || tokens[0].Name != "ci" {
+		t.Fatalf("ListToken = %+v, %v", tokens, err)
+	}
+
+	search := api.NewSearchService(cli)
+	resp, err := search.KQLSearch("logs | take 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 2 || resp.Data.GetTable("PrimaryResult") == nil {
+		t.Errorf("KQLSearch = %+v", resp)
+	}
+	srv.SetKQLResult("empty", fakeserver.NewKQLResponse())
+	if resp, err := search.KQLSearch("empty"); err != nil || resp.Total != 0 {
+		t.Errorf("KQLSearch(empty) = %+v, %v", resp, err)
+	}
+
+	// A client with the wrong token is rejected.
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	bad := client.NewIngextClient(ts.URL, "wrong", false, nil)
+	if _, err := api.NewAuthService(bad).ListUser(); !errors.Is(err, client.ErrUnauthorized) {
+		t.Errorf("ListUser with bad token = %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,87 @@
+package fakeserver
+
+import (
+	"strings"
+	"time"
+
+	fsb "github.com/SecurityDo/ingext_api/fsb"
+	kqlModel "github.com/SecurityDo/ingext_api/kql/model"
+)
+
+// DefaultKQLResponse is the canned kql_search result returned for queries
+// without a result set by SetKQLResult: a "PrimaryResult" table with two rows.
+func DefaultKQLResponse() *kqlModel.KQLSearchResponse {
+	scheme := kqlModel.NewColumnInfo([]string{"timestamp", "source", "message"})
+	ts := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := []kqlModel.Row{
+		kqlModel.NewRow(scheme, []kqlModel.KValue{kqlModel.NewKDateTime(ts), kqlModel.NewKString("fake"), kqlModel.NewKString("hello")}),
+		kqlModel.NewRow(scheme, []kqlModel.KValue{kqlModel.NewKDateTime(ts.Add(time.Second)), kqlModel.NewKString("fake"), kqlModel.NewKString("world")}),
+	}
+	return NewKQLResponse(&kqlModel.DataTable{Name: "PrimaryResult", Columns: kqlModel.InferColumnDefs(rows), Rows: rows})
+}
+
+// NewKQLResponse wraps tables in a KQLSearchResponse whose Total is the row
+// count of the first table.
+func NewKQLResponse(tables ...*kqlModel.DataTable) *kqlModel.KQLSearchResponse {
+	ds := kqlModel.NewDataSet()
+	for _, t := range tables {
+		ds.AddTable(t)
+	}
+	resp := &kqlModel.KQLSearchResponse{Data: ds}
+	if len(tables) > 0 {
+		resp.Total = int64(len(tables[0].Rows))
+	}
+	return resp
+}
+
+// SetKQLResult makes kql_search return resp for the query kql (compared
+// after trimming spaces).
+func (s *Server) SetKQLResult(kql string, resp *kqlModel.KQLSearchResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kqlResults[strings.TrimSpace(kql)] = resp
+}
+
+// SetDefaultKQLResult sets the result returned for queries without a result
+// set by SetKQLResult.
+func (s *Server) SetDefaultKQLResult(resp *kqlModel.KQLSearchResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaultKQL = resp
+}
+
+type kqlRequest struct {
+	KQL       string `json:"kql"`
+	RangeFrom int64  `json:"rangeFrom"`
+	RangeTo   int64  `json:"rangeTo"`
+}
+
+func (s *Server) registerKQL() {
+	s.handleFunc(dsPrefix, "kql_search", s.kqlSearch)
+	s.handleFunc(dsPrefix, "kql_validate", s.kqlValidate)
+}
+
+func (s *Server) kqlSearch(kargs *fsb.JNode) (interface{}, error) {
+	var req kqlRequest
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	if res := s.kqlResults[strings.TrimSpace(req.KQL)]; res != nil {
+		return res, nil
+	}
+	return s.defaultKQL, nil
+}
+
+// kqlValidate accepts any non-empty query and reports its leading table name.
+func (s *Server) kqlValidate(kargs *fsb.JNode) (interface{}, error) {
+	var req kqlRequest
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	kql := strings.TrimSpace(req.KQL)
+	if kql == "" {
+		return map[string]interface{}{"ok": false, "error": "empty query"}, nil
+	}
+	table := strings.TrimSpace(strings.SplitN(kql, "|", 2)[0])
+	return map[string]interface{}{"ok": true, "table": table}, nil
+}
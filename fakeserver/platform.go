@@ -0,0 +1,545 @@
+package fakeserver
+
+import (
+	"fmt"
+
+	fsb "github.com/SecurityDo/ingext_api/fsb"
+	"github.com/SecurityDo/ingext_api/model"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	dsPrefix   = "api/ds"
+	authPrefix = "api/auth"
+)
+
+// Plugins is the list returned by platform_list_plugins.
+var Plugins = []string{"hec", "webhook", "s3", "syslog", "okta", "office365_audit"}
+
+// daoRequest is the {action, args{id, entry}} payload of the *_dao functions.
+type daoRequest struct {
+	Action string `json:"action"`
+	Args   struct {
+		ID    string                 `json:"id"`
+		Entry map[string]interface{} `json:"entry"`
+	} `json:"args"`
+}
+
+// dao serves get/add/update/delete/list on the named collection, replying
+// with {entry}, {id}, {} and {entries}.
+func (s *Server) dao(name string) func(kargs *fsb.JNode) (interface{}, error) {
+	return func(kargs *fsb.JNode) (interface{}, error) {
+		var req daoRequest
+		if err := decodeKargs(kargs, &req); err != nil {
+			return nil, err
+		}
+		c := s.collections[name]
+		switch req.Action {
+		case "get":
+			entry, err := c.get(req.Args.ID)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"entry": entry}, nil
+		case "add":
+			entry := req.Args.Entry
+			if entry == nil {
+				entry = map[string]interface{}{}
+			}
+			id, err := c.add(entry, s.newID(name))
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"id": id}, nil
+		case "update":
+			if req.Args.Entry == nil {
+				return nil, fmt.Errorf("update: missing entry")
+			}
+			return nil, c.update(req.Args.ID, req.Args.Entry)
+		case "delete":
+			return nil, c.delete(req.Args.ID)
+		case "list":
+			return map[string]interface{}{"entries": c.list()}, nil
+		default:
+			return nil, fmt.Errorf("unsupported action %q", req.Action)
+		}
+	}
+}
+
+func (s *Server) registerPlatform() {
+	for function, name := range map[string]string{
+		"platform_datasource_dao":            Sources,
+		"platform_datasink_dao":              Sinks,
+		"platform_channel_dao":               Channels,
+		"platform_processor_dao":             Processors,
+		"platform_integration_dao":           Integrations,
+		"platform_instancerole_dao":          InstanceRoles,
+		"platform_notification_endpoint_dao": NotificationEndpoints,
+		"platform_import_device_dao":         ImportDevices,
+		"github_repo_dao":                    Repos,
+		"ingext_datalake_dao":                Datalakes,
+		"ingext_datalake_schema_dao":         Schemas,
+	} {
+		s.handleFunc(dsPrefix, function, s.dao(name))
+	}
+	s.handleFunc(dsPrefix, "platform_router_dao", s.routerDAO)
+	s.handleFunc(dsPrefix, "platform_application_template_dao", s.appTemplateDAO)
+	s.handleFunc(dsPrefix, "platform_list_application_template", s.listAppTemplates)
+	s.handleFunc(dsPrefix, "platform_list_configs", s.listConfigs)
+	s.handleFunc(dsPrefix, "platform_add_simple_router", s.addSimpleRouter)
+	s.handleFunc(dsPrefix, "platform_source_set_router", s.setSourceRouter)
+	s.handleFunc(dsPrefix, "platform_router_add_pipe", s.addRouterPipe)
+	s.handleFunc(dsPrefix, "platform_router_delete_pipe", s.deleteRouterPipe)
+	s.handleFunc(dsPrefix, "platform_router_update_pipes", s.updateRouterPipes)
+	s.handleFunc(dsPrefix, "platform_pipe_update", s.updatePipe)
+	s.handleFunc(dsPrefix, "platform_pipe_update_processor", s.updatePipeProcessor)
+	s.handleFunc(dsPrefix, "platform_processor_validate", s.validateProcessor)
+	s.handleFunc(dsPrefix, "platform_processor_test", s.testProcessor)
+	s.handleFunc(dsPrefix, "platform_instancerole_add_local", s.addLocalInstanceRole)
+	s.handleFunc(dsPrefix, "ingext_datalake_index_add", s.addDatalakeIndex)
+	s.handleFunc(dsPrefix, "ingext_datalake_index_delete", s.deleteDatalakeIndex)
+	s.handleFunc(dsPrefix, "ingext_datalake_index_list", s.listDatalakeIndex)
+	s.handleFunc(dsPrefix, "ingext_syslog_get_config", s.getSyslog)
+	s.handleFunc(dsPrefix, "ingext_syslog_register_config", s.registerSyslog(false))
+	s.handleFunc(dsPrefix, "ingext_syslog_update_config", s.registerSyslog(true))
+	s.handleFunc(dsPrefix, "ingext_syslog_delete_config", func(*fsb.JNode) (interface{}, error) {
+		if s.syslog == nil {
+			return nil, fmt.Errorf("syslog is not configured")
+		}
+		s.syslog = nil
+		return nil, nil
+	})
+	s.handleFunc(dsPrefix, "platform_list_plugins", func(*fsb.JNode) (interface{}, error) {
+		return map[string]interface{}{"plugins": Plugins}, nil
+	})
+	s.handleFunc(dsPrefix, "get_pod_role", func(*fsb.JNode) (interface{}, error) {
+		return map[string]string{"role": "ingext-fake", "arn": "arn:aws:iam::000000000000:role/ingext-fake"}, nil
+	})
+	s.handleFunc(dsPrefix, "platform_instancerole_test", func(*fsb.JNode) (interface{}, error) { return nil, nil })
+	s.handleFunc(dsPrefix, "platform_source_reload", func(*fsb.JNode) (interface{}, error) { return nil, nil })
+	// The fake carries no traffic: components have no metrics.
+	s.handleFunc(dsPrefix, "platform_component_metrics", func(*fsb.JNode) (interface{}, error) {
+		return map[string]interface{}{"metrics": []interface{}{}}, nil
+	})
+	s.handleFunc(dsPrefix, "collector_list", func(*fsb.JNode) (interface{}, error) {
+		return map[string]interface{}{"entries": []interface{}{}}, nil
+	})
+	s.handleFunc(dsPrefix, "get_system_status", func(*fsb.JNode) (interface{}, error) {
+		return map[string]interface{}{"status": "ok"}, nil
+	})
+}
+
+// routerDAO is the generic DAO with a get that also returns the router's
+// pipes, and a delete that removes them along with source connections.
+func (s *Server) routerDAO(kargs *fsb.JNode) (interface{}, error) {
+	var req daoRequest
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	routers, pipes := s.collections[Routers], s.collections[Pipes]
+	switch req.Action {
+	case "get":
+		entry, err := routers.get(req.Args.ID)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"entry": entry, "pipes": s.routerPipes(entry)}, nil
+	case "delete":
+		if err := routers.delete(req.Args.ID); err != nil {
+			return nil, err
+		}
+		for _, pipe := range pipes.find("routerID", req.Args.ID) {
+			pipes.delete(pipe["id"].(string))
+		}
+		for sourceID, routerID := range s.connections {
+			if routerID == req.Args.ID {
+				delete(s.connections, sourceID)
+			}
+		}
+		return nil, nil
+	default:
+		return s.dao(Routers)(kargs)
+	}
+}
+
+// routerPipes returns the router's pipes in pipeIDs order.
+func (s *Server) routerPipes(router map[string]interface{}) []map[string]interface{} {
+	pipes := []map[string]interface{}{}
+	for _, id := range stringsOf(router["pipeIDs"]) {
+		if pipe, ok := s.collections[Pipes].items[id]; ok {
+			pipes = append(pipes, pipe)
+		}
+	}
+	return pipes
+}
+
+func (s *Server) listConfigs(*fsb.JNode) (interface{}, error) {
+	connections := []map[string]string{}
+	for _, sourceID := range sortedKeys(s.connections) {
+		connections = append(connections, map[string]string{"routerID": s.connections[sourceID], "sourceID": sourceID})
+	}
+	return map[string]interface{}{
+		"sources":      s.collections[Sources].list(),
+		"sinks":        s.collections[Sinks].list(),
+		"routers":      s.collections[Routers].list(),
+		"pipes":        s.collections[Pipes].list(),
+		"channels":     s.collections[Channels].list(),
+		"connections":  connections,
+		"integrations": s.collections[Integrations].list(),
+		"errors":       []interface{}{},
+		"errorStates":  []interface{}{},
+	}, nil
+}
+
+func (s *Server) addSimpleRouter(kargs *fsb.JNode) (interface{}, error) {
+	var req struct {
+		Processor string `json:"processor"`
+		Router    string `json:"router"`
+		Pipe      string `json:"pipe"`
+	}
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	if req.Router == "" {
+		return nil, fmt.Errorf("router name is required")
+	}
+	if req.Processor != "" {
+		if _, err := s.collections[Processors].get(req.Processor); err != nil {
+			return nil, fmt.Errorf("processor %w", err)
+		}
+	}
+	routerID, err := s.collections[Routers].add(map[string]interface{}{"name": req.Router, "workerCount": 1}, s.newID(Routers))
+	if err != nil {
+		return nil, err
+	}
+	pipe := map[string]interface{}{"name": req.Pipe, "routerID": routerID, "matchAll": true, "processorNames": []interface{}{}}
+	if req.Processor != "" {
+		pipe["processorNames"] = []interface{}{req.Processor}
+	}
+	pipeID, err := s.collections[Pipes].add(pipe, s.newID(Pipes))
+	if err != nil {
+		return nil, err
+	}
+	s.collections[Routers].items[routerID]["pipeIDs"] = []interface{}{pipeID}
+	return map[string]string{"id": routerID}, nil
+}
+
+func (s *Server) setSourceRouter(kargs *fsb.JNode) (interface{}, error) {
+	var req struct {
+		RouterID     string `json:"routerID"`
+		DataSourceID string `json:"dataSourceID"`
+	}
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	if _, err := s.collections[Sources].get(req.DataSourceID); err != nil {
+		return nil, fmt.Errorf("data source %w", err)
+	}
+	if req.RouterID == "" {
+		delete(s.connections, req.DataSourceID)
+		return nil, nil
+	}
+	if _, err := s.collections[Routers].get(req.RouterID); err != nil {
+		return nil, fmt.Errorf("router %w", err)
+	}
+	s.connections[req.DataSourceID] = req.RouterID
+	return nil, nil
+}
+
+func (s *Server) addRouterPipe(kargs *fsb.JNode) (interface{}, error) {
+	var req struct {
+		RouterID   string                 `json:"routerID"`
+		PipeConfig map[string]interface{} `json:"pipeConfig"`
+	}
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	router, err := s.collections[Routers].get(req.RouterID)
+	if err != nil {
+		return nil, fmt.Errorf("router %w", err)
+	}
+	if req.PipeConfig == nil {
+		return nil, fmt.Errorf("pipeConfig is required")
+	}
+	req.PipeConfig["routerID"] = req.RouterID
+	id, err := s.collections[Pipes].add(req.PipeConfig, s.newID(Pipes))
+	if err != nil {
+		return nil, err
+	}
+	pipeIDs, _ := router["pipeIDs"].([]interface{})
+	router["pipeIDs"] = append(pipeIDs, id)
+	return map[string]string{"id": id}, nil
+}
+
+func (s *Server) deleteRouterPipe(kargs *fsb.JNode) (interface{}, error) {
+	var req struct {
+		RouterID string `json:"routerID"`
+		PipeID   string `json:"pipeID"`
+	}
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	router, err := s.collections[Routers].get(req.RouterID)
+	if err != nil {
+		return nil, fmt.Errorf("router %w", err)
+	}
+	if err := s.collections[Pipes].delete(req.PipeID); err != nil {
+		return nil, fmt.Errorf("pipe %w", err)
+	}
+	pipeIDs := []interface{}{}
+	for _, id := range stringsOf(router["pipeIDs"]) {
+		if id != req.PipeID {
+			pipeIDs = append(pipeIDs, id)
+		}
+	}
+	router["pipeIDs"] = pipeIDs
+	return nil, nil
+}
+
+func (s *Server) updateRouterPipes(kargs *fsb.JNode) (interface{}, error) {
+	var req struct {
+		RouterID string   `json:"routerID"`
+		PipeIDs  []string `json:"pipeIDs"`
+	}
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	router, err := s.collections[Routers].get(req.RouterID)
+	if err != nil {
+		return nil, fmt.Errorf("router %w", err)
+	}
+	pipeIDs := make([]interface{}, 0, len(req.PipeIDs))
+	for _, id := range req.PipeIDs {
+		if _, err := s.collections[Pipes].get(id); err != nil {
+			return nil, fmt.Errorf("pipe %w", err)
+		}
+		pipeIDs = append(pipeIDs, id)
+	}
+	router["pipeIDs"] = pipeIDs
+	return nil, nil
+}
+
+func (s *Server) updatePipe(kargs *fsb.JNode) (interface{}, error) {
+	var req struct {
+		RouterID   string                 `json:"routerID"`
+		PipeConfig map[string]interface{} `json:"pipeConfig"`
+	}
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	if req.PipeConfig == nil {
+		return nil, fmt.Errorf("pipeConfig is required")
+	}
+	req.PipeConfig["routerID"] = req.RouterID
+	id, _ := req.PipeConfig["id"].(string)
+	if err := s.collections[Pipes].update(id, req.PipeConfig); err != nil {
+		return nil, fmt.Errorf("pipe %w", err)
+	}
+	return nil, nil
+}
+
+func (s *Server) updatePipeProcessor(kargs *fsb.JNode) (interface{}, error) {
+	var req struct {
+		RouterName    string `json:"routerName"`
+		PipeName      string `json:"pipeName"`
+		ProcessorName string `json:"processorName"`
+	}
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	routers := s.collections[Routers].find("name", req.RouterName)
+	if len(routers) == 0 {
+		return nil, fmt.Errorf("router %q not found", req.RouterName)
+	}
+	for _, pipe := range s.routerPipes(routers[0]) {
+		if pipe["name"] == req.PipeName {
+			pipe["processorNames"] = []interface{}{req.ProcessorName}
+			return nil, nil
+		}
+	}
+	return nil, fmt.Errorf("pipe %q not found", req.PipeName)
+}
+
+func (s *Server) validateProcessor(kargs *fsb.JNode) (interface{}, error) {
+	var req struct {
+		Script string `json:"script"`
+	}
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	if req.Script == "" {
+		return map[string]interface{}{"ok": false, "error": "empty script"}, nil
+	}
+	return map[string]interface{}{"ok": true}, nil
+}
+
+// testProcessor echoes the sample source back as the processed content.
+func (s *Server) testProcessor(kargs *fsb.JNode) (interface{}, error) {
+	var req struct {
+		Source string `json:"source"`
+	}
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"newContent": req.Source, "status": "ok"}, nil
+}
+
+func (s *Server) addLocalInstanceRole(kargs *fsb.JNode) (interface{}, error) {
+	var req daoRequest
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	if req.Args.Entry == nil {
+		return nil, fmt.Errorf("missing entry")
+	}
+	req.Args.Entry["local"] = true
+	id, err := s.collections[InstanceRoles].add(req.Args.Entry, s.newID(InstanceRoles))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"id": id}, nil
+}
+
+func (s *Server) addDatalakeIndex(kargs *fsb.JNode) (interface{}, error) {
+	var req struct {
+		Entry map[string]interface{} `json:"entry"`
+	}
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	if req.Entry == nil {
+		return nil, fmt.Errorf("missing entry")
+	}
+	lake, _ := req.Entry["datalake"].(string)
+	index, _ := req.Entry["datalakeIndex"].(string)
+	if _, err := s.collections[Datalakes].get(lake); err != nil {
+		return nil, fmt.Errorf("datalake %w", err)
+	}
+	req.Entry["id"] = lake + "/" + index
+	_, err := s.collections[DatalakeIndexes].add(req.Entry, "")
+	return nil, err
+}
+
+func (s *Server) deleteDatalakeIndex(kargs *fsb.JNode) (interface{}, error) {
+	var req struct {
+		Lake  string `json:"lake"`
+		Index string `json:"index"`
+	}
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	return nil, s.collections[DatalakeIndexes].delete(req.Lake + "/" + req.Index)
+}
+
+func (s *Server) listDatalakeIndex(kargs *fsb.JNode) (interface{}, error) {
+	var req struct {
+		Lake string `json:"lake"`
+	}
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	entries := s.collections[DatalakeIndexes].list()
+	if req.Lake != "" {
+		entries = s.collections[DatalakeIndexes].find("datalake", req.Lake)
+	}
+	return map[string]interface{}{"entries": entries}, nil
+}
+
+// appTemplateDAO is the generic DAO keyed by the template name, which add
+// and update read from the content, along with the config listed by
+// platform_list_application_template.
+func (s *Server) appTemplateDAO(kargs *fsb.JNode) (interface{}, error) {
+	var req daoRequest
+	if err := decodeKargs(kargs, &req); err != nil {
+		return nil, err
+	}
+	if req.Action != "add" && req.Action != "update" {
+		return s.dao(AppTemplates)(kargs)
+	}
+	content, _ := req.Args.Entry["content"].(string)
+	var template model.ApplicationTemplate
+	if err := yaml.Unmarshal([]byte(content), &template); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	app := template.ApplicationConfig
+	if app == nil || app.Name == "" {
+		return nil, fmt.Errorf("invalid template: application.metadata.name is required")
+	}
+	config, err := toMap(&model.ApplicationTemplateConfig{
+		Name:           app.Name,
+		DisplayName:    app.Spec.DisplayName,
+		Description:    app.Spec.Description,
+		Icon:           app.Spec.Icon,
+		Category:       app.Spec.Category,
+		ResourceGroups: app.Spec.ResourceGroups,
+		Parameters:     app.Spec.Parameters,
+		Output:         app.Spec.Output,
+	})
+	if err != nil {
+		return nil, err
+	}
+	entry := map[string]interface{}{"name": app.Name, "config": config, "content": content}
+	c := s.collections[AppTemplates]
+	if req.Action == "update" {
+		if req.Args.ID != app.Name {
+			return nil, fmt.Errorf("template %q is named %q", req.Args.ID, app.Name)
+		}
+		return nil, c.update(app.Name, entry)
+	}
+	id, err := c.add(entry, "")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"id": id}, nil
+}
+
+func (s *Server) listAppTemplates(*fsb.JNode) (interface{}, error) {
+	entries := []interface{}{}
+	for _, entry := range s.collections[AppTemplates].list() {
+		entries = append(entries, entry["config"])
+	}
+	return map[string]interface{}{"entries": entries}, nil
+}
+
+func (s *Server) getSyslog(*fsb.JNode) (interface{}, error) {
+	if s.syslog == nil {
+		return map[string]interface{}{}, nil
+	}
+	return map[string]interface{}{"config": s.syslog}, nil
+}
+
+// registerSyslog enables the requested listeners, on fixed ports, and
+// replies with the config.
+func (s *Server) registerSyslog(update bool) func(kargs *fsb.JNode) (interface{}, error) {
+	return func(kargs *fsb.JNode) (interface{}, error) {
+		var req model.SyslogPortRequest
+		if err := decodeKargs(kargs, &req); err != nil {
+			return nil, err
+		}
+		if update && s.syslog == nil {
+			return nil, fmt.Errorf("syslog is not configured")
+		}
+		if !update && s.syslog != nil {
+			return nil, fmt.Errorf("syslog is already configured")
+		}
+		config := &model.SyslogConfig{Domain: "syslog.fake.local", PortBegin: 5514, PortEnd: 5517}
+		if req.SyslogUDP {
+			config.SyslogUDP, config.SyslogUDPPort = true, 5514
+		}
+		if req.SyslogTCP {
+			config.SyslogTCP, config.SyslogTCPPort = true, 5515
+		}
+		if req.SyslogTLS {
+			config.SyslogTLS, config.SyslogTLSPort = true, 5516
+		}
+		if req.TLSRfc6587 {
+			config.TLSRfc6587, config.TLSRfc6587Port = true, 5517
+		}
+		m, err := toMap(config)
+		if err != nil {
+			return nil, err
+		}
+		s.syslog = m
+		return map[string]interface{}{"config": m}, nil
+	}
+}
@@ -0,0 +1,29 @@
+package fakeserver
+
+import (
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SecurityDo/ingext_api/client"
+)
+
+// NewTest starts a fake site on a local httptest server, closed when the test
+// ends, and returns it with a client connected to it. Client logs are
+// discarded.
+func NewTest(t testing.TB) (*Server, *client.IngextClient) {
+	t.Helper()
+	s := New()
+	s.Token = "fake-token"
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cli, err := client.NewIngextClientWithOptions(ts.URL, s.Token,
+		client.WithLogger(logger), client.WithRetryPolicy(client.NoRetryPolicy()))
+	if err != nil {
+		t.Fatalf("fakeserver: %v", err)
+	}
+	return s, cli
+}
@@ -0,0 +1,200 @@
+package fsb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Attachments travel next to the JSON envelope in a multipart body:
+//
+//   - a request with attachments is multipart/form-data; its first part,
+//     named RequestPart, holds the CallRequest JSON, and each following part
+//     holds the content of the attachment whose Id is the part name;
+//   - a response with attachments is multipart/mixed with a ResponsePart
+//     holding the CallResponse JSON followed by the attachment parts.
+//
+// Attachment.Hash is "sha256:<hex>" of the content and is verified on
+// receipt. Attachment.Path is the local file of the side holding it and is
+// never sent.
+const (
+	RequestPart  = "request"
+	ResponsePart = "response"
+	HashPrefix   = "sha256:"
+)
+
+// ErrHashMismatch is returned when received attachment content does not
+// match Attachment.Hash.
+var ErrHashMismatch = errors.New("attachment hash mismatch")
+
+// HashFile returns the Attachment.Hash of the file at path.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return HashPrefix + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// PrepareAttachments fills in the Name (base name of Path), Id, Type (from
+// the file extension) and Hash of attachments whose Path is a local file,
+// and returns their total size.
+func PrepareAttachments(attachments []*Attachment) (size int64, err error) {
+	for i, a := range attachments {
+		if a.Path == "" {
+			return 0, fmt.Errorf("attachment %d: missing path", i)
+		}
+		info, err := os.Stat(a.Path)
+		if err != nil {
+			return 0, fmt.Errorf("attachment %d: %w", i, err)
+		}
+		if info.IsDir() {
+			return 0, fmt.Errorf("attachment %d: %s is a directory", i, a.Path)
+		}
+		size += info.Size()
+		if a.Name == "" {
+			a.Name = filepath.Base(a.Path)
+		}
+		if a.Id == "" {
+			a.Id = "attachment-" + strconv.Itoa(i)
+		}
+		if a.Type == "" {
+			a.Type = mime.TypeByExtension(filepath.Ext(a.Path))
+		}
+		if a.Type == "" {
+			a.Type = "application/octet-stream"
+		}
+		if a.Hash == "" {
+			if a.Hash, err = HashFile(a.Path); err != nil {
+				return 0, fmt.Errorf("attachment %d: %w", i, err)
+			}
+		}
+	}
+	return size, nil
+}
+
+// WireAttachments returns copies of attachments without the local Path, as
+// listed in an envelope.
+func WireAttachments(attachments []*Attachment) []*Attachment {
+	if attachments == nil {
+		return nil
+	}
+	wire := make([]*Attachment, len(attachments))
+	for i, a := range attachments {
+		c := *a
+		c.Path = ""
+		wire[i] = &c
+	}
+	return wire
+}
+
+// HasLocalAttachments reports whether any attachment has a local Path to send.
+func HasLocalAttachments(attachments []*Attachment) bool {
+	for _, a := range attachments {
+		if a.Path != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteMultipart writes the envelope as the part named envelopePart followed
+// by the content of every attachment with a local Path.
+func WriteMultipart(mw *multipart.Writer, envelopePart string, envelope []byte, attachments []*Attachment) error {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": envelopePart}))
+	h.Set("Content-Type", "application/json")
+	w, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(envelope); err != nil {
+		return err
+	}
+	for _, a := range attachments {
+		if a.Path == "" {
+			continue
+		}
+		if err := writeAttachmentPart(mw, a); err != nil {
+			return fmt.Errorf("attachment %s: %w", a.Name, err)
+		}
+	}
+	return mw.Close()
+}
+
+func writeAttachmentPart(mw *multipart.Writer, a *Attachment) error {
+	f, err := os.Open(a.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": a.Id, "filename": a.Name}))
+	h.Set("Content-Type", a.Type)
+	w, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// FindAttachment returns the attachment with the given Id, or nil.
+func FindAttachment(attachments []*Attachment, id string) *Attachment {
+	for _, a := range attachments {
+		if a.Id == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// SaveAttachment writes r to a file named after a.Name in dir, verifies
+// a.Hash when set and records the file in a.Path. The file is removed if the
+// content does not match.
+func SaveAttachment(r io.Reader, dir string, a *Attachment) error {
+	name := filepath.Base(filepath.Clean("/" + a.Name))
+	if name == "/" || name == "." {
+		name = a.Id
+	}
+	f, err := os.CreateTemp(dir, "."+name+".*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && a.Hash != "" {
+		if got := HashPrefix + hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(got, a.Hash) {
+			err = fmt.Errorf("%w: %s: got %s, want %s", ErrHashMismatch, a.Name, got, a.Hash)
+		}
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	path := filepath.Join(dir, name)
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	a.Path = path
+	return nil
+}
@@ -0,0 +1,136 @@
+package fsb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrPathNotFound is returned by the JNode path accessors when a path
+// element does not exist.
+var ErrPathNotFound = errors.New("path not found")
+
+// Decode decodes node into a new T. Numbers decoded into interface{} values
+// are json.Number, as with GetMap. A nil node decodes to the zero T.
+func Decode[T any](node *JNode) (T, error) {
+	var out T
+	err := node.Decode(&out)
+	return out, err
+}
+
+// Decode decodes the node into out using json.Number for numbers held in
+// interface{} values.
+func (r *JNode) Decode(out interface{}) error {
+	if r == nil {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(r.GetBytes()))
+	decoder.UseNumber()
+	return decoder.Decode(out)
+}
+
+// Get returns the value at a dot separated path such as "entries.0.id":
+// object keys select fields and integers index arrays. Objects are returned
+// as map[string]interface{}, arrays as []interface{} and numbers as
+// json.Number. An empty path returns the whole document.
+func (r *JNode) Get(path string) (interface{}, error) {
+	var v interface{}
+	if err := r.Decode(&v); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return v, nil
+	}
+	for i, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			child, ok := node[key]
+			if !ok {
+				return nil, pathError(path, i, ErrPathNotFound)
+			}
+			v = child
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+			if err != nil {
+				return nil, pathError(path, i, fmt.Errorf("%q is not an array index", key))
+			}
+			if idx < 0 || idx >= len(node) {
+				return nil, pathError(path, i, ErrPathNotFound)
+			}
+			v = node[idx]
+		default:
+			return nil, pathError(path, i, ErrPathNotFound)
+		}
+	}
+	return v, nil
+}
+
+// GetNode returns the value at path (see Get) as a JNode.
+func (r *JNode) GetNode(path string) (*JNode, error) {
+	v, err := r.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewJNodeInterface(v)
+}
+
+// GetString returns the string at path (see Get).
+func (r *JNode) GetString(path string) (string, error) {
+	v, err := r.Get(path)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%s: not a string", path)
+	}
+	return s, nil
+}
+
+// GetInt64 returns the integer at path (see Get).
+func (r *JNode) GetInt64(path string) (int64, error) {
+	v, err := r.Get(path)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("%s: not a number", path)
+	}
+	return n.Int64()
+}
+
+// GetFloat64 returns the number at path (see Get).
+func (r *JNode) GetFloat64(path string) (float64, error) {
+	v, err := r.Get(path)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("%s: not a number", path)
+	}
+	return n.Float64()
+}
+
+// GetBool returns the boolean at path (see Get).
+func (r *JNode) GetBool(path string) (bool, error) {
+	v, err := r.Get(path)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s: not a boolean", path)
+	}
+	return b, nil
+}
+
+// pathError reports the failing prefix of path, e.g. "entries.3".
+func pathError(path string, elem int, err error) error {
+	parts := strings.SplitN(path, ".", elem+2)
+	return fmt.Errorf("%s: %w", strings.Join(parts[:elem+1], "."), err)
+}
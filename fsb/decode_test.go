@@ -0,0 +1,45 @@
+package fsb
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestJNodeDecodeAndGet(t *testing.T) {
+	node := NewJNodeString(`{"entries":[{"id":"a","size":9007199254740993,"ok":true}],"name":"lake"}`)
+
+	type entry struct {
+		ID   string      `json:"id"`
+		Size interface{} `json:"size"`
+	}
+	out, err := Decode[struct{ Entries []entry }](node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Entries[0].ID != "a" || out.Entries[0].Size != json.Number("9007199254740993") {
+		t.Errorf("Decode = %+v", out)
+	}
+
+	if id, err := node.GetString("entries.0.id"); err != nil || id != "a" {
+		t.Errorf("GetString = %q, %v", id, err)
+	}
+	if size, err := node.GetInt64("entries.0.size"); err != nil || size != 9007199254740993 {
+		t.Errorf("GetInt64 = %d, %v", size, err)
+	}
+	if ok, err := node.GetBool("entries.0.ok"); err != nil || !ok {
+		t.Errorf("GetBool = %v, %v", ok, err)
+	}
+	sub, err := node.GetNode("entries.0")
+	if err != nil || sub.String() != `{"id":"a","ok":true,"size":9007199254740993}` {
+		t.Errorf("GetNode = %s, %v", sub, err)
+	}
+	for _, path := range []string{"entries.1.id", "missing", "name.x"} {
+		if _, err := node.Get(path); !errors.Is(err, ErrPathNotFound) {
+			t.Errorf("Get(%q) = %v, want ErrPathNotFound", path, err)
+		}
+	}
+	if _, err := node.Get("entries.x"); err == nil {
+		t.Error("Get with a non-numeric array index should fail")
+	}
+}
@@ -0,0 +1,413 @@
+package fsb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Middleware wraps a ServiceAction, e.g. to authenticate, log or validate a call.
+type Middleware func(next ServiceAction) ServiceAction
+
+// RequestContext is passed as the context argument of every ServiceAction
+// (and ApiHandle/OpenAPIHandle Call) dispatched by Server.
+type RequestContext struct {
+	// Context is the HTTP request context; it is cancelled when the client goes away.
+	Context  context.Context
+	Prefix   string
+	Function string
+	// Request is the incoming HTTP request. Its body has already been read.
+	Request *http.Request
+	// Values lets middleware pass data (e.g. the authenticated user) to actions.
+	Values map[string]interface{}
+}
+
+// DefaultMaxRequestSize bounds request bodies accepted by Server.
+const DefaultMaxRequestSize = 64 << 20 // 64 MiB
+
+type route struct {
+	action     ServiceAction
+	middleware []Middleware
+}
+
+// Server is an http.Handler speaking the fsb protocol: it routes
+// POST /<prefix>/<function> with a CallRequest body to the ServiceAction
+// registered for that prefix and function, and writes back a CallResponse.
+//
+// An action returning an error produces an ERROR verdict; a panicking action
+// produces an EXCEPTION verdict. Unknown functions get HTTP 404, other
+// methods than POST get HTTP 405.
+//
+// Attachments are exchanged as multipart bodies (see RequestPart): uploaded
+// files are available to the action at Attachment.Path until it returns,
+// and response attachments with a Path are sent back with their content.
+type Server struct {
+	// MaxRequestSize bounds the request body (DefaultMaxRequestSize if 0).
+	MaxRequestSize int64
+	// Logger receives panics and protocol errors (stderr if nil).
+	Logger *slog.Logger
+
+	mu          sync.RWMutex
+	routes      map[string]map[string]*route
+	apiHandles  map[string]ApiHandle
+	openHandles map[string]OpenAPIHandle
+	services    map[string]GenericServiceWithContext
+	middleware  []Middleware
+}
+
+// NewServer returns an empty Server.
+func NewServer() *Server {
+	return &Server{
+		routes:      map[string]map[string]*route{},
+		apiHandles:  map[string]ApiHandle{},
+		openHandles: map[string]OpenAPIHandle{},
+		services:    map[string]GenericServiceWithContext{},
+	}
+}
+
+// Handle registers action for prefix/function (e.g. "api/ds", "kql_search").
+// mw wraps this function only, inside the server-wide middleware added by Use.
+func (s *Server) Handle(prefix, function string, action ServiceAction, mw ...Middleware) {
+	prefix = strings.Trim(prefix, "/")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.routes[prefix] == nil {
+		s.routes[prefix] = map[string]*route{}
+	}
+	s.routes[prefix][function] = &route{action: action, middleware: mw}
+}
+
+// Use adds middleware applied to every dispatched call, outermost first.
+func (s *Server) Use(mw ...Middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.middleware = append(s.middleware, mw...)
+}
+
+// MountApiHandle serves every function of prefix accepted by h.CheckAPI that
+// has no action registered with Handle.
+func (s *Server) MountApiHandle(prefix string, h ApiHandle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiHandles[strings.Trim(prefix, "/")] = h
+}
+
+// MountOpenAPIHandle is like MountApiHandle for handles that take the raw
+// request body; they are consulted after ApiHandles.
+func (s *Server) MountOpenAPIHandle(prefix string, h OpenAPIHandle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.openHandles[strings.Trim(prefix, "/")] = h
+}
+
+// MountService serves every function of prefix not matched otherwise with
+// svc. svc receives RequestContext.Values as its context map.
+func (s *Server) MountService(prefix string, svc GenericServiceWithContext) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.services[strings.Trim(prefix, "/")] = svc
+}
+
+// Functions returns the registered "<prefix>/<function>" names, sorted.
+func (s *Server) Functions() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var names []string
+	for prefix, fns := range s.routes {
+		for fn := range fns {
+			names = append(names, prefix+"/"+fn)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix, function := splitCallPath(r.URL.Path)
+	if function == "" {
+		writeCallResponse(w, http.StatusNotFound, NewErrorResponse("missing function name in path "+r.URL.Path))
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeCallResponse(w, http.StatusMethodNotAllowed, NewErrorResponse("method "+r.Method+" not allowed"))
+		return
+	}
+
+	action := s.lookup(prefix, function)
+	if action == nil {
+		writeCallResponse(w, http.StatusNotFound, NewErrorResponse(fmt.Sprintf("function %s/%s not found", prefix, function)))
+		return
+	}
+
+	var req CallRequest
+	body, dir, err := s.readRequest(r, &req)
+	if err != nil {
+		writeCallResponse(w, http.StatusBadRequest, NewErrorResponse(err.Error()))
+		return
+	}
+	if dir != "" {
+		defer os.RemoveAll(dir)
+	}
+	req.Function = function
+	if req.Kargs == nil {
+		req.Kargs = NewEmptyMap()
+	}
+
+	rc := &RequestContext{
+		Context:  r.Context(),
+		Prefix:   prefix,
+		Function: function,
+		Request:  r,
+		Values:   map[string]interface{}{},
+	}
+	rc.Values[rawBodyKey] = body
+	status, res := s.dispatch(action, &req, rc)
+	s.writeResponse(w, status, res)
+}
+
+// rawBodyKey keeps the raw body for OpenAPIHandle dispatch.
+const rawBodyKey = "fsb.rawBody"
+
+// lookup returns the action (with middleware applied) serving prefix/function.
+func (s *Server) lookup(prefix, function string) ServiceAction {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var action ServiceAction
+	var routeMW []Middleware
+	if rt := s.routes[prefix][function]; rt != nil {
+		action, routeMW = rt.action, rt.middleware
+	} else if h := s.apiHandles[prefix]; h != nil && h.CheckAPI(function) {
+		action = func(kargs *JNode, attachments []*Attachment, context interface{}) (*CallResponse, error) {
+			rc := context.(*RequestContext)
+			return h.Call(rc.Function, &CallRequest{Function: rc.Function, Kargs: kargs, Attachments: attachments}, rc)
+		}
+	} else if h := s.openHandles[prefix]; h != nil && h.CheckAPI(function) {
+		action = func(kargs *JNode, attachments []*Attachment, context interface{}) (*CallResponse, error) {
+			rc := context.(*RequestContext)
+			raw, _ := rc.Values[rawBodyKey].([]byte)
+			return h.Call(rc.Function, raw, rc)
+		}
+	} else if svc := s.services[prefix]; svc != nil {
+		action = func(kargs *JNode, attachments []*Attachment, context interface{}) (*CallResponse, error) {
+			rc := context.(*RequestContext)
+			return svc(&CallRequest{Function: rc.Function, Kargs: kargs, Attachments: attachments}, rc.Values)
+		}
+	} else {
+		return nil
+	}
+
+	for i := len(routeMW) - 1; i >= 0; i-- {
+		action = routeMW[i](action)
+	}
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		action = s.middleware[i](action)
+	}
+	return action
+}
+
+// dispatch runs action, turning errors into ERROR and panics into EXCEPTION
+// verdicts. An ApiError with an HTTP error code sets the response status.
+func (s *Server) dispatch(action ServiceAction, req *CallRequest, rc *RequestContext) (status int, res *CallResponse) {
+	defer func() {
+		if p := recover(); p != nil {
+			s.logger().Error("fsb action panicked", "prefix", rc.Prefix, "function", rc.Function, "panic", p, "stack", string(debug.Stack()))
+			status, res = http.StatusOK, NewExceptionResponse(fmt.Sprint(p))
+		}
+	}()
+	res, err := action(req.Kargs, req.Attachments, rc)
+	if err != nil {
+		status = http.StatusOK
+		var apiErr ApiError
+		var apiErrPtr *ApiError
+		if errors.As(err, &apiErr) && apiErr.Code >= 400 && apiErr.Code < 600 {
+			status = apiErr.Code
+		} else if errors.As(err, &apiErrPtr) && apiErrPtr.Code >= 400 && apiErrPtr.Code < 600 {
+			status = apiErrPtr.Code
+		}
+		return status, NewErrorResponse(err.Error())
+	}
+	if res == nil {
+		return http.StatusOK, NewOKEmptyResponse()
+	}
+	return http.StatusOK, res
+}
+
+// readRequest decodes the CallRequest of r into req and returns its raw
+// JSON. The files of a multipart request are saved, with their hash checked,
+// in a new temporary directory returned in dir, which the caller removes;
+// their Path is set in req.Attachments.
+func (s *Server) readRequest(r *http.Request, req *CallRequest) (body []byte, dir string, err error) {
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		if body, err = s.readBody(r); err != nil {
+			return nil, "", err
+		}
+		return body, "", decodeRequest(body, req)
+	}
+
+	mr := multipart.NewReader(http.MaxBytesReader(nil, r.Body, s.maxRequestSize()), params["boundary"])
+	part, err := mr.NextPart()
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid multipart request: %w", err)
+	}
+	if part.FormName() != RequestPart {
+		return nil, "", fmt.Errorf("invalid multipart request: first part must be %q", RequestPart)
+	}
+	if body, err = io.ReadAll(part); err != nil {
+		return nil, "", fmt.Errorf("failed to read request body: %w", err)
+	}
+	if err := decodeRequest(body, req); err != nil {
+		return nil, "", err
+	}
+	for _, a := range req.Attachments {
+		a.Path = ""
+	}
+
+	if dir, err = os.MkdirTemp("", "fsb-attachments-"); err != nil {
+		return nil, "", err
+	}
+	for i := 0; ; i++ {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return body, dir, nil
+		}
+		if err == nil {
+			err = saveRequestPart(part, filepath.Join(dir, strconv.Itoa(i)), req)
+		}
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, "", fmt.Errorf("invalid attachment: %w", err)
+		}
+	}
+}
+
+// saveRequestPart saves part in its own directory (names may collide) as the
+// attachment of req with the same Id, adding it if req does not list it.
+func saveRequestPart(part *multipart.Part, dir string, req *CallRequest) error {
+	a := FindAttachment(req.Attachments, part.FormName())
+	if a == nil {
+		a = &Attachment{Id: part.FormName(), Type: part.Header.Get("Content-Type")}
+		req.Attachments = append(req.Attachments, a)
+	}
+	if a.Name == "" {
+		a.Name = part.FileName()
+	}
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		return err
+	}
+	return SaveAttachment(part, dir, a)
+}
+
+func decodeRequest(body []byte, req *CallRequest) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(req); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) maxRequestSize() int64 {
+	if s.MaxRequestSize <= 0 {
+		return DefaultMaxRequestSize
+	}
+	return s.MaxRequestSize
+}
+
+func (s *Server) readBody(r *http.Request) ([]byte, error) {
+	limit := s.maxRequestSize()
+	var body io.Reader = http.MaxBytesReader(nil, r.Body, limit)
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip request body: %w", err)
+		}
+		defer zr.Close()
+		body = io.LimitReader(zr, limit+1)
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if int64(len(b)) > limit {
+		return nil, fmt.Errorf("request body exceeds %d bytes", limit)
+	}
+	return b, nil
+}
+
+func (s *Server) logger() *slog.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, nil))
+}
+
+// splitCallPath splits "/api/ds/kql_search" into ("api/ds", "kql_search").
+func splitCallPath(path string) (prefix, function string) {
+	path = strings.Trim(path, "/")
+	idx := strings.LastIndex(path, "/")
+	if idx < 0 {
+		return "", path
+	}
+	return path[:idx], path[idx+1:]
+}
+
+// writeResponse writes res, as a multipart/mixed body carrying the files of
+// the attachments with a local Path if it has any.
+func (s *Server) writeResponse(w http.ResponseWriter, status int, res *CallResponse) {
+	if !HasLocalAttachments(res.Attachments) {
+		writeCallResponse(w, status, res)
+		return
+	}
+	var local []*Attachment
+	for _, a := range res.Attachments {
+		if a.Path != "" {
+			local = append(local, a)
+		}
+	}
+	if _, err := PrepareAttachments(local); err != nil {
+		s.logger().Error("fsb response attachment", "error", err)
+		writeCallResponse(w, http.StatusInternalServerError, NewExceptionResponse(err.Error()))
+		return
+	}
+	envelope := *res
+	envelope.Attachments = WireAttachments(res.Attachments)
+	b, err := json.Marshal(&envelope)
+	if err != nil {
+		writeCallResponse(w, http.StatusInternalServerError, NewExceptionResponse(err.Error()))
+		return
+	}
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	w.WriteHeader(status)
+	if err := WriteMultipart(mw, ResponsePart, b, res.Attachments); err != nil {
+		// The status is already sent; the client sees a truncated body.
+		s.logger().Error("failed to write fsb response attachments", "error", err)
+	}
+}
+
+func writeCallResponse(w http.ResponseWriter, status int, res *CallResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,173 @@
+package fsb_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SecurityDo/ingext_api/client"
+	fsb "github.com/SecurityDo/ingext_api/fsb"
+)
+
+type echoHandle struct{}
+
+func (echoHandle) CheckAPI(fn string) bool { return fn == "echo" }
+
+func (echoHandle) Call(fn string, req *fsb.CallRequest, _ interface{}) (*fsb.CallResponse, error) {
+	return fsb.NewOKResponse(req.Kargs), nil
+}
+
+func TestServerDispatch(t *testing.T) {
+	srv := fsb.NewServer()
+	var order []string
+	trace := func(name string) fsb.Middleware {
+		return func(next fsb.ServiceAction) fsb.ServiceAction {
+			return func(kargs *fsb.JNode, att []*fsb.Attachment, ctx interface{}) (*fsb.CallResponse, error) {
+				order = append(order, name)
+				return next(kargs, att, ctx)
+			}
+		}
+	}
+	srv.Use(trace("global"))
+	srv.Handle("api/ds", "add", func(kargs *fsb.JNode, _ []*fsb.Attachment, ctx interface{}) (*fsb.CallResponse, error) {
+		var args struct{ A, B int }
+		if err := json.Unmarshal(kargs.GetBytes(), &args); err != nil {
+			return nil, err
+		}
+		if rc := ctx.(*fsb.RequestContext); rc.Prefix != "api/ds" || rc.Function != "add" {
+			return nil, fmt.Errorf("unexpected context %s/%s", rc.Prefix, rc.Function)
+		}
+		return fsb.NewOKInterfaceResponse(map[string]int{"sum": args.A + args.B})
+	}, trace("route"))
+	srv.Handle("api/ds", "fail", func(*fsb.JNode, []*fsb.Attachment, interface{}) (*fsb.CallResponse, error) {
+		return nil, errors.New("boom")
+	})
+	srv.Handle("api/ds", "panic", func(*fsb.JNode, []*fsb.Attachment, interface{}) (*fsb.CallResponse, error) {
+		panic("kaboom")
+	})
+	srv.Handle("api/ds", "denied", func(*fsb.JNode, []*fsb.Attachment, interface{}) (*fsb.CallResponse, error) {
+		return nil, fsb.ApiError{Code: http.StatusForbidden, Info: "denied"}
+	})
+	srv.MountApiHandle("api/auth", echoHandle{})
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	cli := client.NewIngextClient(ts.URL, "token", false, nil)
+	ctx := context.Background()
+
+	var sum struct{ Sum int }
+	if err := cli.GenericCallInto(ctx, "api/ds", "add", map[string]int{"a": 2, "b": 3}, &sum); err != nil {
+		t.Fatal(err)
+	}
+	if sum.Sum != 5 {
+		t.Errorf("sum = %d, want 5", sum.Sum)
+	}
+	if strings.Join(order, ",") != "global,route" {
+		t.Errorf("middleware order = %v", order)
+	}
+
+	var echoed map[string]string
+	if err := cli.GenericCallInto(ctx, "api/auth", "echo", map[string]string{"x": "y"}, &echoed); err != nil || echoed["x"] != "y" {
+		t.Errorf("echo = %v, %v", echoed, err)
+	}
+
+	_, err := cli.GenericCallContext(ctx, "api/ds", "fail", nil)
+	if client.ErrorKindOf(err) != client.KindError || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("fail: %v", err)
+	}
+	_, err = cli.GenericCallContext(ctx, "api/ds", "panic", nil)
+	if !errors.Is(err, client.ErrException) || !strings.Contains(err.Error(), "kaboom") {
+		t.Errorf("panic: %v", err)
+	}
+	_, err = cli.GenericCallContext(ctx, "api/ds", "denied", nil)
+	var rpcErr *client.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.HTTPStatus != http.StatusForbidden {
+		t.Errorf("denied: %v", err)
+	}
+	_, err = cli.GenericCallContext(ctx, "api/ds", "missing", nil)
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("missing: %v", err)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/ds/add")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d", resp.StatusCode)
+	}
+}
+
+func TestServerAttachments(t *testing.T) {
+	outDir := t.TempDir()
+	srv := fsb.NewServer()
+	// upper returns every uploaded file upper-cased under the same name.
+	srv.Handle("api/ds", "upper", func(kargs *fsb.JNode, att []*fsb.Attachment, _ interface{}) (*fsb.CallResponse, error) {
+		res := fsb.NewOKResponse(kargs)
+		for _, a := range att {
+			b, err := os.ReadFile(a.Path)
+			if err != nil {
+				return nil, err
+			}
+			path := filepath.Join(outDir, a.Name)
+			if err := os.WriteFile(path, bytes.ToUpper(b), 0o600); err != nil {
+				return nil, err
+			}
+			res.Attachments = append(res.Attachments, &fsb.Attachment{Id: a.Id, Name: a.Name, Path: path})
+		}
+		return res, nil
+	})
+	srv.Handle("api/ds", "corrupt", func(*fsb.JNode, []*fsb.Attachment, interface{}) (*fsb.CallResponse, error) {
+		path := filepath.Join(outDir, "corrupt.txt")
+		if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+			return nil, err
+		}
+		res := fsb.NewOKEmptyResponse()
+		res.Attachments = []*fsb.Attachment{{Id: "x", Name: "corrupt.txt", Path: path, Hash: fsb.HashPrefix + "00"}}
+		return res, nil
+	})
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	cli := client.NewIngextClient(ts.URL, "token", false, nil)
+	ctx := context.Background()
+
+	in := filepath.Join(t.TempDir(), "lookup.csv")
+	if err := os.WriteFile(in, []byte("a,b\n1,2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	downloads := t.TempDir()
+	res, err := cli.GenericCallWithAttachments(ctx, "api/ds", "upper", map[string]string{"k": "v"},
+		[]*fsb.Attachment{{Path: in}}, downloads)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := res.Response.GetString("k"); v != "v" {
+		t.Errorf("response = %s", res.Response.GetBytes())
+	}
+	if len(res.Attachments) != 1 || res.Attachments[0].Path != filepath.Join(downloads, "lookup.csv") {
+		t.Fatalf("attachments = %+v", res.Attachments)
+	}
+	if b, _ := os.ReadFile(res.Attachments[0].Path); string(b) != "A,B\n1,2\n" {
+		t.Errorf("downloaded %q", b)
+	}
+	if h, _ := fsb.HashFile(res.Attachments[0].Path); h != res.Attachments[0].Hash {
+		t.Errorf("hash = %s, want %s", h, res.Attachments[0].Hash)
+	}
+
+	_, err = cli.GenericCallWithAttachments(ctx, "api/ds", "corrupt", nil, nil, downloads)
+	if !errors.Is(err, fsb.ErrHashMismatch) {
+		t.Errorf("corrupt: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(downloads, "corrupt.txt")); !os.IsNotExist(err) {
+		t.Errorf("corrupt file kept: %v", err)
+	}
+}
@@ -10,7 +10,7 @@ import (
 
 func (c *Client) ListAppTemplates() (templates []*model.ApplicationTemplateConfig, err error) {
 
-	applicationService := ingextAPI.NewApplicationService(c.ingextClient)
+	applicationService := ingextAPI.NewApplicationService(c.ingextClient).WithContext(c.context())
 
 	resp, err := applicationService.ListAppTemplates()
 
@@ -23,7 +23,7 @@ func (c *Client) ListAppTemplates() (templates []*model.ApplicationTemplateConfi
 
 func (c *Client) InstallAppInstance(application, instance string, displayName string, parameters []*model.InputParameter) (err error) {
 
-	applicationService := ingextAPI.NewApplicationService(c.ingextClient)
+	applicationService := ingextAPI.NewApplicationService(c.ingextClient).WithContext(c.context())
 
 	req := &api.InstallAppInstanceRequest{
 		Config: &model.InstanceConfig{
@@ -46,7 +46,7 @@ func (c *Client) InstallAppInstance(application, instance string, displayName st
 
 func (c *Client) GetAppInstance(application, instance string) (res *api.GetAppInstanceResponse, err error) {
 
-	applicationService := ingextAPI.NewApplicationService(c.ingextClient)
+	applicationService := ingextAPI.NewApplicationService(c.ingextClient).WithContext(c.context())
 	res, err = applicationService.GetAppInstance(application, instance)
 
 	if err != nil {
@@ -59,7 +59,7 @@ func (c *Client) GetAppInstance(application, instance string) (res *api.GetAppIn
 
 func (c *Client) UnInstallAppInstance(application, instance string) (err error) {
 
-	applicationService := ingextAPI.NewApplicationService(c.ingextClient)
+	applicationService := ingextAPI.NewApplicationService(c.ingextClient).WithContext(c.context())
 
 	req := &api.UnInstallAppInstanceRequest{
 		Application: application,
@@ -77,7 +77,7 @@ func (c *Client) UnInstallAppInstance(application, instance string) (err error)
 
 func (c *Client) AddTemplate(content string) (id string, err error) {
 
-	applicationService := ingextAPI.NewApplicationService(c.ingextClient)
+	applicationService := ingextAPI.NewApplicationService(c.ingextClient).WithContext(c.context())
 
 	id, err = applicationService.AddAppTemplate(content)
 
@@ -90,7 +90,7 @@ func (c *Client) AddTemplate(content string) (id string, err error) {
 
 func (c *Client) DeleteTemplate(name string) (err error) {
 
-	applicationService := ingextAPI.NewApplicationService(c.ingextClient)
+	applicationService := ingextAPI.NewApplicationService(c.ingextClient).WithContext(c.context())
 
 	err = applicationService.DeleteAppTemplate(name)
 
@@ -103,7 +103,7 @@ func (c *Client) DeleteTemplate(name string) (err error) {
 
 func (c *Client) UpdateTemplate(name string, content string) (err error) {
 
-	applicationService := ingextAPI.NewApplicationService(c.ingextClient)
+	applicationService := ingextAPI.NewApplicationService(c.ingextClient).WithContext(c.context())
 
 	err = applicationService.UpdateAppTemplate(name, content)
 
@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	ingextAPI "github.com/SecurityDo/ingext_api/api"
-	"github.com/SecurityDo/ingext_api/client"
 	"github.com/SecurityDo/ingext_api/model"
 	ingextModel "github.com/SecurityDo/ingext_api/model"
 )
@@ -17,7 +16,7 @@ func (c *Client) AddUser(name, displayName, role, org, oauth string) error {
 	//	"role", role,
 	//)
 
-	authService := ingextAPI.NewAuthService(c.ingextClient)
+	authService := ingextAPI.NewAuthService(c.ingextClient).WithContext(c.context())
 
 	user := &ingextModel.UserEntry{
 		Username:     name,
@@ -50,7 +49,7 @@ func (c *Client) DeleteUser(username string) (err error) {
 
 	// Use structured logging
 
-	authService := ingextAPI.NewAuthService(c.ingextClient)
+	authService := ingextAPI.NewAuthService(c.ingextClient).WithContext(c.context())
 
 	err = authService.DeleteUser(username)
 	if err != nil {
@@ -64,7 +63,7 @@ func (c *Client) ListUser() (users []*model.UserEntry, err error) {
 
 	// Use structured logging
 
-	authService := ingextAPI.NewAuthService(c.ingextClient)
+	authService := ingextAPI.NewAuthService(c.ingextClient).WithContext(c.context())
 
 	users, err = authService.ListUser()
 	if err != nil {
@@ -76,7 +75,7 @@ func (c *Client) ListUser() (users []*model.UserEntry, err error) {
 
 // AddToken adds an API token on the configured site (ingextClient).
 func (c *Client) AddToken(name, description, role string) (token string, err error) {
-	authService := ingextAPI.NewAuthService(c.ingextClient)
+	authService := ingextAPI.NewAuthService(c.ingextClient).WithContext(c.context())
 
 	token, err = authService.AddToken(name, description, role)
 	if err != nil {
@@ -88,7 +87,7 @@ func (c *Client) AddToken(name, description, role string) (token string, err err
 
 // DeleteToken removes an API token on the configured site (ingextClient).
 func (c *Client) DeleteToken(name string) (err error) {
-	authService := ingextAPI.NewAuthService(c.ingextClient)
+	authService := ingextAPI.NewAuthService(c.ingextClient).WithContext(c.context())
 
 	err = authService.DeleteToken(name)
 	if err != nil {
@@ -100,13 +99,16 @@ func (c *Client) DeleteToken(name string) (err error) {
 
 // GPTAIAuthService builds an AuthService for the given GPT API base URL and bearer token (e.g. ingext ai --url --token).
 // HTTP debug dumps are always disabled for this client. Call AddToken / DeleteToken on the returned service.
-func (c *Client) GPTAIAuthService(baseURL, bearerToken string) *ingextAPI.AuthService {
-	cli := client.NewIngextClient(baseURL, bearerToken, false, c.Logger)
-	return ingextAPI.NewAuthService(cli)
+func (c *Client) GPTAIAuthService(baseURL, bearerToken string) (*ingextAPI.AuthService, error) {
+	cli, err := c.newIngextClient(baseURL, bearerToken, nil)
+	if err != nil {
+		return nil, err
+	}
+	return ingextAPI.NewAuthService(cli).WithContext(c.context()), nil
 }
 
 func (c *Client) SetUserSitePolicy(username, policy string) error {
-	authService := ingextAPI.NewAuthService(c.ingextClient)
+	authService := ingextAPI.NewAuthService(c.ingextClient).WithContext(c.context())
 
 	err := authService.SetUserSitePolicy(username, policy)
 	if err != nil {
@@ -120,7 +122,7 @@ func (c *Client) ListToken() (tokens []*model.ApiTokenEntry, err error) {
 
 	// Use structured logging
 
-	authService := ingextAPI.NewAuthService(c.ingextClient)
+	authService := ingextAPI.NewAuthService(c.ingextClient).WithContext(c.context())
 
 	tokens, err = authService.ListToken()
 	if err != nil {
@@ -9,7 +9,7 @@ import (
 
 func (c *Client) GetPodRole() (role, arn string, err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	role, arn, err = platformService.GetPodRole()
 
@@ -23,7 +23,7 @@ func (c *Client) GetPodRole() (role, arn string, err error) {
 
 func (c *Client) TestAssumedRole(roleARN, roleExternalID string) (err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	err = platformService.TestAssumedRole(roleARN, roleExternalID)
 
@@ -35,7 +35,7 @@ func (c *Client) TestAssumedRole(roleARN, roleExternalID string) (err error) {
 }
 func (c *Client) AddLocalAssumedRole(roleName, roleARN, roleExternalID string) (id string, err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	id, err = platformService.AddLocalAssumedRole(roleName, roleARN, roleExternalID)
 
@@ -48,7 +48,7 @@ func (c *Client) AddLocalAssumedRole(roleName, roleARN, roleExternalID string) (
 
 func (c *Client) AddAssumedRole(roleName, roleARN, roleExternalID string) (id string, err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	id, err = platformService.AddAssumedRole(roleName, roleARN, roleExternalID)
 
@@ -61,7 +61,7 @@ func (c *Client) AddAssumedRole(roleName, roleARN, roleExternalID string) (id st
 
 func (c *Client) DeleteAssumedRole(roleID string) (err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	err = platformService.DeleteAssumedRole(roleID)
 
@@ -74,7 +74,7 @@ func (c *Client) DeleteAssumedRole(roleID string) (err error) {
 
 func (c *Client) ListAssumedRole() (roles []*model.InstanceRole, err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	roles, err = platformService.ListAssumedRole()
 
@@ -1,14 +1,15 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
-	"strings"
 
 	"github.com/SecurityDo/ingext_api/client"
-	"github.com/SecurityDo/ingext_api/internal/config"
+	fsb "github.com/SecurityDo/ingext_api/fsb"
+	"github.com/SecurityDo/ingext_api/model"
 )
 
 // IngextAppAPI defines the contract for interacting with the backend
@@ -33,6 +34,27 @@ type Client struct {
 	// Embed the K8s helper
 	k8sClient    *K8sClusterClient
 	ingextClient *client.IngextClient // If you have a separate client for ingext
+	// siteURL is the URL of the site, also when calls go through a port-forward.
+	siteURL string
+
+	// ctx bounds every RPC call made through the wrappers (see SetContext).
+	ctx context.Context
+	// retryPolicy is applied to the ingext client when it is created (see SetRetryPolicy).
+	retryPolicy *client.RetryPolicy
+	// tlsConfig is applied to the ingext client when it is created (see SetTLSConfig).
+	tlsConfig *client.TLSConfig
+	// clientOptions are extra options for the ingext client (see AddClientOptions).
+	clientOptions []client.Option
+	// transport selects how Init reaches the site (see SetTransport);
+	// stopForward ends the port-forward it started, if any.
+	transport   string
+	stopForward func()
+	// proxy is the proxy URL used when the ingext client is created (see SetProxy).
+	proxy string
+	// rateLimit throttles the ingext client when it is created (see SetRateLimit).
+	rateLimit *client.RateLimit
+	// batchOptions controls bulk operations (see SetBatchOptions).
+	batchOptions *client.BatchOptions
 }
 
 // Option 1: Constructor injection (Recommended)
@@ -54,58 +76,214 @@ func NewClient(logger *slog.Logger) *Client {
 
 var IngextAppAPI *Client
 
+// Init initializes the client from the app secret and site config of a
+// Kubernetes namespace.
 func (c *Client) Init(cluster, namespace string, kubeContext string) error {
-	c.Cluster = cluster
-	c.Namespace = namespace
+	return c.InitWithCredentials(c.KubeCredentials(cluster, namespace, kubeContext))
+}
 
-	c.Logger.Debug("initializing k8s client", "context", kubeContext)
+// KubeCredentials returns a provider reading the credentials of namespace
+// with the client's Kubernetes connection, which status checks reuse.
+func (c *Client) KubeCredentials(cluster, namespace, kubeContext string) *KubeCredentials {
+	return &KubeCredentials{
+		Client:      c.k8sClient,
+		Cluster:     cluster,
+		Namespace:   namespace,
+		KubeContext: kubeContext,
+		Logger:      c.Logger,
+	}
+}
 
-	// 1. Connect to Kubernetes
-	if err := c.k8sClient.Connect(kubeContext); err != nil {
+// InitWithCredentials initializes the client with the credentials resolved
+// by provider, typically a CredentialChain. Credentials read from a
+// Kubernetes cluster are reached through a port-forward if the transport is
+// TransportPortForward.
+func (c *Client) InitWithCredentials(provider CredentialProvider) error {
+	creds, err := provider.Credentials(c.context())
+	if err != nil {
 		return err
 	}
-	c.Logger.Info("connected to kubernetes cluster", "context", kubeContext)
+	if creds.Namespace != "" {
+		c.Cluster = creds.Cluster
+		c.Namespace = creds.Namespace
+	}
+
+	siteURL := creds.SiteURL
+	if c.transport == TransportPortForward {
+		if creds.Namespace == "" {
+			c.Logger.Warn("port-forward transport applies to Kubernetes credentials only; calling the site directly", "credentials", creds.Source)
+		} else {
+			localURL, stop, err := c.k8sClient.PortForwardService(c.context(), creds.Namespace, apiServiceName, apiServicePort)
+			if err != nil {
+				return fmt.Errorf("failed to port-forward to the %s service: %w", apiServiceName, err)
+			}
+			c.stopForward = stop
+			c.Logger.Info("tunneling calls through a port-forward", "service", apiServiceName, "port", apiServicePort, "localURL", localURL)
+			siteURL = localURL
+		}
+	}
 
-	// TODO: Perform actual login / connection logic here
-	c.Logger.Debug("Connecting to cluster ...\n", "cluster", cluster, "namespace", namespace)
+	ingextClient, err := c.newIngextClient(siteURL, creds.Token, creds.Settings)
+	if err != nil {
+		return err
+	}
+	c.ingextClient = ingextClient
+	c.siteURL = creds.SiteURL
+
+	c.Logger.Info("initialized ingext client",
+		"siteURL", siteURL,
+		"credentials", creds.Source,
+	)
+	return nil
+}
 
-	token, err := c.k8sClient.GetAppSecret(namespace, "app-secret", "token")
+// InitDirect initializes the client with a siteURL and token directly (no Kubernetes, no site config file).
+func (c *Client) InitDirect(siteURL, token string) error {
+	ingextClient, err := c.newIngextClient(siteURL, token, nil)
 	if err != nil {
-		return fmt.Errorf("failed to get app secret token: %s", err)
+		return err
 	}
+	c.ingextClient = ingextClient
+	c.siteURL = siteURL
+	return nil
+}
 
-	configName := "ingext-community-config"
-	if strings.HasPrefix(namespace, "acc-") || strings.HasPrefix(namespace, "grid-") {
-		configName = "account-config"
+// newIngextClient creates the RPC client with the settings configured on c.
+// site holds per-site settings from site_credentials.json (nil if none); the
+// settings configured on c take precedence.
+func (c *Client) newIngextClient(siteURL, token string, site *model.SiteSettings) (*client.IngextClient, error) {
+	opts := []client.Option{
+		client.WithLogger(c.Logger),
+		client.WithRetryPolicy(c.retryPolicy),
+		client.WithTLSConfig(c.mergeTLSConfig(site)),
+		client.WithRateLimit(c.mergeRateLimit(site)),
 	}
-	configText, err := c.k8sClient.GetAppConfig(namespace, configName, "site_config.json")
+	proxy := c.proxy
+	if proxy == "" && site != nil {
+		proxy = site.Proxy
+	}
+	if proxy != "" {
+		opts = append(opts, client.WithProxy(proxy))
+	}
+	ingextClient, err := client.NewIngextClientWithOptions(siteURL, token, append(opts, c.clientOptions...)...)
 	if err != nil {
-		return fmt.Errorf("failed to get app config: %s", err)
+		return nil, fmt.Errorf("failed to create client for %s: %w", siteURL, err)
 	}
-	var config struct {
-		SiteURL string `json:"siteURL"`
+	return ingextClient, nil
+}
+
+// mergeTLSConfig fills the TLS settings not configured on c from site.
+func (c *Client) mergeTLSConfig(site *model.SiteSettings) *client.TLSConfig {
+	merged := client.TLSConfig{}
+	if c.tlsConfig != nil {
+		merged = *c.tlsConfig
 	}
+	if site == nil {
+		return &merged
+	}
+	if merged.CAFile == "" {
+		merged.CAFile = site.CAFile
+	}
+	if merged.CertFile == "" && merged.KeyFile == "" {
+		merged.CertFile, merged.KeyFile = site.CertFile, site.KeyFile
+	}
+	merged.InsecureSkipVerify = merged.InsecureSkipVerify || site.Insecure
+	return &merged
+}
 
-	if err := json.Unmarshal([]byte(configText), &config); err != nil {
-		c.Logger.Error("failed to parse site config", "error", err, "config", configText)
-		return fmt.Errorf("failed to parse site config:  %s", err)
+// mergeRateLimit fills the limits not configured on c from site.
+func (c *Client) mergeRateLimit(site *model.SiteSettings) *client.RateLimit {
+	merged := client.RateLimit{}
+	if c.rateLimit != nil {
+		merged = *c.rateLimit
 	}
+	if site == nil {
+		return &merged
+	}
+	if merged.Rate <= 0 {
+		merged.Rate = site.RateLimit
+	}
+	if merged.MaxInFlight <= 0 {
+		merged.MaxInFlight = site.MaxInFlight
+	}
+	return &merged
+}
 
-	ingextClient := client.NewIngextClient(config.SiteURL, token, false, c.Logger)
+// SetContext binds all subsequent RPC calls to ctx, so they are aborted when
+// ctx is cancelled (e.g. Ctrl-C) or its deadline expires.
+func (c *Client) SetContext(ctx context.Context) {
+	c.ctx = ctx
+}
 
-	c.ingextClient = ingextClient
+// context returns the context set by SetContext, or context.Background().
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
 
-	c.Logger.Info("initialized ingext client",
-		"siteURL", config.SiteURL,
-		//"token", token,
-	)
+// SetRetryPolicy sets the retry policy for transient failures. Call it before
+// Init/InitDirect/InitFromSiteConfig; nil keeps client.DefaultRetryPolicy.
+func (c *Client) SetRetryPolicy(p *client.RetryPolicy) {
+	c.retryPolicy = p
+	if c.ingextClient != nil {
+		c.ingextClient.SetRetryPolicy(p)
+	}
+}
 
-	return nil
+// SetTLSConfig sets the TLS verification and client certificate settings. Call
+// it before Init/InitDirect/InitFromSiteConfig.
+func (c *Client) SetTLSConfig(cfg *client.TLSConfig) {
+	c.tlsConfig = cfg
 }
 
-// InitDirect initializes the client with a siteURL and token directly (no Kubernetes, no site config file).
-func (c *Client) InitDirect(siteURL, token string) {
-	c.ingextClient = client.NewIngextClient(siteURL, token, false, c.Logger)
+// AddClientOptions adds options (user agent, headers, transport, ...) applied
+// whenever the ingext client is created. Call it before the Init methods.
+func (c *Client) AddClientOptions(opts ...client.Option) {
+	c.clientOptions = append(c.clientOptions, opts...)
+}
+
+// SetTransport selects how Init reaches the site: TransportDirect (or "")
+// calls the siteURL of the site config, TransportPortForward tunnels calls
+// through the Kubernetes API to the api service, for clusters whose site is
+// not reachable from here. Call it before Init; InitDirect and
+// InitFromSiteConfig always call the site directly.
+func (c *Client) SetTransport(transport string) error {
+	switch transport {
+	case "", TransportDirect, TransportPortForward:
+		c.transport = transport
+		return nil
+	}
+	return fmt.Errorf("unknown transport %q (want %s or %s)", transport, TransportDirect, TransportPortForward)
+}
+
+// Close releases the resources held by the client, such as a port-forward.
+func (c *Client) Close() {
+	if c.stopForward != nil {
+		c.stopForward()
+		c.stopForward = nil
+	}
+}
+
+// SetProxy sets the proxy URL used to reach the site (http, https, socks5 or
+// socks5h; "direct" for none). Call it before the Init methods; when empty,
+// the site setting or the HTTPS_PROXY/NO_PROXY environment applies.
+func (c *Client) SetProxy(proxyURL string) {
+	c.proxy = proxyURL
+}
+
+// SetRateLimit throttles every request sent to the site, shared by all
+// commands and batch workers of this process. Call it before the Init
+// methods; limits left at 0 are taken from the site settings, if any.
+func (c *Client) SetRateLimit(l *client.RateLimit) {
+	c.rateLimit = l
+}
+
+// SetBatchOptions sets the concurrency and rate limit of bulk operations
+// such as DeleteDataSources and ImportProcessor; nil keeps the defaults.
+func (c *Client) SetBatchOptions(opts *client.BatchOptions) {
+	c.batchOptions = opts
 }
 
 // SetDebug enables or disables HTTP request/response dump logging (e.g. when --log-level debug).
@@ -119,20 +297,26 @@ func (c *Client) SetDebug(debug bool) {
 // siteConfigPath is the path to site_credentials.json; site is the hostname key (e.g. "demo.cloud.fluencysecurity.com").
 // If site is empty, the first site in tokenMap is used.
 func (c *Client) InitFromSiteConfig(siteConfigPath, site string) error {
-	creds, err := config.LoadSiteCredentials(siteConfigPath)
-	if err != nil {
-		return err
+	return c.InitWithCredentials(&FileCredentials{Path: siteConfigPath, Site: site})
+}
+
+// Call invokes prefix/functionName with the JSON arguments functionArgs,
+// uploading the local files of attachments with the request. Attachments of
+// the response are saved in downloadDir (their Path is set), or only listed
+// if downloadDir is empty.
+func (c *Client) Call(prefix, functionName string, functionArgs json.RawMessage, attachments []string, downloadDir string) (*fsb.CallResponse, error) {
+	var uploads []*fsb.Attachment
+	for _, path := range attachments {
+		uploads = append(uploads, &fsb.Attachment{Path: path})
 	}
-	baseURL, token, err := config.ResolveSite(creds, site)
+	var args interface{}
+	if len(functionArgs) > 0 {
+		args = functionArgs
+	}
+	res, err := c.ingextClient.GenericCallWithAttachments(c.context(), prefix, functionName, args, uploads, downloadDir)
 	if err != nil {
-		return err
+		c.Logger.Error("call error", "prefix", prefix, "function", functionName, "error", err)
+		return nil, fmt.Errorf("call %s/%s: %w", prefix, functionName, err)
 	}
-	c.ingextClient = client.NewIngextClient(baseURL, token, false, c.Logger)
-	c.Logger.Info("initialized ingext client from site config", "siteURL", baseURL)
-	return nil
-}
-
-func (c *Client) Call(functionName string, functionArgs json.RawMessage) error {
-	// TODO: Implementation
-	return nil
+	return res, nil
 }
@@ -9,7 +9,7 @@ import (
 
 // CollectorList calls the collector_list API (kargs: {}) and returns collectors for web.
 func (c *Client) CollectorList() ([]*model.CollectorForWeb, error) {
-	svc := fluencyAPI.NewCollectorService(c.ingextClient)
+	svc := fluencyAPI.NewCollectorService(c.ingextClient).WithContext(c.context())
 	entries, err := svc.CollectorList()
 	if err != nil {
 		c.Logger.Error("failed to list collectors", "error", err)
@@ -20,7 +20,7 @@ func (c *Client) CollectorList() ([]*model.CollectorForWeb, error) {
 
 // CollectorStatus calls the collector_status API with collector name and cargs; returns response as map[string]interface{}.
 func (c *Client) CollectorStatus(collector string, cargs map[string]interface{}) (map[string]interface{}, error) {
-	svc := fluencyAPI.NewCollectorService(c.ingextClient)
+	svc := fluencyAPI.NewCollectorService(c.ingextClient).WithContext(c.context())
 	out, err := svc.CollectorStatus(collector, cargs)
 	if err != nil {
 		c.Logger.Error("failed to get collector status", "collector", collector, "error", err)
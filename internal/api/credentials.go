@@ -0,0 +1,322 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/SecurityDo/ingext_api/internal/config"
+	"github.com/SecurityDo/ingext_api/model"
+	"github.com/zalando/go-keyring"
+)
+
+// ErrNoCredentials is returned (wrapped) by a CredentialProvider that has
+// nothing to offer, e.g. unset env vars or a missing file, so that a
+// CredentialChain moves on to the next provider.
+var ErrNoCredentials = errors.New("no credentials")
+
+// DefaultKeyringService is the OS keyring service under which tokens are stored.
+const DefaultKeyringService = "ingext"
+
+// Credentials are the site URL and API token used to call a site.
+type Credentials struct {
+	SiteURL string
+	Token   string
+	// Source names the provider the credentials come from.
+	Source string
+	// Settings are per-site connection settings (site_credentials.json), or nil.
+	Settings *model.SiteSettings
+	// Cluster and Namespace are set when the credentials were read from a
+	// Kubernetes cluster, which Init can then port-forward to.
+	Cluster   string
+	Namespace string
+}
+
+// CredentialProvider resolves the credentials of a site.
+type CredentialProvider interface {
+	// Name identifies the provider in logs and errors.
+	Name() string
+	// Credentials returns the credentials, or an error wrapping
+	// ErrNoCredentials if the provider has none.
+	Credentials(ctx context.Context) (*Credentials, error)
+}
+
+// CredentialChain tries each provider in order and returns the credentials
+// of the first one that has some. Providers failing with anything other than
+// ErrNoCredentials stop the chain, so a broken keyring or exec plugin is
+// reported instead of silently falling back to another token.
+type CredentialChain []CredentialProvider
+
+func (ch CredentialChain) Name() string { return "chain" }
+
+func (ch CredentialChain) Credentials(ctx context.Context) (*Credentials, error) {
+	var skipped []string
+	for _, p := range ch {
+		creds, err := p.Credentials(ctx)
+		if errors.Is(err, ErrNoCredentials) {
+			skipped = append(skipped, p.Name()+": "+strings.TrimPrefix(err.Error(), ErrNoCredentials.Error()+": "))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s credentials: %w", p.Name(), err)
+		}
+		if creds.Source == "" {
+			creds.Source = p.Name()
+		}
+		return creds, nil
+	}
+	return nil, fmt.Errorf("%w (%s)", ErrNoCredentials, strings.Join(skipped, "; "))
+}
+
+// EnvCredentials reads INGEXT_SITE_URL and INGEXT_TOKEN. SiteURL, if set,
+// is used when INGEXT_SITE_URL is not.
+type EnvCredentials struct {
+	SiteURL string
+}
+
+func (p *EnvCredentials) Name() string { return "env" }
+
+func (p *EnvCredentials) Credentials(ctx context.Context) (*Credentials, error) {
+	siteURL := os.Getenv("INGEXT_SITE_URL")
+	if siteURL == "" {
+		siteURL = p.SiteURL
+	}
+	token := os.Getenv("INGEXT_TOKEN")
+	if siteURL == "" || token == "" {
+		return nil, fmt.Errorf("%w: INGEXT_SITE_URL and INGEXT_TOKEN not set", ErrNoCredentials)
+	}
+	return &Credentials{SiteURL: siteURL, Token: token}, nil
+}
+
+// FileCredentials reads a site_credentials.json file; Site is the hostname
+// in its tokenMap (the first one if empty).
+type FileCredentials struct {
+	Path string
+	Site string
+}
+
+func (p *FileCredentials) Name() string { return "file" }
+
+func (p *FileCredentials) Credentials(ctx context.Context) (*Credentials, error) {
+	if p.Path == "" {
+		return nil, fmt.Errorf("%w: no site config path", ErrNoCredentials)
+	}
+	if _, err := os.Stat(p.Path); err != nil {
+		return nil, fmt.Errorf("%w: %s not found", ErrNoCredentials, p.Path)
+	}
+	creds, err := config.LoadSiteCredentials(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	baseURL, token, err := config.ResolveSite(creds, p.Site)
+	if err != nil {
+		return nil, err
+	}
+	return &Credentials{SiteURL: baseURL, Token: token, Settings: config.SiteSettingsFor(creds, baseURL)}, nil
+}
+
+// KubeCredentials reads the token from the app secret of a Kubernetes
+// namespace and the site URL from its site config map.
+type KubeCredentials struct {
+	Client      *K8sClusterClient
+	Cluster     string
+	Namespace   string
+	KubeContext string
+	// SecretName and SecretKey locate the token ("app-secret", "token" if empty).
+	SecretName string
+	SecretKey  string
+	Logger     *slog.Logger
+}
+
+func (p *KubeCredentials) Name() string { return "kube" }
+
+func (p *KubeCredentials) Credentials(ctx context.Context) (*Credentials, error) {
+	if p.Cluster == "" {
+		return nil, fmt.Errorf("%w: no cluster configured", ErrNoCredentials)
+	}
+	logger := p.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if p.KubeContext == "" {
+		logger.Warn("no kube-context specified in config, using current system default")
+	}
+	logger.Debug("initializing k8s client", "context", p.KubeContext)
+	if err := p.Client.Connect(p.KubeContext); err != nil {
+		return nil, err
+	}
+	logger.Info("connected to kubernetes cluster", "context", p.KubeContext)
+
+	secretName, secretKey := p.SecretName, p.SecretKey
+	if secretName == "" {
+		secretName = "app-secret"
+	}
+	if secretKey == "" {
+		secretKey = "token"
+	}
+	token, err := p.Client.GetAppSecret(p.Namespace, secretName, secretKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app secret token: %w", err)
+	}
+
+	configName := "ingext-community-config"
+	if strings.HasPrefix(p.Namespace, "acc-") || strings.HasPrefix(p.Namespace, "grid-") {
+		configName = "account-config"
+	}
+	configText, err := p.Client.GetAppConfig(p.Namespace, configName, "site_config.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app config: %w", err)
+	}
+	var siteConfig struct {
+		SiteURL string `json:"siteURL"`
+	}
+	if err := json.Unmarshal([]byte(configText), &siteConfig); err != nil {
+		logger.Error("failed to parse site config", "error", err, "config", configText)
+		return nil, fmt.Errorf("failed to parse site config:  %w", err)
+	}
+	return &Credentials{SiteURL: siteConfig.SiteURL, Token: token, Cluster: p.Cluster, Namespace: p.Namespace}, nil
+}
+
+// KeyringCredentials reads credentials stored with StoreKeyringCredentials
+// in the OS keyring (macOS Keychain, Windows Credential Manager, or the
+// Secret Service on Linux). SiteURL is used if the stored entry has none.
+type KeyringCredentials struct {
+	Service string
+	Account string
+	SiteURL string
+}
+
+func (p *KeyringCredentials) Name() string { return "keyring" }
+
+func (p *KeyringCredentials) service() string {
+	if p.Service == "" {
+		return DefaultKeyringService
+	}
+	return p.Service
+}
+
+func (p *KeyringCredentials) Credentials(ctx context.Context) (*Credentials, error) {
+	secret, err := keyring.Get(p.service(), p.Account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, fmt.Errorf("%w: no entry %s/%s in the keyring", ErrNoCredentials, p.service(), p.Account)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the keyring: %w", err)
+	}
+	return parseCredentials([]byte(secret), p.SiteURL)
+}
+
+// StoreKeyringCredentials saves creds in the OS keyring under service (or
+// DefaultKeyringService) and account, for KeyringCredentials.
+func StoreKeyringCredentials(service, account string, creds *Credentials) error {
+	if service == "" {
+		service = DefaultKeyringService
+	}
+	b, err := json.Marshal(storedCredentials{SiteURL: creds.SiteURL, Token: creds.Token})
+	if err != nil {
+		return err
+	}
+	return keyring.Set(service, account, string(b))
+}
+
+// DeleteKeyringCredentials removes credentials saved by StoreKeyringCredentials.
+func DeleteKeyringCredentials(service, account string) error {
+	if service == "" {
+		service = DefaultKeyringService
+	}
+	return keyring.Delete(service, account)
+}
+
+// ExecCredentials runs an external command, like a kubectl exec credential
+// plugin, and reads the credentials from its standard output: either a JSON
+// object {"siteURL": ..., "token": ...} or a bare token, with SiteURL as the
+// site URL. The command inherits stdin and stderr so it can prompt; its
+// environment is extended with Env.
+type ExecCredentials struct {
+	Command string
+	Args    []string
+	Env     map[string]string
+	SiteURL string
+}
+
+func (p *ExecCredentials) Name() string { return "exec" }
+
+func (p *ExecCredentials) Credentials(ctx context.Context) (*Credentials, error) {
+	if p.Command == "" {
+		return nil, fmt.Errorf("no command configured")
+	}
+	cmd := exec.CommandContext(ctx, p.Command, p.Args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	for k, v := range p.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w", p.Command, err)
+	}
+	return parseCredentials(stdout.Bytes(), p.SiteURL)
+}
+
+// AgentFileCredentials reads a token file kept up to date by an agent, such
+// as a Vault Agent sink: a bare token, or the JSON object accepted by
+// ExecCredentials. SiteURL is used if the file has none.
+type AgentFileCredentials struct {
+	Path    string
+	SiteURL string
+}
+
+func (p *AgentFileCredentials) Name() string { return "agent-file" }
+
+func (p *AgentFileCredentials) Credentials(ctx context.Context) (*Credentials, error) {
+	if p.Path == "" {
+		return nil, fmt.Errorf("no token file configured")
+	}
+	b, err := os.ReadFile(p.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s not found (is the agent running?)", ErrNoCredentials, p.Path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseCredentials(b, p.SiteURL)
+}
+
+// storedCredentials is the JSON form of credentials in the keyring, token
+// files and exec plugin output.
+type storedCredentials struct {
+	SiteURL string `json:"siteURL,omitempty"`
+	Token   string `json:"token"`
+}
+
+// parseCredentials parses a storedCredentials object or a bare token;
+// siteURL applies when the input has no site URL.
+func parseCredentials(b []byte, siteURL string) (*Credentials, error) {
+	b = bytes.TrimSpace(b)
+	stored := storedCredentials{}
+	if bytes.HasPrefix(b, []byte("{")) {
+		if err := json.Unmarshal(b, &stored); err != nil {
+			return nil, fmt.Errorf("invalid credentials: %w", err)
+		}
+	} else {
+		stored.Token = string(b)
+	}
+	if stored.SiteURL == "" {
+		stored.SiteURL = siteURL
+	}
+	if stored.Token == "" {
+		return nil, fmt.Errorf("credentials have no token")
+	}
+	if stored.SiteURL == "" {
+		return nil, fmt.Errorf("credentials have no site URL (set siteURL in the profile)")
+	}
+	return &Credentials{SiteURL: stored.SiteURL, Token: stored.Token}, nil
+}
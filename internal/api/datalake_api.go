@@ -9,7 +9,7 @@ import (
 
 func (c *Client) ListDatalakes() (entries []*model.Datalake, err error) {
 
-	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient)
+	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient).WithContext(c.context())
 
 	resp, err := datalakeService.ListDatalake()
 
@@ -22,7 +22,7 @@ func (c *Client) ListDatalakes() (entries []*model.Datalake, err error) {
 
 func (c *Client) AddDatalake(name string, managed bool, integrationID string) (err error) {
 
-	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient)
+	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient).WithContext(c.context())
 
 	err = datalakeService.AddDatalake(name, managed, integrationID)
 
@@ -35,7 +35,7 @@ func (c *Client) AddDatalake(name string, managed bool, integrationID string) (e
 
 func (c *Client) AddDatalakeIndex(lake, index string, schema string) (err error) {
 
-	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient)
+	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient).WithContext(c.context())
 
 	err = datalakeService.AddDatalakeIndex(lake, index, schema)
 
@@ -48,7 +48,7 @@ func (c *Client) AddDatalakeIndex(lake, index string, schema string) (err error)
 
 func (c *Client) ListDatalakeIndex(lake string) (entries []*model.DatalakeIndex, err error) {
 
-	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient)
+	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient).WithContext(c.context())
 
 	entries, err = datalakeService.ListDatalakeIndex(lake)
 
@@ -60,7 +60,7 @@ func (c *Client) ListDatalakeIndex(lake string) (entries []*model.DatalakeIndex,
 }
 
 func (c *Client) ListSchemas() (entries []*model.SchemaEntry, err error) {
-	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient)
+	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient).WithContext(c.context())
 
 	entries, err = datalakeService.ListSchema()
 	if err != nil {
@@ -71,7 +71,7 @@ func (c *Client) ListSchemas() (entries []*model.SchemaEntry, err error) {
 }
 
 func (c *Client) UpdateSchema(name, description, content string) error {
-	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient)
+	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient).WithContext(c.context())
 
 	err := datalakeService.UpdateSchema(name, description, content)
 	if err != nil {
@@ -82,7 +82,7 @@ func (c *Client) UpdateSchema(name, description, content string) error {
 }
 
 func (c *Client) DeleteSchema(name string) error {
-	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient)
+	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient).WithContext(c.context())
 
 	err := datalakeService.DeleteSchema(name)
 	if err != nil {
@@ -93,7 +93,7 @@ func (c *Client) DeleteSchema(name string) error {
 }
 
 func (c *Client) AddSchema(name, description, content string) error {
-	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient)
+	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient).WithContext(c.context())
 
 	err := datalakeService.AddSchema(name, description, content)
 	if err != nil {
@@ -105,7 +105,7 @@ func (c *Client) AddSchema(name, description, content string) error {
 
 func (c *Client) DeleteDatalakeIndex(lake, index string) (err error) {
 
-	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient)
+	datalakeService := ingextAPI.NewDatalakeService(c.ingextClient).WithContext(c.context())
 
 	err = datalakeService.DeleteDatalakeIndex(lake, index)
 
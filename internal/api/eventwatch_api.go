@@ -9,7 +9,7 @@ import (
 
 // SummarySearch calls the overview summary search API with the given search string and time range.
 func (c *Client) SummarySearch(searchString string, rangeFrom, rangeTo int64) (*model.ElasticSearchResult, error) {
-	svc := fluencyAPI.NewEventWatchService(c.ingextClient)
+	svc := fluencyAPI.NewEventWatchService(c.ingextClient).WithContext(c.context())
 	resp, err := svc.SummarySearch(searchString, rangeFrom, rangeTo)
 	if err != nil {
 		c.Logger.Error("failed to run summary search", "error", err)
@@ -20,7 +20,7 @@ func (c *Client) SummarySearch(searchString string, rangeFrom, rangeTo int64) (*
 
 // TimelineSearch calls the fsm_behavior_search API with the given search string and time range.
 func (c *Client) TimelineSearch(searchString string, rangeFrom, rangeTo int64) (*model.ElasticSearchResult, error) {
-	svc := fluencyAPI.NewEventWatchService(c.ingextClient)
+	svc := fluencyAPI.NewEventWatchService(c.ingextClient).WithContext(c.context())
 	resp, err := svc.TimelineSearch(searchString, rangeFrom, rangeTo)
 	if err != nil {
 		c.Logger.Error("failed to run timeline search", "error", err)
@@ -31,7 +31,7 @@ func (c *Client) TimelineSearch(searchString string, rangeFrom, rangeTo int64) (
 
 // RuleSearch calls the eventwatch_bucket_search API with the given search string (no time range).
 func (c *Client) RuleSearch(searchString string) (*model.ElasticSearchResult, error) {
-	svc := fluencyAPI.NewEventWatchService(c.ingextClient)
+	svc := fluencyAPI.NewEventWatchService(c.ingextClient).WithContext(c.context())
 	resp, err := svc.RuleSearch(searchString)
 	if err != nil {
 		c.Logger.Error("failed to run rule search", "error", err)
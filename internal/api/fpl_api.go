@@ -9,7 +9,7 @@ import (
 
 // RunReport calls the run_fplv2_report API with the given request and returns the task id.
 func (c *Client) RunReport(req *model.RunFPLV2Report) (uint, error) {
-	svc := fluencyAPI.NewFPLService(c.ingextClient)
+	svc := fluencyAPI.NewFPLService(c.ingextClient).WithContext(c.context())
 	id, err := svc.RunReport(req)
 	if err != nil {
 		c.Logger.Error("failed to run FPL report", "error", err)
@@ -20,7 +20,7 @@ func (c *Client) RunReport(req *model.RunFPLV2Report) (uint, error) {
 
 // GetTaskByID calls the get_fpl_task API with the given id and returns the task response.
 func (c *Client) GetTaskByID(id uint) (*model.GetTaskResponse, error) {
-	svc := fluencyAPI.NewFPLService(c.ingextClient)
+	svc := fluencyAPI.NewFPLService(c.ingextClient).WithContext(c.context())
 	resp, err := svc.GetTaskByID(id)
 	if err != nil {
 		c.Logger.Error("failed to get FPL task", "id", id, "error", err)
@@ -31,7 +31,7 @@ func (c *Client) GetTaskByID(id uint) (*model.GetTaskResponse, error) {
 
 // GetResultsByID calls the get_fpl_result API with the given id and returns the metric result.
 func (c *Client) GetResultsByID(id uint) (*model.GetMetricFPLResultResponse, error) {
-	svc := fluencyAPI.NewFPLService(c.ingextClient)
+	svc := fluencyAPI.NewFPLService(c.ingextClient).WithContext(c.context())
 	resp, err := svc.GetResultsByID(id)
 	if err != nil {
 		c.Logger.Error("failed to get FPL result", "id", id, "error", err)
@@ -8,7 +8,7 @@ import (
 )
 
 func (c *Client) ListAccount() (resp *model.ListFluencyAccountsResponse, err error) {
-	service := ingextAPI.NewGridService(c.ingextClient)
+	service := ingextAPI.NewGridService(c.ingextClient).WithContext(c.context())
 
 	resp, err = service.ListAccount()
 	if err != nil {
@@ -19,7 +19,7 @@ func (c *Client) ListAccount() (resp *model.ListFluencyAccountsResponse, err err
 }
 
 func (c *Client) AddSaasAccount(req *model.GridAddSaasAccountRequest) error {
-	service := ingextAPI.NewGridService(c.ingextClient)
+	service := ingextAPI.NewGridService(c.ingextClient).WithContext(c.context())
 
 	if err := service.AddSaasAccount(req); err != nil {
 		c.Logger.Error("add saas account error", "error", err)
@@ -29,7 +29,7 @@ func (c *Client) AddSaasAccount(req *model.GridAddSaasAccountRequest) error {
 }
 
 func (c *Client) DeleteSaasAccount(req *model.GridDeleteSaasAccountRequest) error {
-	service := ingextAPI.NewGridService(c.ingextClient)
+	service := ingextAPI.NewGridService(c.ingextClient).WithContext(c.context())
 
 	if err := service.DeleteSaasAccount(req); err != nil {
 		c.Logger.Error("delete saas account error", "error", err)
@@ -9,39 +9,39 @@ import (
 
 func (c *Client) AddIntegration(entry *model.Integration) (id string, err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	id, err = platformService.AddIntegration(entry)
 
 	if err != nil {
 		c.Logger.Error("failed to add integration", "error", err)
-		return "", fmt.Errorf("failed to add integration: %s", err.Error())
+		return "", fmt.Errorf("failed to add integration: %w", err)
 	}
 	return id, nil
 }
 
 func (c *Client) DeleteIntegration(id string) (err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	err = platformService.DeleteIntegration(id)
 
 	if err != nil {
 		c.Logger.Error("failed to delete integration", "error", err)
-		return fmt.Errorf("failed to delete integration: %s", err.Error())
+		return fmt.Errorf("failed to delete integration: %w", err)
 	}
 	return nil
 }
 
 func (c *Client) ListIntegration() (entries []*model.Integration, err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	entries, err = platformService.ListIntegrations()
 
 	if err != nil {
 		c.Logger.Error("failed to list integration", "error", err)
-		return nil, fmt.Errorf("failed to list integration: %s", err.Error())
+		return nil, fmt.Errorf("failed to list integration: %w", err)
 	}
 	return entries, nil
 }
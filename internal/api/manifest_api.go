@@ -0,0 +1,47 @@
+package api
+
+import (
+	"fmt"
+
+	ingextAPI "github.com/SecurityDo/ingext_api/api"
+	"github.com/SecurityDo/ingext_api/internal/manifest"
+)
+
+// ApplyManifest creates or updates the resources of m that differ from the
+// site (see manifest.Apply). The results of the resources applied are
+// returned also on error.
+func (c *Client) ApplyManifest(m *manifest.Manifest, opts manifest.Options) (results []*manifest.Result, err error) {
+
+	results, err = manifest.Apply(c.manifestSite(), m, opts)
+
+	if err != nil {
+		c.Logger.Error("failed to apply manifest", "error", err)
+		return results, fmt.Errorf("failed to apply manifest: %w", err)
+	}
+	return results, nil
+}
+
+// ExportManifest snapshots the configuration of the site as a manifest (see
+// manifest.Export).
+func (c *Client) ExportManifest() (m *manifest.Manifest, err error) {
+
+	m, err = manifest.Export(c.manifestSite(), c.siteURL)
+
+	if err != nil {
+		c.Logger.Error("failed to export site", "error", err)
+		return nil, fmt.Errorf("failed to export site: %w", err)
+	}
+	return m, nil
+}
+
+// manifestSite returns the services manifest.Apply and manifest.Export call.
+func (c *Client) manifestSite() *manifest.Site {
+	ctx := c.context()
+	return &manifest.Site{
+		Platform:     ingextAPI.NewPlatformService(c.ingextClient).WithContext(ctx),
+		Datalake:     ingextAPI.NewDatalakeService(c.ingextClient).WithContext(ctx),
+		Application:  ingextAPI.NewApplicationService(c.ingextClient).WithContext(ctx),
+		Notification: ingextAPI.NewNotificationService(c.ingextClient).WithContext(ctx),
+		Syslog:       ingextAPI.NewSyslogService(c.ingextClient).WithContext(ctx),
+	}
+}
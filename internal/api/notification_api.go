@@ -8,7 +8,7 @@ import (
 )
 
 func (c *Client) NotificationList() ([]*model.EndpointConfig, error) {
-	service := ingextAPI.NewNotificationService(c.ingextClient)
+	service := ingextAPI.NewNotificationService(c.ingextClient).WithContext(c.context())
 
 	endpoints, err := service.List()
 	if err != nil {
@@ -19,7 +19,7 @@ func (c *Client) NotificationList() ([]*model.EndpointConfig, error) {
 }
 
 func (c *Client) NotificationDelete(name string) error {
-	service := ingextAPI.NewNotificationService(c.ingextClient)
+	service := ingextAPI.NewNotificationService(c.ingextClient).WithContext(c.context())
 
 	if err := service.Delete(name); err != nil {
 		c.Logger.Error("delete notification endpoint error", "error", err)
@@ -29,7 +29,7 @@ func (c *Client) NotificationDelete(name string) error {
 }
 
 func (c *Client) NotificationAddEmail(name string, action string, to []string, cc []string) (string, error) {
-	service := ingextAPI.NewNotificationService(c.ingextClient)
+	service := ingextAPI.NewNotificationService(c.ingextClient).WithContext(c.context())
 
 	id, err := service.AddEmail(name, action, to, cc)
 	if err != nil {
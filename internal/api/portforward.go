@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// Transports selectable with SetTransport.
+const (
+	// TransportDirect calls the site URL from the site config (default).
+	TransportDirect = "direct"
+	// TransportPortForward tunnels calls through the Kubernetes API to the
+	// api service, for private clusters without an ingress.
+	TransportPortForward = "port-forward"
+)
+
+// The in-cluster service answering RPC calls (see CheckServiceEndpoints).
+const (
+	apiServiceName = "api"
+	apiServicePort = 8002
+)
+
+// portForwardTimeout bounds the time to set up a port-forward.
+const portForwardTimeout = 30 * time.Second
+
+// PortForwardService forwards a random local port to a ready pod backing
+// port of the service, like kubectl port-forward svc/<service>. It returns
+// the local base URL; the forward runs until ctx is done or stop is called.
+func (k *K8sClusterClient) PortForwardService(ctx context.Context, namespace, service string, port int) (localURL string, stop func(), err error) {
+	if k.clientset == nil {
+		return "", nil, fmt.Errorf("k8s client not initialized")
+	}
+	pod, podPort, err := k.servicePod(ctx, namespace, service, port)
+	if err != nil {
+		return "", nil, err
+	}
+
+	restConfig, err := k.config.ClientConfig()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	transport, upgrader, err := spdy.RoundTripperFor(restConfig)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create port-forward transport: %w", err)
+	}
+	url := k.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(namespace).Name(pod).SubResource("portforward").URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	var errOut bytes.Buffer
+	fw, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", podPort)}, stopCh, readyCh, nil, &errOut)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to set up port-forward to %s/%s: %w", namespace, pod, err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- fw.ForwardPorts() }()
+
+	var stopOnce sync.Once
+	stop = func() { stopOnce.Do(func() { close(stopCh) }) }
+	timer := time.NewTimer(portForwardTimeout)
+	defer timer.Stop()
+	select {
+	case <-readyCh:
+	case err := <-done:
+		return "", nil, fmt.Errorf("port-forward to %s/%s failed: %v %s", namespace, pod, err, strings.TrimSpace(errOut.String()))
+	case <-timer.C:
+		stop()
+		return "", nil, fmt.Errorf("port-forward to %s/%s: not ready after %s", namespace, pod, portForwardTimeout)
+	case <-ctx.Done():
+		stop()
+		return "", nil, ctx.Err()
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			stop()
+		case <-done:
+		}
+	}()
+
+	ports, err := fw.GetPorts()
+	if err != nil || len(ports) == 0 {
+		stop()
+		return "", nil, fmt.Errorf("port-forward to %s/%s: no local port: %v", namespace, pod, err)
+	}
+	return fmt.Sprintf("http://127.0.0.1:%d", ports[0].Local), stop, nil
+}
+
+// servicePod returns a running, ready pod selected by the service and the pod
+// port its service port targets.
+func (k *K8sClusterClient) servicePod(ctx context.Context, namespace, service string, port int) (pod string, podPort int, err error) {
+	svc, err := k.clientset.CoreV1().Services(namespace).Get(ctx, service, metav1.GetOptions{})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get service %s/%s: %w", namespace, service, err)
+	}
+	var svcPort *corev1.ServicePort
+	for i := range svc.Spec.Ports {
+		if int(svc.Spec.Ports[i].Port) == port {
+			svcPort = &svc.Spec.Ports[i]
+			break
+		}
+	}
+	if svcPort == nil {
+		return "", 0, fmt.Errorf("service %s/%s has no port %d", namespace, service, port)
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return "", 0, fmt.Errorf("service %s/%s has no pod selector", namespace, service)
+	}
+
+	pods, err := k.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(svc.Spec.Selector).String(),
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to list pods of service %s/%s: %w", namespace, service, err)
+	}
+	for i := range pods.Items {
+		p := &pods.Items[i]
+		if p.Status.Phase != corev1.PodRunning || !podReady(p) {
+			continue
+		}
+		if podPort, ok := targetPort(p, svcPort); ok {
+			return p.Name, podPort, nil
+		}
+	}
+	return "", 0, fmt.Errorf("no ready pod serves %s/%s port %d", namespace, service, port)
+}
+
+func podReady(p *corev1.Pod) bool {
+	for _, c := range p.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// targetPort resolves the pod port of a service port, looking up named
+// target ports in the pod's containers.
+func targetPort(p *corev1.Pod, sp *corev1.ServicePort) (int, bool) {
+	tp := sp.TargetPort
+	if tp.StrVal == "" {
+		if tp.IntVal == 0 {
+			return int(sp.Port), true
+		}
+		return int(tp.IntVal), true
+	}
+	for _, c := range p.Spec.Containers {
+		for _, cp := range c.Ports {
+			if cp.Name == tp.StrVal {
+				return int(cp.ContainerPort), true
+			}
+		}
+	}
+	return 0, false
+}
@@ -9,7 +9,7 @@ import (
 
 func (c *Client) AddProcessor(name, content, processorType, description string) (err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	if processorType == "" {
 		processorType = "fpl_processor" // Default to JavaScript if not specified
@@ -26,33 +26,33 @@ func (c *Client) AddProcessor(name, content, processorType, description string)
 
 	if err != nil {
 		c.Logger.Error("failed to add processor", "error", err)
-		return fmt.Errorf("failed to add processor: %s", err.Error())
+		return fmt.Errorf("failed to add processor: %w", err)
 	}
 	return nil
 }
 
 func (c *Client) DeleteProcessor(name string) (err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	err = platformService.DeleteProcessor(name)
 
 	if err != nil {
 		c.Logger.Error("failed to delete processor", "name", name, "error", err)
-		return fmt.Errorf("failed to delete processor %s: %s", name, err.Error())
+		return fmt.Errorf("failed to delete processor %s: %w", name, err)
 	}
 	return nil
 }
 
 func (c *Client) ListProcessor() (entries []*model.FPLScript, err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	entries, err = platformService.ListProcessors()
 
 	if err != nil {
 		c.Logger.Error("failed to list processor", "error", err)
-		return nil, fmt.Errorf("failed to list processor: %s", err.Error())
+		return nil, fmt.Errorf("failed to list processor: %w", err)
 	}
 	return entries, nil
 }
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 
 	ingextAPI "github.com/SecurityDo/ingext_api/api"
@@ -21,7 +22,7 @@ func getParserType(processor_type string) (string, error) {
 
 func (c *Client) ImportProcessor(processor_type string, repoName string) (err error) {
 
-	repoService := ingextAPI.NewRepoService(c.ingextClient)
+	repoService := ingextAPI.NewRepoService(c.ingextClient).WithContext(c.context())
 
 	resp, err := repoService.ListRepos()
 
@@ -70,17 +71,25 @@ func (c *Client) ImportProcessor(processor_type string, repoName string) (err er
 			files = append(files, *file.Path)
 		}
 	}
-	err = repoService.ImportRepoProcessors(repoID, files)
-	if err != nil {
-		c.Logger.Error("failed to import repo processors", "error", err)
-		return fmt.Errorf("failed to import repo processors: %w", err)
+	// One call per processor, run as a batch, so a bad processor does not
+	// fail the whole import.
+	var errs []error
+	for i, res := range repoService.ImportRepoProcessorsBatch(repoID, files, c.batchOptions) {
+		if res.Err != nil {
+			c.Logger.Error("failed to import repo processor", "path", files[i], "error", res.Err)
+			errs = append(errs, fmt.Errorf("%s: %w", files[i], res.Err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to import %d of %d repo processors: %w", len(errs), len(files), errors.Join(errs...))
 	}
+	c.Logger.Info("imported repo processors", "count", len(files))
 	return nil
 }
 
 func (c *Client) ImportAppTemplate(repoName string) (err error) {
 
-	repoService := ingextAPI.NewRepoService(c.ingextClient)
+	repoService := ingextAPI.NewRepoService(c.ingextClient).WithContext(c.context())
 
 	resp, err := repoService.ListRepos()
 
@@ -133,7 +142,7 @@ func (c *Client) ImportAppTemplate(repoName string) (err error) {
 
 func (c *Client) ImportLakeSchemas(repoName string) (err error) {
 
-	repoService := ingextAPI.NewRepoService(c.ingextClient)
+	repoService := ingextAPI.NewRepoService(c.ingextClient).WithContext(c.context())
 
 	resp, err := repoService.ListRepos()
 
@@ -10,7 +10,7 @@ import (
 func (c *Client) ResourceSearch(resourceType string, customer string) (resp *model.LakeSearchResponse, err error) {
 
 	fmt.Printf("Searching for resource type '%s' and customer '%s'...\n", resourceType, customer)
-	service := ingextAPI.NewResourceService(c.ingextClient)
+	service := ingextAPI.NewResourceService(c.ingextClient).WithContext(c.context())
 
 	resp, err = service.Search(resourceType, customer)
 
@@ -9,7 +9,7 @@ import (
 
 func (c *Client) KQLSearch(kql string) (resp *kqlModel.KQLSearchResponse, err error) {
 
-	service := ingextAPI.NewSearchService(c.ingextClient)
+	service := ingextAPI.NewSearchService(c.ingextClient).WithContext(c.context())
 
 	resp, err = service.KQLSearch(kql)
 
@@ -22,7 +22,7 @@ func (c *Client) KQLSearch(kql string) (resp *kqlModel.KQLSearchResponse, err er
 
 // KQLValidate parses a KQL query on the search service without executing it.
 func (c *Client) KQLValidate(kql string) (*ingextAPI.KQLValidateResponse, error) {
-	service := ingextAPI.NewSearchService(c.ingextClient)
+	service := ingextAPI.NewSearchService(c.ingextClient).WithContext(c.context())
 	resp, err := service.KQLValidate(kql)
 	if err != nil {
 		c.Logger.Error("kql validate error", "error", err)
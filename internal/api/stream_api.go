@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/SecurityDo/ingext_api/api"
@@ -10,117 +11,139 @@ import (
 
 func (c *Client) AddDataSource(source *model.DataSourceConfig) (resp *ingextAPI.AddDataSourceResponse, err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	resp, err = platformService.AddDataSource(source)
 
 	if err != nil {
 		c.Logger.Error("failed to add data source", "error", err)
-		return nil, fmt.Errorf("failed to add data source: %s", err.Error())
+		return nil, fmt.Errorf("failed to add data source: %w", err)
 	}
 	return resp, nil
 }
 
 func (c *Client) DeleteDataSource(id string) (err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	err = platformService.DeleteDataSource(id)
 
 	if err != nil {
 		c.Logger.Error("failed to delete data source", "error", err)
-		return fmt.Errorf("failed to delete data source: %s", err.Error())
+		return fmt.Errorf("failed to delete data source: %w", err)
 	}
 	return nil
 }
 
+// DeleteDataSources deletes several data sources as a batch (see
+// SetBatchOptions). It returns the ids deleted and an error listing the ids
+// that could not be deleted.
+func (c *Client) DeleteDataSources(ids []string) (deleted []string, err error) {
+
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
+
+	var errs []error
+	for i, res := range platformService.DeleteDataSources(ids, c.batchOptions) {
+		if res.Err != nil {
+			c.Logger.Error("failed to delete data source", "id", ids[i], "error", res.Err)
+			errs = append(errs, fmt.Errorf("%s: %w", ids[i], res.Err))
+			continue
+		}
+		deleted = append(deleted, ids[i])
+	}
+	if len(errs) > 0 {
+		return deleted, fmt.Errorf("failed to delete %d of %d data sources: %w", len(errs), len(ids), errors.Join(errs...))
+	}
+	return deleted, nil
+}
+
 func (c *Client) ListDataSource() (entries []*model.DataSourceConfig, err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	entries, err = platformService.ListDataSource()
 
 	if err != nil {
 		c.Logger.Error("failed to list data source", "error", err)
-		return nil, fmt.Errorf("failed to list data source: %s", err.Error())
+		return nil, fmt.Errorf("failed to list data source: %w", err)
 	}
 	return entries, nil
 }
 
 func (c *Client) AddDataSink(sink *model.DataSinkConfig) (resp *ingextAPI.AddDataSinkResponse, err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	resp, err = platformService.AddDataSink(sink)
 
 	if err != nil {
 		c.Logger.Error("failed to add data sink", "error", err)
-		return nil, fmt.Errorf("failed to add data sink: %s", err.Error())
+		return nil, fmt.Errorf("failed to add data sink: %w", err)
 	}
 	return resp, nil
 }
 
 func (c *Client) DeleteDataSink(id string) (err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	err = platformService.DeleteDataSink(id)
 
 	if err != nil {
 		c.Logger.Error("failed to delete data sink", "error", err)
-		return fmt.Errorf("failed to delete data sink: %s", err.Error())
+		return fmt.Errorf("failed to delete data sink: %w", err)
 	}
 	return nil
 }
 
 func (c *Client) ListDataSink() (entries []*model.DataSinkConfig, err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	entries, err = platformService.ListDataSink()
 
 	if err != nil {
 		c.Logger.Error("failed to list data sink", "error", err)
-		return nil, fmt.Errorf("failed to list data sink: %s", err.Error())
+		return nil, fmt.Errorf("failed to list data sink: %w", err)
 	}
 	return entries, nil
 }
 
 func (c *Client) AddRouter(routerConfig *model.RouterConfig) (id string, err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	resp, err := platformService.AddRouter(routerConfig)
 
 	if err != nil {
 		c.Logger.Error("failed to add router", "error", err)
-		return "", fmt.Errorf("failed to add router: %s", err.Error())
+		return "", fmt.Errorf("failed to add router: %w", err)
 	}
 	return resp.ID, nil
 }
 
 func (c *Client) AddSimpleRouter(processorName string, routerName string) (id string, err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	id, err = platformService.AddSimpleRouter(processorName, routerName)
 
 	if err != nil {
 		c.Logger.Error("failed to add router", "error", err)
-		return "", fmt.Errorf("failed to add router: %s", err.Error())
+		return "", fmt.Errorf("failed to add router: %w", err)
 	}
 	return id, nil
 }
 
 func (c *Client) SetRouterSink(routerID, sinkID string) (err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	result, err := platformService.GetRouter(routerID)
 
 	if err != nil {
 		c.Logger.Error("failed to get router by ID", "error", err)
-		return fmt.Errorf("failed get router by ID: %s", err.Error())
+		return fmt.Errorf("failed get router by ID: %w", err)
 	}
 	if len(result.Pipes) == 0 {
 		c.Logger.Error("router has no pipes", "routerID", routerID)
@@ -144,7 +167,7 @@ func (c *Client) SetRouterSink(routerID, sinkID string) (err error) {
 	err = platformService.UpdatePipe(req)
 	if err != nil {
 		c.Logger.Error("failed to update pipe with new sink", "error", err)
-		return fmt.Errorf("failed to update pipe with new sink: %s", err.Error())
+		return fmt.Errorf("failed to update pipe with new sink: %w", err)
 	}
 
 	return nil
@@ -152,7 +175,7 @@ func (c *Client) SetRouterSink(routerID, sinkID string) (err error) {
 
 func (c *Client) UpdatePipeProcessor(routerName, pipeName, processorName string) (err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	err = platformService.UpdatePipeProcessor(&api.PipeProcessorUpdateReq{
 		RouterName:    routerName,
@@ -162,14 +185,14 @@ func (c *Client) UpdatePipeProcessor(routerName, pipeName, processorName string)
 
 	if err != nil {
 		c.Logger.Error("failed to update pipe processor", "error", err)
-		return fmt.Errorf("failed to update pipe processor: %s", err.Error())
+		return fmt.Errorf("failed to update pipe processor: %w", err)
 	}
 	return nil
 }
 
 func (c *Client) SetSourceRouter(sourceID, routerID string) (err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	err = platformService.SetDataSourceRouter(&api.SourceSetRouterReq{
 		DataSourceID: sourceID,
@@ -178,7 +201,7 @@ func (c *Client) SetSourceRouter(sourceID, routerID string) (err error) {
 
 	if err != nil {
 		c.Logger.Error("failed to connect source to router", "error", err)
-		return fmt.Errorf("failed to connect source to router: %s", err.Error())
+		return fmt.Errorf("failed to connect source to router: %w", err)
 	}
 	return nil
 }
@@ -186,13 +209,13 @@ func (c *Client) SetSourceRouter(sourceID, routerID string) (err error) {
 /*
 func (c *Client) AddPipe(routerConfig *model.StreamPipeConfig) (id string, err error) {
 
-	platformService := ingextAPI.NewPlatformService(c.ingextClient)
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
 
 	resp, err := platformService.AddRouter(routerConfig)
 
 	if err != nil {
 		c.Logger.Error("failed to add router", "error", err)
-		return "", fmt.Errorf("failed to add router: %s", err.Error())
+		return "", fmt.Errorf("failed to add router: %w", err)
 	}
 	return resp.ID, nil
 }*/
@@ -8,7 +8,7 @@ import (
 )
 
 func (c *Client) RegisterSyslogConfig(ports []string) (*model.GetSyslogConfigResponse, error) {
-	service := ingextAPI.NewSyslogService(c.ingextClient)
+	service := ingextAPI.NewSyslogService(c.ingextClient).WithContext(c.context())
 
 	resp, err := service.Register(ports)
 	if err != nil {
@@ -19,7 +19,7 @@ func (c *Client) RegisterSyslogConfig(ports []string) (*model.GetSyslogConfigRes
 }
 
 func (c *Client) UpdateSyslogConfig(ports []string) (*model.GetSyslogConfigResponse, error) {
-	service := ingextAPI.NewSyslogService(c.ingextClient)
+	service := ingextAPI.NewSyslogService(c.ingextClient).WithContext(c.context())
 
 	resp, err := service.Update(ports)
 	if err != nil {
@@ -30,7 +30,7 @@ func (c *Client) UpdateSyslogConfig(ports []string) (*model.GetSyslogConfigRespo
 }
 
 func (c *Client) GetSyslogConfig() (*model.GetSyslogConfigResponse, error) {
-	service := ingextAPI.NewSyslogService(c.ingextClient)
+	service := ingextAPI.NewSyslogService(c.ingextClient).WithContext(c.context())
 
 	resp, err := service.Get()
 	if err != nil {
@@ -41,7 +41,7 @@ func (c *Client) GetSyslogConfig() (*model.GetSyslogConfigResponse, error) {
 }
 
 func (c *Client) DeleteSyslogConfig() error {
-	service := ingextAPI.NewSyslogService(c.ingextClient)
+	service := ingextAPI.NewSyslogService(c.ingextClient).WithContext(c.context())
 
 	if err := service.Delete(); err != nil {
 		c.Logger.Error("delete syslog config error", "error", err)
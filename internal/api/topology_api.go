@@ -0,0 +1,50 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	ingextAPI "github.com/SecurityDo/ingext_api/api"
+	"github.com/SecurityDo/ingext_api/internal/topology"
+)
+
+// StreamGraph builds the graph of the stream pipeline of the site. With a
+// window, each component is annotated with its throughput over the last
+// window; metrics that cannot be fetched are logged and left out.
+func (c *Client) StreamGraph(window time.Duration) (g *topology.Graph, err error) {
+
+	platformService := ingextAPI.NewPlatformService(c.ingextClient).WithContext(c.context())
+
+	configs, err := platformService.ListConfigs()
+	if err != nil {
+		c.Logger.Error("failed to list stream configs", "error", err)
+		return nil, fmt.Errorf("failed to list stream configs: %w", err)
+	}
+	g = topology.Build(configs)
+	if window <= 0 {
+		return g, nil
+	}
+
+	to := time.Now().UTC()
+	from := to.Add(-window)
+	nodes := g.Components()
+	reqs := make([]*ingextAPI.ComponentMetricReq, len(nodes))
+	for i, n := range nodes {
+		reqs[i] = &ingextAPI.ComponentMetricReq{
+			Component: string(n.Kind),
+			ID:        n.ComponentID,
+			From:      from.Format(time.RFC3339),
+			To:        to.Format(time.RFC3339),
+			Interval:  topology.MetricInterval(window),
+		}
+	}
+	resps, results := platformService.ComponentMetricsBatch(reqs, c.batchOptions)
+	for i, res := range results {
+		if res.Err != nil {
+			c.Logger.Warn("failed to get component metrics", "kind", nodes[i].Kind, "name", nodes[i].Name, "error", res.Err)
+			continue
+		}
+		nodes[i].SetThroughput(resps[i].Metrics, window)
+	}
+	return g, nil
+}
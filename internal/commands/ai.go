@@ -25,7 +25,11 @@ var aiRegisterCmd = &cobra.Command{
 	Short: "Add an API token on the GPT API (role admin)",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// API expects name and description; CLI uses --account and --display-name.
-		token, err := AppAPI.GPTAIAuthService(aiURL, aiToken).AddToken(aiRegisterAccount, aiRegisterDisplayName, "tenant")
+		authService, err := AppAPI.GPTAIAuthService(aiURL, aiToken)
+		if err != nil {
+			return err
+		}
+		token, err := authService.AddToken(aiRegisterAccount, aiRegisterDisplayName, "tenant")
 		if err != nil {
 			cmd.PrintErrf("Error registering GPT AI token: %s %v\n", aiRegisterAccount, err)
 			return err
@@ -39,7 +43,11 @@ var aiUnregisterCmd = &cobra.Command{
 	Use:   "unregister",
 	Short: "Delete an API token on the GPT API",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		err := AppAPI.GPTAIAuthService(aiURL, aiToken).DeleteToken(aiUnregisterAccount)
+		authService, err := AppAPI.GPTAIAuthService(aiURL, aiToken)
+		if err != nil {
+			return err
+		}
+		err = authService.DeleteToken(aiUnregisterAccount)
 		if err != nil {
 			cmd.PrintErrf("Error unregistering GPT AI token: %s %v\n", aiUnregisterAccount, err)
 			return err
@@ -32,15 +32,16 @@ var appTemplateListCmd = &cobra.Command{
 			return err
 		}
 
-		if len(templates) == 0 {
-			cmd.PrintErrf("No application template found.")
-			return nil
-		}
-
-		for _, entry := range templates {
-			cmd.PrintErrf("Name: %s, Description: %s\n", entry.Name, entry.Description)
-		}
-		return nil
+		return printList(cmd, templates, listOutput[*model.ApplicationTemplateConfig]{
+			columns: []column[*model.ApplicationTemplateConfig]{
+				{header: "NAME", value: func(e *model.ApplicationTemplateConfig) string { return e.Name }},
+				{header: "DESCRIPTION", value: func(e *model.ApplicationTemplateConfig) string { return e.Description }},
+				{header: "DISPLAY NAME", wide: true, value: func(e *model.ApplicationTemplateConfig) string { return e.DisplayName }},
+				{header: "CATEGORY", wide: true, value: func(e *model.ApplicationTemplateConfig) string { return e.Category }},
+			},
+			name:  func(e *model.ApplicationTemplateConfig) string { return e.Name },
+			empty: "No application template found.",
+		})
 	},
 }
 
@@ -114,17 +115,25 @@ var appInstanceGetCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		// Sensitive values are never displayed.
+		outputs := make([]*model.InputParameter, 0, len(resp.Outputs))
 		for _, output := range resp.Outputs {
 			if output.Sensitive {
 				cmd.PrintErrf("Output %s is sensitive and cannot be displayed\n", output.Name)
-				continue
+				masked := *output
+				masked.Value = ""
+				output = &masked
 			}
-			cmd.Printf("Output %s: %s\n", output.Name, output.Value)
+			outputs = append(outputs, output)
 		}
-
-		//cmd.PrintErrf("Application %s:%s added successfully: ", appName, instanceName)
-		//cmd.Println(id)
-		return nil
+		return printList(cmd, outputs, listOutput[*model.InputParameter]{
+			columns: []column[*model.InputParameter]{
+				{header: "OUTPUT", value: func(o *model.InputParameter) string { return o.Name }},
+				{header: "VALUE", value: func(o *model.InputParameter) string { return o.Value }},
+				{header: "DESCRIPTION", wide: true, value: func(o *model.InputParameter) string { return o.Description }},
+			},
+			name: func(o *model.InputParameter) string { return o.Name },
+		})
 	},
 }
 
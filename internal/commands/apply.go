@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/SecurityDo/ingext_api/internal/manifest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	applyFile  string
+	applyPrune bool
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Create or update a pipeline from a manifest",
+	Long: `Apply a YAML manifest describing integrations, processors, sinks, channels,
+routers with their pipes, and sources by name. It can also hold schemas,
+datalakes with their indexes, notification endpoints, application templates and
+the syslog listeners of the site.
+
+Names are resolved to IDs against the manifest and the site. Resources missing
+from the site are created, those with a field differing from the manifest are
+updated, and the others are left unchanged, so applying a manifest again is a
+no-op. Resources and fields the manifest does not mention are kept, unless
+--prune is given: then the sources, routers, pipes, channels and sinks of the
+site missing from the manifest are deleted, except those it refers to. Use
+'ingext diff' or --dry-run to review the changes first.
+
+The manifest can be a directory: its *.yaml files are read as one manifest.
+
+  version: 1
+  processors:
+    - name: parse-web
+      scriptFile: parse-web.fpl
+  sinks:
+    - name: lake
+      type: dataLake
+      dataLake: {datalake: managed, datalakeIndex: web}
+  routers:
+    - name: web
+      pipes:
+        - name: main
+          processors: [parse-web]
+          sinks: [lake]
+  sources:
+    - name: web-hec
+      type: hec
+      router: web`,
+	Example: `  ingext apply -f pipeline.yaml
+  ingext apply -f pipeline.yaml --dry-run
+  ingext apply -f ./pipelines --prune -o json
+  cat pipeline.yaml | ingext apply -f -`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := loadManifest(cmd, applyFile)
+		if err != nil {
+			return err
+		}
+		dryRun := viper.GetBool("dry-run")
+		results, err := AppAPI.ApplyManifest(m, manifest.Options{DryRun: dryRun, Prune: applyPrune})
+		if perr := printApplyResults(cmd, results, dryRun, nil); perr != nil && err == nil {
+			err = perr
+		}
+		return err
+	},
+}
+
+// loadManifest reads the manifest file or directory at path, or stdin for "-".
+func loadManifest(cmd *cobra.Command, path string) (*manifest.Manifest, error) {
+	if path == "" {
+		return nil, fmt.Errorf("--filename is required")
+	}
+	if path != "-" {
+		return manifest.Load(path)
+	}
+	b, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from stdin: %w", err)
+	}
+	return manifest.Parse(b, ".")
+}
+
+// printApplyResults prints the result of each resource applied and a summary
+// on stderr. exportedIDs, from a snapshot, adds the ID each resource had on
+// the exported site.
+func printApplyResults(cmd *cobra.Command, results []*manifest.Result, dryRun bool, exportedIDs map[string]string) error {
+	suffix := ""
+	if dryRun {
+		suffix = " (dry run)"
+	}
+	counts := map[manifest.Action]int{}
+	for _, r := range results {
+		counts[r.Action]++
+	}
+	columns := []column[*manifest.Result]{
+		{header: "KIND", value: func(r *manifest.Result) string { return r.Kind }},
+		{header: "NAME", value: func(r *manifest.Result) string { return r.Name }},
+		{header: "ACTION", value: func(r *manifest.Result) string { return string(r.Action) + suffix }},
+		{header: "ID", wide: exportedIDs == nil, value: func(r *manifest.Result) string { return r.ID }},
+	}
+	if exportedIDs != nil {
+		columns = append(columns, column[*manifest.Result]{
+			header: "EXPORTED ID",
+			value:  func(r *manifest.Result) string { return exportedIDs[r.Kind+"/"+r.Name] },
+		})
+	}
+	err := printList(cmd, results, listOutput[*manifest.Result]{
+		columns: columns,
+		name:    func(r *manifest.Result) string { return r.Kind + "/" + r.Name },
+	})
+	if len(results) > 0 {
+		cmd.PrintErrf("%d created, %d configured, %d unchanged, %d deleted%s.\n",
+			counts[manifest.Created], counts[manifest.Configured], counts[manifest.Unchanged], counts[manifest.Deleted], suffix)
+	}
+	return err
+}
+
+func init() {
+	RootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().StringVarP(&applyFile, "filename", "f", "", "manifest file or directory, or - for stdin")
+	applyCmd.Flags().BoolVar(&applyPrune, "prune", false, "delete the sources, routers, pipes, channels and sinks of the site that are not in the manifest")
+}
@@ -4,10 +4,10 @@ import (
 	//"fmt"
 
 	"fmt"
-	"strings"
+	"strconv"
 
+	"github.com/SecurityDo/ingext_api/model"
 	"github.com/spf13/cobra"
-	//"github.com/SecurityDo/ingext_api/model"
 )
 
 var (
@@ -66,10 +66,18 @@ var userListCmd = &cobra.Command{
 			cmd.PrintErrf("Error listing user: %v\n", err)
 			return err
 		}
-		for _, user := range users {
-			cmd.Printf("User: %s, Display Name: %s, Role: %s, Org: %s\n", user.Username, user.FirstName, strings.Join(user.Roles, ","), user.Organization)
-		}
-		return nil
+		return printList(cmd, users, listOutput[*model.UserEntry]{
+			columns: []column[*model.UserEntry]{
+				{header: "USER", value: func(u *model.UserEntry) string { return u.Username }},
+				{header: "DISPLAY NAME", value: func(u *model.UserEntry) string { return u.FirstName }},
+				{header: "ROLES", value: func(u *model.UserEntry) string { return joined(u.Roles) }},
+				{header: "ORG", value: func(u *model.UserEntry) string { return u.Organization }},
+				{header: "EMAIL", wide: true, value: func(u *model.UserEntry) string { return u.Email }},
+				{header: "OAUTH", wide: true, value: func(u *model.UserEntry) string { return u.OAuthProvider }},
+			},
+			name:  func(u *model.UserEntry) string { return u.Username },
+			empty: "No user found.",
+		})
 	},
 }
 
@@ -112,10 +120,18 @@ var tokenListCmd = &cobra.Command{
 			cmd.PrintErrf("Error listing tokens: %v\n", err)
 			return err
 		}
-		for _, token := range tokens {
-			cmd.PrintErrf("Token: %s, Display Name: %s, Role: %s\n", token.Name, token.Description, strings.Join(token.Roles, ","))
-		}
-		return nil
+		return printList(cmd, tokens, listOutput[*model.ApiTokenEntry]{
+			columns: []column[*model.ApiTokenEntry]{
+				{header: "TOKEN", value: func(t *model.ApiTokenEntry) string { return t.Name }},
+				{header: "DISPLAY NAME", value: func(t *model.ApiTokenEntry) string { return t.Description }},
+				{header: "ROLES", value: func(t *model.ApiTokenEntry) string { return joined(t.Roles) }},
+				{header: "ID", wide: true, value: func(t *model.ApiTokenEntry) string { return t.ID }},
+				{header: "DISABLED", wide: true, value: func(t *model.ApiTokenEntry) string { return strconv.FormatBool(t.Disabled) }},
+				{header: "CREATED", wide: true, value: func(t *model.ApiTokenEntry) string { return timestamp(t.CreatedOn) }},
+			},
+			name:  func(t *model.ApiTokenEntry) string { return t.Name },
+			empty: "No token found.",
+		})
 	},
 }
 
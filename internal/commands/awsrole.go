@@ -2,7 +2,10 @@ package commands
 
 import (
 	"fmt"
+	"io"
+	"strconv"
 
+	"github.com/SecurityDo/ingext_api/model"
 	"github.com/spf13/cobra"
 )
 
@@ -31,8 +34,14 @@ var getPodRoleCmd = &cobra.Command{
 		}
 
 		cmd.PrintErrln("Get Pod Role: ", role, " ARN: ", arn)
-		fmt.Println(role)
-		return nil
+		out := struct {
+			Role string `json:"role"`
+			ARN  string `json:"arn"`
+		}{role, arn}
+		return printObject(cmd, out, func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, role)
+			return err
+		})
 	},
 }
 
@@ -116,15 +125,18 @@ var listAssumedRoleCmd = &cobra.Command{
 			return err
 		}
 		cmd.PrintErrln("Listing AWS Roles...")
-		if len(roles) == 0 {
-			cmd.Println("No roles found.")
-			return nil
-		}
-
-		for _, role := range roles {
-			cmd.PrintErrf("Role ID: %s, Name: %s, ARN: %s, External ID: %s\n", role.ID, role.DisplayName, role.RoleARN, role.ExternalID)
-		}
-		return nil
+		return printList(cmd, roles, listOutput[*model.InstanceRole]{
+			columns: []column[*model.InstanceRole]{
+				{header: "ROLE ID", value: func(r *model.InstanceRole) string { return r.ID }},
+				{header: "NAME", value: func(r *model.InstanceRole) string { return r.DisplayName }},
+				{header: "ARN", value: func(r *model.InstanceRole) string { return r.RoleARN }},
+				{header: "EXTERNAL ID", value: func(r *model.InstanceRole) string { return r.ExternalID }},
+				{header: "LOCAL", wide: true, value: func(r *model.InstanceRole) string { return strconv.FormatBool(r.Local) }},
+				{header: "CREATED", wide: true, value: func(r *model.InstanceRole) string { return timestamp(r.CreatedOn) }},
+			},
+			name:  func(r *model.InstanceRole) string { return r.ID },
+			empty: "No roles found.",
+		})
 	},
 }
 
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	callPrefix      string
+	callKargs       string
+	callAttachments []string
+	callDownloadDir string
+)
+
+var callCmd = &cobra.Command{
+	Use:   "call <function>",
+	Short: "Call an API function directly, with optional file attachments",
+	Long: `Call an API function with JSON arguments and print the JSON response
+(or YAML etc. with -o), with secret values masked.
+
+Files given with --attach are uploaded with the call as attachments (e.g. a
+large lookup file), instead of being inlined in the arguments. Attachments
+returned by the server (e.g. an FPL report export) are saved in --download-dir
+after their hash is checked.
+
+Examples:
+  ingext call get_system_status
+  ingext call lookup_upload --kargs '{"name":"assets"}' --attach ./assets.csv
+  ingext call export_report --kargs @args.json --download-dir ./exports`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		kargs := strings.TrimSpace(callKargs)
+		if strings.HasPrefix(kargs, "@") {
+			data, err := os.ReadFile(kargs[1:])
+			if err != nil {
+				return fmt.Errorf("read kargs file %s: %w", kargs[1:], err)
+			}
+			kargs = strings.TrimSpace(string(data))
+		}
+		if kargs != "" && !json.Valid([]byte(kargs)) {
+			return fmt.Errorf("--kargs is not valid JSON")
+		}
+		if callDownloadDir != "" {
+			if err := os.MkdirAll(callDownloadDir, 0o755); err != nil {
+				return fmt.Errorf("create download dir: %w", err)
+			}
+		}
+
+		res, err := AppAPI.Call(callPrefix, args[0], json.RawMessage(kargs), callAttachments, callDownloadDir)
+		if err != nil {
+			return err
+		}
+
+		if err := printObject(cmd, res.Response, nil); err != nil {
+			return err
+		}
+
+		for _, a := range res.Attachments {
+			if a.Path != "" {
+				cmd.PrintErrf("Saved attachment %s to %s\n", a.Name, a.Path)
+			} else {
+				cmd.PrintErrf("Attachment %s not downloaded (use --download-dir)\n", a.Name)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	callCmd.Flags().StringVar(&callPrefix, "prefix", "api/ds", "API prefix of the function (api/ds, api/auth, api/grid)")
+	callCmd.Flags().StringVar(&callKargs, "kargs", "", "JSON arguments, or @file to read them from a file")
+	callCmd.Flags().StringArrayVar(&callAttachments, "attach", nil, "file to upload as an attachment (repeatable)")
+	callCmd.Flags().StringVar(&callDownloadDir, "download-dir", "", "directory where response attachments are saved")
+	RootCmd.AddCommand(callCmd)
+}
@@ -1,9 +1,10 @@
 package commands
 
 import (
-	"encoding/json"
 	"fmt"
+	"strconv"
 
+	"github.com/SecurityDo/ingext_api/model"
 	"github.com/spf13/cobra"
 )
 
@@ -22,14 +23,16 @@ var collectorListCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		if len(entries) == 0 {
-			cmd.PrintErrln("No collectors found.")
-			return nil
-		}
-		for _, e := range entries {
-			cmd.Printf("Name: %s, LastPoll: %d\n", e.Name, e.LastPoll)
-		}
-		return nil
+		return printList(cmd, entries, listOutput[*model.CollectorForWeb]{
+			columns: []column[*model.CollectorForWeb]{
+				{header: "NAME", value: func(e *model.CollectorForWeb) string { return e.Name }},
+				{header: "LAST POLL", value: func(e *model.CollectorForWeb) string { return strconv.FormatInt(e.LastPoll, 10) }},
+				{header: "ID", wide: true, value: func(e *model.CollectorForWeb) string { return e.ID }},
+				{header: "DESCRIPTION", wide: true, value: func(e *model.CollectorForWeb) string { return e.Description }},
+			},
+			name:  func(e *model.CollectorForWeb) string { return e.Name },
+			empty: "No collectors found.",
+		})
 	},
 }
 
@@ -47,12 +50,7 @@ var collectorStatusCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		b, err := json.MarshalIndent(out, "", "  ")
-		if err != nil {
-			return err
-		}
-		cmd.Printf("%s\n", b)
-		return nil
+		return printObject(cmd, out, nil)
 	},
 }
 
@@ -87,6 +87,9 @@ var configAddCmd = &cobra.Command{
 		}
 		viper.Set(prefix+"context", confContext)
 
+		// Save connection settings (--ca-file, --insecure, ...) given on the command line
+		saveProfileConnectionFlags(cmd, prefix)
+
 		// 4. Write to disk
 		if err := config.SaveConfig(); err != nil {
 			fmt.Printf("Error saving config: %v\n", err)
@@ -106,10 +109,6 @@ var configListCmd = &cobra.Command{
 		// GetStringMap returns map[string]interface{}
 		clusters := viper.GetStringMap("clusters")
 
-		w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "CURRENT\tPROFILE\tCLUSTER\tNAMESPACE\tPROVIDER")
-		fmt.Fprintln(w, "-------\t-------\t-------\t---------\t--------")
-
 		// Sort keys for consistent output
 		var keys []string
 		for k := range clusters {
@@ -117,6 +116,7 @@ var configListCmd = &cobra.Command{
 		}
 		sort.Strings(keys)
 
+		profiles := make([]*profileEntry, 0, len(keys))
 		for _, name := range keys {
 			// Extract details from the nested map
 			details, ok := clusters[name].(map[string]interface{})
@@ -124,31 +124,55 @@ var configListCmd = &cobra.Command{
 				continue
 			}
 
-			isCurrent := ""
-			if name == current {
-				isCurrent = "*"
-			}
-
 			// Parse composite key to extract cluster and namespace
-			clusterPart := name
-			namespacePart := ""
+			p := &profileEntry{Name: name, Cluster: name, Current: name == current}
 			if idx := strings.Index(name, ":"); idx >= 0 {
-				clusterPart = name[:idx]
-				namespacePart = name[idx+1:]
+				p.Cluster = name[:idx]
+				p.Namespace = name[idx+1:]
 			}
 
-			// Safe getter for provider
-			prov := ""
+			// Safe getters for provider and context
 			if v, ok := details["provider"]; ok {
-				prov = fmt.Sprintf("%v", v)
+				p.Provider = fmt.Sprintf("%v", v)
+			}
+			if v, ok := details["context"]; ok {
+				p.Context = fmt.Sprintf("%v", v)
 			}
+			profiles = append(profiles, p)
+		}
 
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", isCurrent, name, clusterPart, namespacePart, prov)
+		if err := printList(cmd, profiles, listOutput[*profileEntry]{
+			columns: []column[*profileEntry]{
+				{header: "CURRENT", value: func(p *profileEntry) string {
+					if p.Current {
+						return "*"
+					}
+					return ""
+				}},
+				{header: "PROFILE", value: func(p *profileEntry) string { return p.Name }},
+				{header: "CLUSTER", value: func(p *profileEntry) string { return p.Cluster }},
+				{header: "NAMESPACE", value: func(p *profileEntry) string { return p.Namespace }},
+				{header: "PROVIDER", value: func(p *profileEntry) string { return p.Provider }},
+				{header: "CONTEXT", wide: true, value: func(p *profileEntry) string { return p.Context }},
+			},
+			name:  func(p *profileEntry) string { return p.Name },
+			empty: "No profiles configured.",
+		}); err != nil {
+			cmd.PrintErrln("Error:", err)
 		}
-		w.Flush()
 	},
 }
 
+// profileEntry is a profile as listed by config list.
+type profileEntry struct {
+	Name      string `json:"name"`
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	Provider  string `json:"provider,omitempty"`
+	Context   string `json:"context,omitempty"`
+	Current   bool   `json:"current"`
+}
+
 // Subcommand: DELETE
 var configDeleteCmd = &cobra.Command{
 	Use:   "delete",
@@ -265,6 +289,9 @@ var configSetCmd = &cobra.Command{
 		}
 		viper.Set(prefix+"context", confContext)
 
+		// Save connection settings (--ca-file, --insecure, ...) given on the command line
+		saveProfileConnectionFlags(cmd, prefix)
+
 		// 4. Write to disk
 		if err := config.SaveConfig(); err != nil {
 			fmt.Printf("Error saving config: %v\n", err)
@@ -494,3 +521,19 @@ func init() {
 	// 2. Register 'view' as a child of 'config'
 	configCmd.AddCommand(configViewCmd)
 }*/
+
+// saveProfileConnectionFlags stores the connection flags explicitly set on cmd
+// under the profile prefix, so they apply whenever the profile is active.
+func saveProfileConnectionFlags(cmd *cobra.Command, prefix string) {
+	for _, key := range config.ProfileConnectionKeys {
+		flag := cmd.Flags().Lookup(key)
+		if flag == nil || !flag.Changed {
+			continue
+		}
+		if flag.Value.Type() == "bool" {
+			viper.Set(prefix+key, flag.Value.String() == "true")
+		} else {
+			viper.Set(prefix+key, flag.Value.String())
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SecurityDo/ingext_api/internal/api"
+	"github.com/SecurityDo/ingext_api/internal/config"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	tokenSiteURL string
+	tokenService string
+	tokenAccount string
+)
+
+// credentialProvider returns the chain of credential sources of the active
+// profile, or the default chain: env vars, site_credentials.json, Kubernetes.
+func credentialProvider(logger *slog.Logger) (api.CredentialProvider, error) {
+	specs, err := config.ProfileCredentials()
+	if err != nil {
+		return nil, err
+	}
+	if len(specs) == 0 {
+		specs = []config.CredentialSpec{
+			{Type: config.CredentialEnv},
+			{Type: config.CredentialFile},
+			{Type: config.CredentialKube},
+		}
+	}
+	chain := make(api.CredentialChain, 0, len(specs))
+	for _, spec := range specs {
+		chain = append(chain, newCredentialProvider(spec))
+	}
+	logger.Debug("resolving credentials", "sources", len(chain))
+	return chain, nil
+}
+
+func newCredentialProvider(spec config.CredentialSpec) api.CredentialProvider {
+	switch spec.Type {
+	case config.CredentialEnv:
+		return &api.EnvCredentials{SiteURL: spec.SiteURL}
+	case config.CredentialFile:
+		path, site := spec.Path, spec.Site
+		if path == "" {
+			path = defaultSiteConfigPath()
+		}
+		if site == "" {
+			site = viper.GetString("site")
+		}
+		if site == "" {
+			site = viper.GetString("default-site")
+		}
+		return &api.FileCredentials{Path: path, Site: site}
+	case config.CredentialKube:
+		p := AppAPI.KubeCredentials(viper.GetString("cluster"), viper.GetString("namespace"), viper.GetString("context"))
+		p.SecretName, p.SecretKey = spec.Secret, spec.Key
+		return p
+	case config.CredentialKeyring:
+		account := spec.Account
+		if account == "" {
+			account = keyringAccount()
+		}
+		return &api.KeyringCredentials{Service: spec.Service, Account: account, SiteURL: spec.SiteURL}
+	case config.CredentialExec:
+		return &api.ExecCredentials{Command: spec.Command, Args: spec.Args, Env: spec.Env, SiteURL: spec.SiteURL}
+	default: // config.CredentialAgentFile, checked by config.ProfileCredentials
+		return &api.AgentFileCredentials{Path: spec.Path, SiteURL: spec.SiteURL}
+	}
+}
+
+// defaultSiteConfigPath returns --site-config, or site_credentials.json in
+// the current directory.
+func defaultSiteConfigPath() string {
+	if path := viper.GetString("site-config"); path != "" {
+		return path
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		return filepath.Join(cwd, "site_credentials.json")
+	}
+	return ""
+}
+
+// keyringAccount is the default keyring account: the active profile name.
+func keyringAccount() string {
+	if current := viper.GetString("current-cluster"); current != "" {
+		return current
+	}
+	return "default"
+}
+
+// Subcommand: STORE-TOKEN
+var configStoreTokenCmd = &cobra.Command{
+	Use:   "store-token",
+	Short: "Store a site URL and token in the OS keyring",
+	Long: `Read an API token from stdin and store it, with the site URL, in the OS keyring
+(macOS Keychain, Windows Credential Manager, Secret Service on Linux).
+
+The entry is used by profiles whose credentials include a keyring source:
+
+  clusters:
+    prod:ingext:
+      credentials:
+        - type: keyring
+
+By default the entry is named after the current profile.`,
+	Example: `  ingext config store-token --site-url https://demo.example.com < token.txt
+  pass show ingext/prod | ingext config store-token --site-url https://prod.example.com --account prod:ingext`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if tokenSiteURL == "" {
+			return fmt.Errorf("--site-url is required")
+		}
+		if isTerminal(os.Stdin) {
+			cmd.PrintErr("Token: ")
+		}
+		line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		token := strings.TrimSpace(line)
+		if token == "" {
+			if err != nil {
+				return fmt.Errorf("failed to read the token from stdin: %w", err)
+			}
+			return fmt.Errorf("empty token")
+		}
+		account := tokenAccount
+		if account == "" {
+			account = keyringAccount()
+		}
+		if err := api.StoreKeyringCredentials(tokenService, account, &api.Credentials{SiteURL: tokenSiteURL, Token: token}); err != nil {
+			return fmt.Errorf("failed to store the token: %w", err)
+		}
+		cmd.PrintErrf("Stored credentials for '%s' in the OS keyring.\n", account)
+		return nil
+	},
+}
+
+// Subcommand: DELETE-TOKEN
+var configDeleteTokenCmd = &cobra.Command{
+	Use:   "delete-token",
+	Short: "Remove a token stored with store-token from the OS keyring",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		account := tokenAccount
+		if account == "" {
+			account = keyringAccount()
+		}
+		if err := api.DeleteKeyringCredentials(tokenService, account); err != nil {
+			return fmt.Errorf("failed to delete the token: %w", err)
+		}
+		cmd.PrintErrf("Deleted credentials for '%s' from the OS keyring.\n", account)
+		return nil
+	},
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func init() {
+	configCmd.AddCommand(configStoreTokenCmd)
+	configCmd.AddCommand(configDeleteTokenCmd)
+
+	configStoreTokenCmd.Flags().StringVar(&tokenSiteURL, "site-url", "", "site URL the token belongs to (e.g. https://demo.example.com)")
+	for _, c := range []*cobra.Command{configStoreTokenCmd, configDeleteTokenCmd} {
+		c.Flags().StringVar(&tokenService, "service", api.DefaultKeyringService, "keyring service name")
+		c.Flags().StringVar(&tokenAccount, "account", "", "keyring account (default: the current profile)")
+	}
+}
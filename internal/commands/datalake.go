@@ -3,8 +3,11 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"text/tabwriter"
+	"time"
 
 	"github.com/SecurityDo/ingext_api/model"
 	"github.com/spf13/cobra"
@@ -37,17 +40,17 @@ var lakeListCmd = &cobra.Command{
 			return err
 		}
 
-		if len(lakes) == 0 {
-			cmd.PrintErrf("No datalake found.")
-			return nil
-		}
-
-		for _, entry := range lakes {
-			cmd.PrintErrf("Name: %s, Description: %s\n", entry.Name, entry.StorageDescription)
-		}
-
-		//cmd.PrintErrln("Datalake added successfully")
-		return nil
+		return printList(cmd, lakes, listOutput[*model.Datalake]{
+			columns: []column[*model.Datalake]{
+				{header: "NAME", value: func(e *model.Datalake) string { return e.Name }},
+				{header: "DESCRIPTION", value: func(e *model.Datalake) string { return e.StorageDescription }},
+				{header: "MANAGED", wide: true, value: func(e *model.Datalake) string { return strconv.FormatBool(e.Managed) }},
+				{header: "INTEGRATION", wide: true, value: func(e *model.Datalake) string { return e.IntegrationID }},
+				{header: "CREATED", wide: true, value: func(e *model.Datalake) string { return e.CreatedAt }},
+			},
+			name:  func(e *model.Datalake) string { return e.Name },
+			empty: "No datalake found.",
+		})
 	},
 }
 
@@ -88,15 +91,16 @@ var lakeListIndexCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		if len(entries) == 0 {
-			cmd.Println("No datalake index found.")
-			return nil
-		}
-
-		for _, entry := range entries {
-			cmd.PrintErrf("Datalake: %s, Index: %s, Description: %s\n", entry.Datalake, entry.DatalakeIndex, entry.StorageDescription)
-		}
-		return nil
+		return printList(cmd, entries, listOutput[*model.DatalakeIndex]{
+			columns: []column[*model.DatalakeIndex]{
+				{header: "DATALAKE", value: func(e *model.DatalakeIndex) string { return e.Datalake }},
+				{header: "INDEX", value: func(e *model.DatalakeIndex) string { return e.DatalakeIndex }},
+				{header: "DESCRIPTION", value: func(e *model.DatalakeIndex) string { return e.StorageDescription }},
+				{header: "SCHEMA", wide: true, value: func(e *model.DatalakeIndex) string { return e.SchemaName }},
+			},
+			name:  func(e *model.DatalakeIndex) string { return e.DatalakeIndex },
+			empty: "No datalake index found.",
+		})
 
 	},
 }
@@ -143,61 +147,55 @@ var lakeListSchemaCmd = &cobra.Command{
 			return err
 		}
 
-		if schemaListJSON {
-			// Machine-readable output: dump each entry's name + description and
-			// the raw schema JSON from Content. stdout so downstream tools can
-			// parse it.
-			type entryOut struct {
-				Name        string          `json:"name"`
-				Description string          `json:"description,omitempty"`
-				Schema      json.RawMessage `json:"schema,omitempty"`
-			}
-			out := make([]entryOut, 0, len(schemas))
-			for _, entry := range schemas {
-				o := entryOut{Name: entry.Name, Description: entry.Description}
-				if entry.Content != "" {
-					o.Schema = json.RawMessage(entry.Content)
+		out := make([]*schemaListEntry, 0, len(schemas))
+		for _, entry := range schemas {
+			o := &schemaListEntry{Name: entry.Name, Description: entry.Description, updated: entry.UpdatedAt}
+			if entry.Content != "" {
+				o.Schema = json.RawMessage(entry.Content)
+				var table model.Table
+				if err := json.Unmarshal([]byte(entry.Content), &table); err != nil {
+					cmd.PrintErrf("Schema: %s (failed to decode: %v)\n", entry.Name, err)
+					o.Schema = nil
+				} else {
+					o.fields = len(table.Fields)
 				}
-				out = append(out, o)
 			}
-			enc := json.NewEncoder(cmd.OutOrStdout())
-			enc.SetIndent("", "  ")
-			return enc.Encode(out)
+			out = append(out, o)
 		}
-
-		if len(schemas) == 0 {
-			cmd.PrintErrln("No schemas found.")
-			return nil
+		if schemaListJSON {
+			// Deprecated alias of -o json.
+			return writeStructured(cmd.OutOrStdout(), outputFormat{format: outputJSON}, out)
 		}
+		return printList(cmd, out, listOutput[*schemaListEntry]{
+			columns: []column[*schemaListEntry]{
+				{header: "NAME", value: func(e *schemaListEntry) string { return e.Name }},
+				{header: "FIELDS", value: func(e *schemaListEntry) string { return strconv.Itoa(e.fields) }},
+				{header: "DESCRIPTION", value: func(e *schemaListEntry) string { return e.Description }},
+				{header: "UPDATED", wide: true, value: func(e *schemaListEntry) string { return timestamp(e.updated) }},
+			},
+			name:  func(e *schemaListEntry) string { return e.Name },
+			empty: "No schemas found.",
+		})
+	},
+}
 
-		for _, entry := range schemas {
-			var table model.Table
-			if err := json.Unmarshal([]byte(entry.Content), &table); err != nil {
-				cmd.PrintErrf("Schema: %s (failed to decode: %v)\n", entry.Name, err)
-				continue
-			}
+// schemaListEntry is a schema as listed by list-schema: the schema JSON from
+// Content is embedded rather than quoted.
+type schemaListEntry struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
 
-			cmd.PrintErrf("Schema: %s\n", entry.Name)
-			if entry.Description != "" {
-				cmd.PrintErrf("Description: %s\n", entry.Description)
-			}
-
-			w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
-			fmt.Fprintln(w, "NAME\tPHYSICAL TYPE\tLOGICAL TYPE\tNULLABLE")
-			fmt.Fprintln(w, "----\t-------------\t------------\t--------")
-			printFields(w, table.Fields, "")
-			w.Flush()
-			cmd.PrintErrln()
-		}
-		return nil
-	},
+	fields  int
+	updated time.Time
 }
 
 var lakeDescribeSchemaCmd = &cobra.Command{
 	Use:   "describe-schema",
 	Short: "Print one schema's full JSON",
 	Long: `Fetch one schema by name and print its full JSON content to stdout.
-Use this to feed a specific table's schema to downstream tools.`,
+Use this to feed a specific table's schema to downstream tools, or use
+-o table to list its fields.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if schemaName == "" {
 			return fmt.Errorf("--name is required")
@@ -219,9 +217,22 @@ Use this to feed a specific table's schema to downstream tools.`,
 				fmt.Fprintln(cmd.OutOrStdout(), entry.Content)
 				return nil
 			}
-			enc := json.NewEncoder(cmd.OutOrStdout())
-			enc.SetIndent("", "  ")
-			return enc.Encode(any)
+			// JSON unless a table is asked for explicitly.
+			var fieldTable func(w io.Writer) error
+			if f, _ := currentOutputFormat(); f.format != "" {
+				fieldTable = func(w io.Writer) error {
+					var table model.Table
+					if err := json.Unmarshal([]byte(entry.Content), &table); err != nil {
+						return fmt.Errorf("failed to decode schema %q: %w", schemaName, err)
+					}
+					tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+					fmt.Fprintln(tw, "NAME\tPHYSICAL TYPE\tLOGICAL TYPE\tNULLABLE")
+					fmt.Fprintln(tw, "----\t-------------\t------------\t--------")
+					printFields(tw, table.Fields, "")
+					return tw.Flush()
+				}
+			}
+			return printObject(cmd, any, fieldTable)
 		}
 		return fmt.Errorf("schema %q not found", schemaName)
 	},
@@ -276,7 +287,7 @@ func printFields(w *tabwriter.Writer, fields []*model.Field, prefix string) {
 func init() {
 	RootCmd.AddCommand(lakeCmd)
 	lakeCmd.AddCommand(lakeAddCmd, lakeListCmd, lakeAddIndexCmd, lakeListIndexCmd, lakeDeleteIndexCmd, lakeAddSchemaCmd, lakeListSchemaCmd, lakeDescribeSchemaCmd, lakeUpdateSchemaCmd, lakeDeleteSchemaCmd)
-	lakeListSchemaCmd.Flags().BoolVar(&schemaListJSON, "json", false, "emit all schemas as a JSON array on stdout")
+	lakeListSchemaCmd.Flags().BoolVar(&schemaListJSON, "json", false, "emit all schemas as a JSON array on stdout (same as -o json)")
 	lakeDescribeSchemaCmd.Flags().StringVar(&schemaName, "name", "", "schema name to describe")
 	_ = lakeDescribeSchemaCmd.MarkFlagRequired("name")
 	//lakeAddCmd.AddCommand(lakeAddIndexCmd)
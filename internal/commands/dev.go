@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/SecurityDo/ingext_api/fakeserver"
+	"github.com/spf13/cobra"
+)
+
+var (
+	devAddr  string
+	devToken string
+)
+
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Local development helpers",
+}
+
+var devServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run an in-memory fake Ingext site for offline testing",
+	Long: `Run an in-memory fake Ingext site implementing the api/ds and api/auth
+functions used by this CLI (data sources, sinks, routers, pipes, processors,
+integrations, users, tokens, schemas, canned KQL results). State is lost when
+the server stops. Point the CLI at it with:
+
+  INGEXT_SITE_URL=http://127.0.0.1:8080 INGEXT_TOKEN=dev ingext stream list-source`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		srv := fakeserver.New()
+		srv.Token = devToken
+
+		ln, err := net.Listen("tcp", devAddr)
+		if err != nil {
+			return err
+		}
+		httpServer := &http.Server{Handler: srv, ReadHeaderTimeout: 10 * time.Second}
+		cmd.PrintErrf("Fake Ingext site listening on http://%s (token %q); press Ctrl-C to stop\n", ln.Addr(), devToken)
+
+		ctx := cmd.Context()
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			httpServer.Shutdown(shutdownCtx)
+		}()
+		if err := httpServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(devCmd)
+	devCmd.AddCommand(devServeCmd)
+
+	devServeCmd.Flags().StringVar(&devAddr, "addr", "127.0.0.1:8080", "address to listen on")
+	devServeCmd.Flags().StringVar(&devToken, "token", "dev", "bearer token clients must send (empty accepts any)")
+}
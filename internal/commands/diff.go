@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/SecurityDo/ingext_api/client"
+	"github.com/SecurityDo/ingext_api/internal/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFile  string
+	diffPrune bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show what applying a manifest would change on the site",
+	Long: `Compare a manifest, or a directory of manifests such as an exported snapshot,
+with the live configuration of the site and print the plan 'ingext apply' would
+carry out: the resources to create (+), update (~) and, with --prune, delete (-),
+with the fields that differ. Nothing is changed on the site.
+
+With -o json or yaml the plan is printed as a list of resources, each with its
+action and field diffs. Secret values are masked.`,
+	Example: `  ingext diff -f pipeline.yaml
+  ingext diff -f ./snapshot --prune
+  ingext diff -f pipeline.yaml -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := loadManifest(cmd, diffFile)
+		if err != nil {
+			return err
+		}
+		results, err := AppAPI.ApplyManifest(m, manifest.Options{DryRun: true, Prune: diffPrune})
+		if err != nil {
+			return err
+		}
+
+		var changes []*manifest.Result
+		counts := map[manifest.Action]int{}
+		for _, r := range results {
+			counts[r.Action]++
+			if r.Action != manifest.Unchanged {
+				changes = append(changes, r)
+			}
+		}
+		f, err := currentOutputFormat()
+		if err != nil {
+			return err
+		}
+		if f.format == "" || f.format == outputTable || f.format == outputWide {
+			writePlan(cmd.OutOrStdout(), changes)
+		} else if err := printList(cmd, changes, listOutput[*manifest.Result]{
+			name: func(r *manifest.Result) string { return r.Kind + "/" + r.Name },
+		}); err != nil {
+			return err
+		}
+
+		if len(changes) == 0 {
+			cmd.PrintErrln("No changes: the site matches the manifest.")
+			return nil
+		}
+		cmd.PrintErrf("Plan: %d to create, %d to update, %d to delete, %d unchanged.\n",
+			counts[manifest.Created], counts[manifest.Configured], counts[manifest.Deleted], counts[manifest.Unchanged])
+		return nil
+	},
+}
+
+// planSymbols prefix the resources of a plan, like a unified diff.
+var planSymbols = map[manifest.Action]string{
+	manifest.Created:    "+",
+	manifest.Configured: "~",
+	manifest.Deleted:    "-",
+}
+
+// writePlan prints each change with its field diffs: "path: new" for a new
+// resource, "path: old -> new" for an update.
+func writePlan(w io.Writer, changes []*manifest.Result) {
+	for _, r := range changes {
+		fmt.Fprintf(w, "%s %s/%s\n", planSymbols[r.Action], r.Kind, r.Name)
+		for _, d := range r.Diff {
+			if r.Action == manifest.Created {
+				fmt.Fprintf(w, "    %s: %s\n", d.Path, planValue(d.New))
+				continue
+			}
+			fmt.Fprintf(w, "    %s: %s -> %s\n", d.Path, planValue(d.Old), planValue(d.New))
+		}
+	}
+}
+
+// planValue formats a field value as compact JSON with secrets masked; unset
+// values are shown as <unset>.
+func planValue(v interface{}) string {
+	if v == nil {
+		return "<unset>"
+	}
+	// Without HTML escaping, so that placeholder IDs print as <kind/name>.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	b := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	return string(client.DefaultRedactor().RedactJSON(b))
+}
+
+func init() {
+	RootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVarP(&diffFile, "filename", "f", "", "manifest file or directory, or - for stdin")
+	diffCmd.Flags().BoolVar(&diffPrune, "prune", false, "also plan the deletion of the sources, routers, pipes, channels and sinks of the site that are not in the manifest")
+}
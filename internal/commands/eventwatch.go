@@ -2,6 +2,8 @@ package commands
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/SecurityDo/ingext_api/model"
@@ -68,38 +70,61 @@ var eventwatchRuleSearchCmd = &cobra.Command{
 }
 
 func printEventwatchHits(cmd *cobra.Command, resp *model.ElasticSearchResult, sourceType string) error {
-	if resp.Hits == nil || len(resp.Hits.Hits) == 0 {
-		cmd.PrintErrln("No hits found.")
+	const empty = "No hits found."
+	switch sourceType {
+	case "BehaviorSummary":
+		return printList(cmd, decodeHits[model.BehaviorSummary](cmd, resp), listOutput[*model.BehaviorSummary]{
+			columns: []column[*model.BehaviorSummary]{
+				{header: "KEY", value: func(s *model.BehaviorSummary) string { return s.Key }},
+				{header: "RISK SCORE", value: func(s *model.BehaviorSummary) string { return strconv.Itoa(s.RiskScore) }},
+				{header: "KEY TYPE", wide: true, value: func(s *model.BehaviorSummary) string { return s.KeyType }},
+				{header: "COUNT", wide: true, value: func(s *model.BehaviorSummary) string { return strconv.Itoa(s.Count) }},
+			},
+			name:  func(s *model.BehaviorSummary) string { return s.Key },
+			empty: empty,
+		})
+	case "BehaviorEvent":
+		return printList(cmd, decodeHits[model.BehaviorEvent](cmd, resp), listOutput[*model.BehaviorEvent]{
+			columns: []column[*model.BehaviorEvent]{
+				{header: "KEY", value: func(e *model.BehaviorEvent) string { return e.Key }},
+				{header: "RISK SCORE", value: func(e *model.BehaviorEvent) string { return strconv.Itoa(e.RiskScore) }},
+				{header: "BEHAVIOR", wide: true, value: func(e *model.BehaviorEvent) string { return e.Behavior }},
+				{header: "RULE", wide: true, value: func(e *model.BehaviorEvent) string { return e.BehaviorRule }},
+			},
+			name:  func(e *model.BehaviorEvent) string { return e.Key },
+			empty: empty,
+		})
+	case "BehaviorRule":
+		return printList(cmd, decodeHits[model.EventWatchBucket](cmd, resp), listOutput[*model.EventWatchBucket]{
+			columns: []column[*model.EventWatchBucket]{
+				{header: "NAME", value: func(b *model.EventWatchBucket) string { return b.Name }},
+				{header: "GROUP", value: func(b *model.EventWatchBucket) string { return b.Group }},
+				{header: "REPOSITORY", value: func(b *model.EventWatchBucket) string { return b.Repository }},
+				{header: "DESCRIPTION", wide: true, value: func(b *model.EventWatchBucket) string { return b.Description }},
+			},
+			name:  func(b *model.EventWatchBucket) string { return b.Name },
+			empty: empty,
+		})
+	}
+	return fmt.Errorf("unknown source type %q", sourceType)
+}
+
+// decodeHits decodes the _source of the hits of resp, skipping those that
+// are not a T.
+func decodeHits[T any](cmd *cobra.Command, resp *model.ElasticSearchResult) []*T {
+	if resp.Hits == nil {
 		return nil
 	}
+	var out []*T
 	for _, hit := range resp.Hits.Hits {
-		switch sourceType {
-		case "BehaviorSummary":
-			var src model.BehaviorSummary
-			if err := json.Unmarshal(hit.Source, &src); err != nil {
-				cmd.PrintErrf("skip hit %s: invalid _source: %v\n", hit.ID, err)
-				continue
-			}
-			cmd.Printf("Key: %s, RiskScore: %d\n", src.Key, src.RiskScore)
-		case "BehaviorEvent":
-			var src model.BehaviorEvent
-			if err := json.Unmarshal(hit.Source, &src); err != nil {
-				cmd.PrintErrf("skip hit %s: invalid _source: %v\n", hit.ID, err)
-				continue
-			}
-			cmd.Printf("Key: %s, RiskScore: %d\n", src.Key, src.RiskScore)
-		case "BehaviorRule":
-			var src model.EventWatchBucket
-			if err := json.Unmarshal(hit.Source, &src); err != nil {
-				cmd.PrintErrf("skip hit %s: invalid _source: %v\n", hit.ID, err)
-				continue
-			}
-			cmd.Printf("Name: %s, Group: %s, Repository: %s\n", src.Name, src.Group, src.Repository)
-		default:
-			cmd.PrintErrf("skip hit %s: unknown source type %q\n", hit.ID, sourceType)
+		var src T
+		if err := json.Unmarshal(hit.Source, &src); err != nil {
+			cmd.PrintErrf("skip hit %s: invalid _source: %v\n", hit.ID, err)
+			continue
 		}
+		out = append(out, &src)
 	}
-	return nil
+	return out
 }
 
 func init() {
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"context"
+	"errors"
+
+	"github.com/SecurityDo/ingext_api/client"
+)
+
+// Process exit codes. Scripts can rely on these to tell failures apart.
+const (
+	ExitOK           = 0
+	ExitError        = 1   // any other failure (bad flags, local errors, ...)
+	ExitUnauthorized = 3   // token rejected or insufficient permissions
+	ExitNotFound     = 4   // the requested entity does not exist
+	ExitServerError  = 5   // server returned an ERROR/EXCEPTION verdict or HTTP 5xx
+	ExitUnavailable  = 6   // site unreachable or rate limited
+	ExitTimeout      = 124 // --timeout expired
+	ExitInterrupted  = 130 // cancelled by Ctrl-C / SIGTERM
+)
+
+// ExitCode maps an error returned by a command to a process exit code.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, context.DeadlineExceeded):
+		return ExitTimeout
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	}
+	switch client.ErrorKindOf(err) {
+	case client.KindUnauthorized:
+		return ExitUnauthorized
+	case client.KindNotFound:
+		return ExitNotFound
+	case client.KindError, client.KindException, client.KindHTTP, client.KindDecode:
+		return ExitServerError
+	case client.KindTransport, client.KindRateLimited:
+		return ExitUnavailable
+	}
+	return ExitError
+}
@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SecurityDo/ingext_api/internal/manifest"
+	"github.com/spf13/cobra"
+)
+
+var exportDir string
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Snapshot the configuration of the site to a directory",
+	Long: `Write the configuration of the site to a new directory of YAML manifests:
+integrations, schemas, datalakes and their indexes, processors, sinks, channels,
+routers with their pipes, sources, notification endpoints, application
+templates and syslog listeners. Resources refer to each other by name.
+
+  export.yaml        format version, site, export time, IDs on the site
+  site.yaml          schemas, datalakes, notification endpoints, syslog
+  pipeline.yaml      integrations, processors, sinks, channels, routers, sources
+  applications.yaml  application templates
+  processors/, schemas/, applications/   scripts, JSON schemas, templates
+
+Secret fields (tokens, passwords, integration secrets, ...) are left out and
+listed in export.yaml. Recreate the configuration on another site with
+'ingext import --from-dir', then set the secrets again.`,
+	Example: `  ingext export --to-dir ./snapshot
+  ingext --site staging.example.com import --from-dir ./snapshot`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := AppAPI.ExportManifest()
+		if err != nil {
+			return err
+		}
+		if err := manifest.Write(m, exportDir); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+		cmd.PrintErrf("Exported %s to %s.\n", exportSummary(m), exportDir)
+		printOmittedSecrets(cmd, m, nil, "Secret fields left out of the snapshot")
+		return nil
+	},
+}
+
+// exportSummary counts the resources of m by kind, e.g. "2 sinks, 1 router".
+func exportSummary(m *manifest.Manifest) string {
+	pipes := 0
+	for _, r := range m.Routers {
+		pipes += len(r.Pipes)
+	}
+	counts := []struct {
+		n    int
+		kind string
+	}{
+		{len(m.Integrations), "integration"},
+		{len(m.Schemas), "schema"},
+		{len(m.Datalakes), "datalake"},
+		{len(m.Processors), "processor"},
+		{len(m.Sinks), "sink"},
+		{len(m.Channels), "channel"},
+		{len(m.Routers), "router"},
+		{pipes, "pipe"},
+		{len(m.Sources), "source"},
+		{len(m.Notifications), "notification endpoint"},
+		{len(m.Applications), "application template"},
+	}
+	var parts []string
+	for _, c := range counts {
+		switch c.n {
+		case 0:
+		case 1:
+			parts = append(parts, "1 "+c.kind)
+		default:
+			parts = append(parts, fmt.Sprintf("%d %ss", c.n, c.kind))
+		}
+	}
+	if m.Syslog != nil {
+		parts = append(parts, "syslog listeners")
+	}
+	if len(parts) == 0 {
+		return "an empty configuration"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printOmittedSecrets lists on stderr the secret fields that were not
+// exported with m. With results, only those of the resources created are
+// listed.
+func printOmittedSecrets(cmd *cobra.Command, m *manifest.Manifest, results []*manifest.Result, title string) {
+	if m.Exported == nil {
+		return
+	}
+	created := map[string]bool{}
+	for _, r := range results {
+		if r.Action == manifest.Created {
+			created[r.Kind+"/"+r.Name] = true
+		}
+	}
+	var secrets []string
+	for _, s := range m.Exported.OmittedSecrets {
+		resource, _, _ := strings.Cut(s, ": ")
+		if results == nil || created[resource] {
+			secrets = append(secrets, s)
+		}
+	}
+	if len(secrets) == 0 {
+		return
+	}
+	cmd.PrintErrf("%s (%d):\n", title, len(secrets))
+	for _, s := range secrets {
+		cmd.PrintErrf("  %s\n", s)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVar(&exportDir, "to-dir", "", "directory to write the snapshot to; must be new or empty")
+	_ = exportCmd.MarkFlagRequired("to-dir")
+}
@@ -3,6 +3,7 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/SecurityDo/ingext_api/model"
@@ -54,8 +55,10 @@ var fplGetCmd = &cobra.Command{
 		if resp.Entry == nil {
 			return fmt.Errorf("task %d: no entry in response", fplID)
 		}
-		cmd.Printf("name: %s state: %s\n", resp.Entry.Name, resp.Entry.State)
-		return nil
+		return printObject(cmd, resp.Entry, func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, "name: %s state: %s\n", resp.Entry.Name, resp.Entry.State)
+			return err
+		})
 	},
 }
 
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/SecurityDo/ingext_api/model"
 	"github.com/spf13/cobra"
@@ -29,14 +30,18 @@ var listAccountCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		if len(resp.Accounts) == 0 {
-			cmd.Println("No accounts found.")
-			return nil
-		}
-		for _, acct := range resp.Accounts {
-			fmt.Printf("Name: %s, Region: %s, Cluster: %s, URL: %s\n", acct.Name, acct.Region, acct.Cluster, acct.URL)
-		}
-		return nil
+		return printList(cmd, resp.Accounts, listOutput[*model.FluencyAccount]{
+			columns: []column[*model.FluencyAccount]{
+				{header: "NAME", value: func(a *model.FluencyAccount) string { return a.Name }},
+				{header: "REGION", value: func(a *model.FluencyAccount) string { return a.Region }},
+				{header: "CLUSTER", value: func(a *model.FluencyAccount) string { return a.Cluster }},
+				{header: "URL", value: func(a *model.FluencyAccount) string { return a.URL }},
+				{header: "DISABLED", wide: true, value: func(a *model.FluencyAccount) string { return strconv.FormatBool(a.Disabled) }},
+				{header: "DESCRIPTION", wide: true, value: func(a *model.FluencyAccount) string { return a.Description }},
+			},
+			name:  func(a *model.FluencyAccount) string { return a.Name },
+			empty: "No accounts found.",
+		})
 	},
 }
 
@@ -1,17 +1,54 @@
 package commands
 
 import (
+	"github.com/SecurityDo/ingext_api/internal/manifest"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var (
 	repoName string
 	//procType    string // Default to "parser" if not specified
+	importDir string
 )
 
 var importCmd = &cobra.Command{
 	Use:   "import",
-	Short: "import resources from github repository",
+	Short: "import resources from github repository or a snapshot",
+	Long: `Import processors, application templates or schemas from a github repository
+with the subcommands, or recreate a snapshot written by 'ingext export' with
+--from-dir.
+
+A snapshot is applied like 'ingext apply -f <dir>': names are resolved to the IDs
+of this site, resources it lacks are created and those that differ are updated.
+The results list the ID each resource had on the exported site and its ID
+here. Secret fields left out of the snapshot must be set again afterwards.`,
+	Example: `  ingext import --from-dir ./snapshot
+  ingext import --from-dir ./snapshot --dry-run
+  ingext import processor --type fpl_processor`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if importDir == "" {
+			return cmd.Help()
+		}
+		m, err := manifest.Load(importDir)
+		if err != nil {
+			return err
+		}
+		dryRun := viper.GetBool("dry-run")
+		var exportedIDs map[string]string
+		if m.Exported != nil {
+			exportedIDs = m.Exported.IDs
+		}
+		results, err := AppAPI.ApplyManifest(m, manifest.Options{DryRun: dryRun})
+		if perr := printApplyResults(cmd, results, dryRun, exportedIDs); perr != nil && err == nil {
+			err = perr
+		}
+		if err == nil {
+			printOmittedSecrets(cmd, m, results, "Secret fields to set on the resources created")
+		}
+		return err
+	},
 }
 
 var importProcessorCmd = &cobra.Command{
@@ -64,4 +101,6 @@ func init() {
 
 	importProcessorCmd.Flags().StringVar(&procType, "type", "fpl_processor", "Processor type (fpl_processor|fpl_receiver|fpl_packer|fpl_report)")
 
+	importCmd.Flags().StringVar(&importDir, "from-dir", "", "snapshot directory written by 'ingext export'")
+
 }
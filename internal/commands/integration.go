@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/SecurityDo/ingext_api/model"
@@ -188,15 +189,18 @@ var integrationListCmd = &cobra.Command{
 			return err
 		}
 		cmd.PrintErrln("Listing Integration...")
-		if len(entries) == 0 {
-			cmd.PrintErrln("No integration found.")
-			return nil
-		}
-
-		for _, entry := range entries {
-			cmd.PrintErrf("ID: %s, Name: %s, Integration: %s, Description: %s\n", entry.ID, entry.Name, entry.Integration, entry.Description)
-		}
-		return nil
+		return printList(cmd, entries, listOutput[*model.Integration]{
+			columns: []column[*model.Integration]{
+				{header: "ID", value: func(e *model.Integration) string { return e.ID }},
+				{header: "NAME", value: func(e *model.Integration) string { return e.Name }},
+				{header: "INTEGRATION", value: func(e *model.Integration) string { return e.Integration }},
+				{header: "DESCRIPTION", value: func(e *model.Integration) string { return e.Description }},
+				{header: "IN USE", wide: true, value: func(e *model.Integration) string { return strconv.FormatBool(e.InUse) }},
+				{header: "UPDATED", wide: true, value: func(e *model.Integration) string { return timestamp(e.UpdatedOn) }},
+			},
+			name:  func(e *model.Integration) string { return e.ID },
+			empty: "No integration found.",
+		})
 	},
 }
 
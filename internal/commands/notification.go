@@ -1,9 +1,9 @@
 package commands
 
 import (
-	"encoding/json"
 	"fmt"
 
+	"github.com/SecurityDo/ingext_api/model"
 	"github.com/spf13/cobra"
 )
 
@@ -27,16 +27,15 @@ var notificationListCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		if len(endpoints) == 0 {
-			cmd.Println("No notification endpoints found.")
-			return nil
-		}
-		jsonBytes, err := json.MarshalIndent(endpoints, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal response: %w", err)
-		}
-		fmt.Println(string(jsonBytes))
-		return nil
+		return printList(cmd, endpoints, listOutput[*model.EndpointConfig]{
+			columns: []column[*model.EndpointConfig]{
+				{header: "NAME", value: func(e *model.EndpointConfig) string { return e.Name }},
+				{header: "INTEGRATION", value: func(e *model.EndpointConfig) string { return e.Integration }},
+				{header: "ACTION", value: func(e *model.EndpointConfig) string { return e.Action }},
+			},
+			name:  func(e *model.EndpointConfig) string { return e.Name },
+			empty: "No notification endpoints found.",
+		})
 	},
 }
 
@@ -0,0 +1,217 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/SecurityDo/ingext_api/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+// Output formats selected with -o/--output. jsonpath and go-template take
+// their expression after "=", e.g. -o jsonpath='{[*].id}'.
+const (
+	outputTable      = "table"
+	outputWide       = "wide"
+	outputJSON       = "json"
+	outputYAML       = "yaml"
+	outputName       = "name"
+	outputJSONPath   = "jsonpath"
+	outputGoTemplate = "go-template"
+)
+
+// outputFormat is the parsed value of -o/--output; an empty format is the
+// default of the command (a table for lists, JSON for single objects).
+type outputFormat struct {
+	format string
+	expr   string
+}
+
+func parseOutputFormat(value string) (outputFormat, error) {
+	format, expr, hasExpr := strings.Cut(value, "=")
+	switch format {
+	case "", outputTable, outputWide, outputJSON, outputYAML, outputName:
+		if hasExpr {
+			return outputFormat{}, fmt.Errorf("output format %q takes no expression", format)
+		}
+	case outputJSONPath, outputGoTemplate:
+		if expr == "" {
+			return outputFormat{}, fmt.Errorf("output format %s requires an expression: -o %s=<expression>", format, format)
+		}
+	default:
+		return outputFormat{}, fmt.Errorf("unknown output format %q (want json, yaml, table, wide, name, jsonpath=... or go-template=...)", value)
+	}
+	return outputFormat{format: format, expr: expr}, nil
+}
+
+// currentOutputFormat returns the format selected with -o/--output or INGEXT_OUTPUT.
+func currentOutputFormat() (outputFormat, error) {
+	return parseOutputFormat(viper.GetString("output"))
+}
+
+// column is a column of the table output of a list; wide columns are only
+// shown with -o wide.
+type column[T any] struct {
+	header string
+	wide   bool
+	value  func(T) string
+}
+
+// listOutput describes how the items of a list command are rendered.
+type listOutput[T any] struct {
+	columns []column[T]
+	// name returns the identifier printed by -o name.
+	name func(T) string
+	// empty is printed on stderr instead of an empty table.
+	empty string
+}
+
+// printList writes items to stdout in the selected output format. Structured
+// formats (json, yaml, jsonpath, go-template) see the items as a JSON array,
+// with secret values masked.
+func printList[T any](cmd *cobra.Command, items []T, out listOutput[T]) error {
+	f, err := currentOutputFormat()
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	switch f.format {
+	case "", outputTable, outputWide:
+		if len(items) == 0 {
+			if out.empty != "" {
+				cmd.PrintErrln(out.empty)
+			}
+			return nil
+		}
+		return writeTable(w, items, out.columns, f.format == outputWide)
+	case outputName:
+		for _, item := range items {
+			fmt.Fprintln(w, out.name(item))
+		}
+		return nil
+	}
+	if items == nil {
+		items = []T{}
+	}
+	return writeStructured(w, f, items)
+}
+
+// printObject writes a single object to stdout in the selected output
+// format. table renders it by default and for -o table and -o wide; without
+// it, the object is printed as JSON instead.
+func printObject(cmd *cobra.Command, v interface{}, table func(w io.Writer) error) error {
+	f, err := currentOutputFormat()
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	switch f.format {
+	case "", outputTable, outputWide:
+		if table != nil {
+			return table(w)
+		}
+		f.format = outputJSON
+	case outputName:
+		return fmt.Errorf("output format name is not supported by %s", cmd.CommandPath())
+	}
+	return writeStructured(w, f, v)
+}
+
+func writeTable[T any](w io.Writer, items []T, columns []column[T], wide bool) error {
+	var shown []column[T]
+	for _, c := range columns {
+		if wide || !c.wide {
+			shown = append(shown, c)
+		}
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	headers := make([]string, len(shown))
+	rules := make([]string, len(shown))
+	for i, c := range shown {
+		headers[i] = c.header
+		rules[i] = strings.Repeat("-", len(c.header))
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	fmt.Fprintln(tw, strings.Join(rules, "\t"))
+	for _, item := range items {
+		values := make([]string, len(shown))
+		for i, c := range shown {
+			values[i] = c.value(item)
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
+
+// writeStructured renders v as JSON, YAML, a JSONPath expression or a Go
+// template. v is encoded to JSON first, so field names are the JSON keys.
+func writeStructured(w io.Writer, f outputFormat, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	b = client.DefaultRedactor().RedactJSON(b)
+
+	switch f.format {
+	case outputJSON:
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, b, "", "  "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err := buf.WriteTo(w)
+		return err
+	case outputYAML:
+		y, err := yaml.JSONToYAML(b)
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		_, err = w.Write(y)
+		return err
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+	switch f.format {
+	case outputJSONPath:
+		expr := f.expr
+		if !strings.HasPrefix(expr, "{") {
+			expr = "{" + expr + "}"
+		}
+		jp := jsonpath.New("output").AllowMissingKeys(true)
+		if err := jp.Parse(expr); err != nil {
+			return fmt.Errorf("invalid jsonpath expression: %w", err)
+		}
+		return jp.Execute(w, data)
+	default: // outputGoTemplate
+		tmpl, err := template.New("output").Option("missingkey=zero").Parse(f.expr)
+		if err != nil {
+			return fmt.Errorf("invalid go-template: %w", err)
+		}
+		return tmpl.Execute(w, data)
+	}
+}
+
+// joined formats a list cell.
+func joined(values []string) string {
+	return strings.Join(values, ",")
+}
+
+// timestamp formats a time cell; the zero time is left blank.
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
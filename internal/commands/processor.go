@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 
+	"github.com/SecurityDo/ingext_api/model"
 	"github.com/spf13/cobra"
 )
 
@@ -24,20 +26,23 @@ var listProcessorCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all processors",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("Listing processors...")
+		cmd.PrintErrln("Listing processors...")
 
 		entries, err := AppAPI.ListProcessor()
 		if err != nil {
 			return err
 		}
-		if len(entries) == 0 {
-			cmd.PrintErrln("No processor found.")
-			return nil
-		}
-		for _, entry := range entries {
-			cmd.PrintErrf("Name: %s, Type: %s\n", entry.Name, entry.Type)
-		}
-		return nil
+		return printList(cmd, entries, listOutput[*model.FPLScript]{
+			columns: []column[*model.FPLScript]{
+				{header: "NAME", value: func(e *model.FPLScript) string { return e.Name }},
+				{header: "TYPE", value: func(e *model.FPLScript) string { return e.Type }},
+				{header: "ID", wide: true, value: func(e *model.FPLScript) string { return strconv.FormatInt(e.ID, 10) }},
+				{header: "UPDATED", wide: true, value: func(e *model.FPLScript) string { return timestamp(e.UpdatedOn) }},
+				{header: "DESCRIPTION", wide: true, value: func(e *model.FPLScript) string { return e.Description }},
+			},
+			name:  func(e *model.FPLScript) string { return e.Name },
+			empty: "No processor found.",
+		})
 	},
 }
 
@@ -1,20 +1,12 @@
 package commands
 
 import (
-	"encoding/json"
-	"fmt"
-
 	"github.com/spf13/cobra"
 )
 
 var resourceType string
 var customer string
 
-func PrettyPrintJSON(x interface{}) {
-	pretty, _ := json.MarshalIndent(x, "", "   ")
-	fmt.Printf("%s\n", pretty)
-}
-
 var resourceCmd = &cobra.Command{
 	Use:   "resource ",
 	Short: "Resource search",
@@ -27,9 +19,7 @@ var resourceCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		PrettyPrintJSON(resp)
-
-		return nil
+		return printObject(cmd, resp, nil)
 	},
 }
 
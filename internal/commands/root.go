@@ -1,12 +1,17 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
-	"path/filepath"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/SecurityDo/ingext_api/client"
 	"github.com/SecurityDo/ingext_api/internal/api"
 
 	"github.com/SecurityDo/ingext_api/internal/config"
@@ -25,6 +30,39 @@ var (
 
 	siteConfig string
 	site       string
+
+	callTimeout   time.Duration
+	retries       int
+	retryMutating bool
+
+	caFile     string
+	clientCert string
+	clientKey  string
+	insecure   bool
+
+	noCompression bool
+	gzipRequests  bool
+
+	batchConcurrency int
+	batchRate        float64
+
+	rateLimit   float64
+	maxInFlight int
+
+	proxyURL      string
+	transportMode string
+
+	// outputValue is -o/--output (see output.go).
+	outputValue string
+
+	// dryRun holds back every call that would change the site.
+	dryRun bool
+
+	// recordPath/replayPath enable cassette recording or replay (hidden flags).
+	recordPath string
+	replayPath string
+	// cancelTimeout releases the --timeout context once the command returns.
+	cancelTimeout context.CancelFunc = func() {}
 )
 
 const (
@@ -60,6 +98,11 @@ var RootCmd = &cobra.Command{
 			return nil
 		}
 
+		// Reject a bad -o/--output before making any call.
+		if _, err := currentOutputFormat(); err != nil {
+			return err
+		}
+
 		// 1. Skip initialization for commands that don't need it (like 'config' or 'help')
 		if cmd.Parent() != nil && cmd.Parent().Name() == "config" {
 			return nil
@@ -67,6 +110,10 @@ var RootCmd = &cobra.Command{
 		if cmd.Name() == "config" {
 			return nil
 		}
+		// 'dev serve' runs a local fake site and needs no connection.
+		if cmd.Parent() != nil && cmd.Parent().Name() == "dev" {
+			return nil
+		}
 		//if cmd.Name() == "version" {
 		//	return nil
 		//}
@@ -79,9 +126,6 @@ var RootCmd = &cobra.Command{
 		}
 
 		// 2. Load values from Viper (which now holds flags + config file values)
-		clusterName := viper.GetString("cluster")
-		namespace := viper.GetString("namespace")
-
 		levelValue := viper.GetString("log-level")
 
 		// 1. Configure the Handler options
@@ -101,7 +145,10 @@ var RootCmd = &cobra.Command{
 		}
 
 		// 2. Create the Handler pointing to STDERR
-		handler := slog.NewTextHandler(cmd.ErrOrStderr(), opts)
+		var handler slog.Handler = slog.NewTextHandler(cmd.ErrOrStderr(), opts)
+		if viper.GetBool("dry-run") {
+			handler = dryRunHandler{handler}
+		}
 
 		// 3. Create the Logger
 		logger := slog.New(handler)
@@ -109,61 +156,108 @@ var RootCmd = &cobra.Command{
 		// 4. Inject into your Client
 		AppAPI = api.NewClient(logger)
 
+		// Bind every RPC call to the command context (cancelled on SIGINT/SIGTERM)
+		// and to --timeout when set.
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if timeout := viper.GetDuration("timeout"); timeout > 0 {
+			ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+		}
+		AppAPI.SetContext(ctx)
+
+		// Retry transient failures (429/502/503/504, connection resets) for
+		// read-only calls; mutating calls only with --retry-mutating.
+		retryPolicy := client.DefaultRetryPolicy()
+		retryPolicy.MaxAttempts = viper.GetInt("retries") + 1
+		retryPolicy.RetryMutating = viper.GetBool("retry-mutating")
+		AppAPI.SetRetryPolicy(retryPolicy)
+
+		if viper.GetBool("no-compression") {
+			AppAPI.AddClientOptions(client.WithCompression(false))
+		} else if viper.GetBool("gzip-requests") {
+			AppAPI.AddClientOptions(client.WithRequestCompression(client.DefaultRequestCompressionThreshold))
+		}
+		AppAPI.AddClientOptions(
+			client.WithUserAgent("ingext-cli/"+appVersion),
+			// Tag calls with X-Request-Id and log mutating calls at info level.
+			client.WithInterceptors(client.NewRequestIDInterceptor(), client.NewAuditInterceptor(logger)),
+		)
+		// --dry-run: mutating calls fail with client.ErrDryRun instead of
+		// being sent; apply and diff plan without making any.
+		if viper.GetBool("dry-run") {
+			AppAPI.AddClientOptions(client.WithInterceptors(client.NewDryRunInterceptor()))
+		}
+
+		// Transport: call the site URL directly, or tunnel through a
+		// Kubernetes port-forward (Kubernetes profiles only).
+		if err := AppAPI.SetTransport(viper.GetString("transport")); err != nil {
+			return err
+		}
+
+		// Proxy: --proxy, INGEXT_PROXY or the profile; otherwise the site
+		// setting or HTTPS_PROXY/NO_PROXY.
+		AppAPI.SetProxy(viper.GetString("proxy"))
+
+		// Client-side throttling, shared by every call of this process.
+		AppAPI.SetRateLimit(&client.RateLimit{
+			Rate:        viper.GetFloat64("rate-limit"),
+			MaxInFlight: viper.GetInt("max-in-flight"),
+		})
+
+		// Bulk operations (multi-ID deletes, imports) fan out with bounded concurrency.
+		AppAPI.SetBatchOptions(&client.BatchOptions{
+			Concurrency: viper.GetInt("concurrency"),
+			Rate:        viper.GetFloat64("batch-rate"),
+		})
+
+		// TLS: verify the site certificate unless --insecure; flags, INGEXT_* env
+		// vars and the active profile override per-site settings in site_credentials.json.
+		AppAPI.SetTLSConfig(&client.TLSConfig{
+			CAFile:             viper.GetString("ca-file"),
+			CertFile:           viper.GetString("client-cert"),
+			KeyFile:            viper.GetString("client-key"),
+			InsecureSkipVerify: viper.GetBool("insecure"),
+		})
+
 		// ai register / unregister: no cluster/site/env; URL and token come from flags on the ai command.
 		if IsAiTokenCommand(cmd) {
 			return nil
 		}
 
-		// 5. Initialize the Global API — three modes in priority order:
+		// --record / --replay: capture or serve every call from a cassette file
+		// (e.g. to attach a reproducible trace to a bug report).
+		if recordPath != "" && replayPath != "" {
+			return fmt.Errorf("--record and --replay are mutually exclusive")
+		}
+		if recordPath != "" {
+			AppAPI.AddClientOptions(client.WithRecord(recordPath))
+		}
+		if replayPath != "" {
+			// No site needed: answers come from the cassette.
+			AppAPI.AddClientOptions(client.WithReplay(replayPath))
+			if err := AppAPI.InitDirect("http://replay.invalid", ""); err != nil {
+				return fmt.Errorf("failed to initialize replay: %w", err)
+			}
+			AppAPI.SetDebug(strings.ToLower(levelValue) == "debug")
+			return nil
+		}
+
+		// 5. Initialize the Global API from the first credential source that
+		//    has credentials: the sources of the active profile, or by default
 		//    a) INGEXT_SITE_URL + INGEXT_TOKEN env vars (direct connect, no k8s)
 		//    b) site_credentials.json file
 		//    c) Kubernetes cluster
-
-		envSiteURL := os.Getenv("INGEXT_SITE_URL")
-		envToken := os.Getenv("INGEXT_TOKEN")
-
-		if envSiteURL != "" && envToken != "" {
-			// Mode (a): direct connect via environment variables
-			AppAPI.InitDirect(envSiteURL, envToken)
-			logger.Info("initialized ingext client from env vars", "siteURL", envSiteURL)
-		} else {
-			siteConfigPath := viper.GetString("site-config")
-			siteName := viper.GetString("site")
-			if siteName == "" {
-				siteName = viper.GetString("default-site")
-			}
-			// Resolve site-config path: if not set, default to site_credentials.json in cwd
-			if siteConfigPath == "" {
-				if cwd, err := os.Getwd(); err == nil {
-					siteConfigPath = filepath.Join(cwd, "site_credentials.json")
-				}
-			}
-
-			useSiteConfig := false
-			if siteConfigPath != "" {
-				if _, err := os.Stat(siteConfigPath); err == nil {
-					useSiteConfig = true
-				}
-			}
-
-			if useSiteConfig {
-				// Mode (b): site_credentials.json
-				if err := AppAPI.InitFromSiteConfig(siteConfigPath, siteName); err != nil {
-					return fmt.Errorf("failed to initialize from site config: %w", err)
-				}
-			} else {
-				// Mode (c): Kubernetes
-				if clusterName == "" {
-					return fmt.Errorf("cluster name is required. Set INGEXT_SITE_URL + INGEXT_TOKEN env vars, place site_credentials.json in the current directory, or use --cluster")
-				}
-				kubeCtx := viper.GetString("context")
-				if kubeCtx == "" {
-					logger.Warn("no kube-context specified in config, using current system default")
-				}
-				if err := AppAPI.Init(clusterName, namespace, kubeCtx); err != nil {
-					return fmt.Errorf("failed to initialize app API: %w", err)
-				}
+		provider, err := credentialProvider(logger)
+		if err != nil {
+			return err
+		}
+		if err := AppAPI.InitWithCredentials(provider); err != nil {
+			if errors.Is(err, api.ErrNoCredentials) {
+				return fmt.Errorf("no credentials found. Set INGEXT_SITE_URL + INGEXT_TOKEN env vars, place site_credentials.json in the current directory, use --cluster, or configure credentials for the profile: %w", err)
 			}
+			return fmt.Errorf("failed to initialize app API: %w", err)
 		}
 
 		// 6. Enable HTTP request/response debug dumps when log level is debug
@@ -177,13 +271,73 @@ var RootCmd = &cobra.Command{
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
-	if err := RootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	// Ctrl-C (or SIGTERM) cancels any in-flight RPC call instead of waiting
+	// for the HTTP client timeout.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := RootCmd.ExecuteContext(ctx)
+	AppAPI.Close()
+	cancelTimeout()
+	stop()
+	if errors.Is(err, client.ErrDryRun) {
+		// The command stopped at a change it was not allowed to make.
+		for _, call := range dryRunCalls(err) {
+			fmt.Fprintf(os.Stderr, "Dry run: would call %s/%s %s\n", call.Prefix, call.Function, call.Kargs)
+		}
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(ExitCode(err))
 	}
 
 }
 
+// dryRunHandler drops the error logs of calls held back by --dry-run, which
+// Execute reports instead.
+type dryRunHandler struct {
+	slog.Handler
+}
+
+func (h dryRunHandler) Handle(ctx context.Context, r slog.Record) error {
+	heldBack := false
+	r.Attrs(func(a slog.Attr) bool {
+		if err, ok := a.Value.Any().(error); ok && errors.Is(err, client.ErrDryRun) {
+			heldBack = true
+		}
+		return !heldBack
+	})
+	if heldBack {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h dryRunHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return dryRunHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h dryRunHandler) WithGroup(name string) slog.Handler {
+	return dryRunHandler{h.Handler.WithGroup(name)}
+}
+
+// dryRunCalls returns the calls held back by --dry-run found in err, which
+// holds several of them when a bulk operation was stopped.
+func dryRunCalls(err error) []*client.DryRunError {
+	switch e := err.(type) {
+	case *client.DryRunError:
+		return []*client.DryRunError{e}
+	case interface{ Unwrap() error }:
+		return dryRunCalls(e.Unwrap())
+	case interface{ Unwrap() []error }:
+		var calls []*client.DryRunError
+		for _, inner := range e.Unwrap() {
+			calls = append(calls, dryRunCalls(inner)...)
+		}
+		return calls
+	}
+	return nil
+}
+
 func init() {
 	cobra.OnInitialize(config.InitConfig)
 
@@ -194,13 +348,52 @@ func init() {
 	RootCmd.PersistentFlags().StringVar(&cluster, "cluster", "", "k8s cluster name")
 	RootCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "ingext", "namespace of the ingext app")
 	RootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "l", defaultLogLevel, "log level: debug, info, warn, error")
+	RootCmd.PersistentFlags().DurationVar(&callTimeout, "timeout", 0, "abort API calls after this duration (e.g. 30s, 5m); 0 means no limit")
+	RootCmd.PersistentFlags().IntVar(&retries, "retries", 2, "retry read-only API calls this many times on transient failures (429/502/503/504, connection resets); 0 disables retries")
+	RootCmd.PersistentFlags().BoolVar(&retryMutating, "retry-mutating", false, "also retry calls that modify state (may apply a change twice if a response is lost)")
+	RootCmd.PersistentFlags().StringVar(&caFile, "ca-file", "", "PEM bundle of additional CAs trusted when verifying the site certificate")
+	RootCmd.PersistentFlags().StringVar(&clientCert, "client-cert", "", "PEM client certificate for mutual TLS (requires --client-key)")
+	RootCmd.PersistentFlags().StringVar(&clientKey, "client-key", "", "PEM private key for --client-cert")
+	RootCmd.PersistentFlags().BoolVar(&insecure, "insecure", false, "skip TLS certificate verification (testing only)")
+	RootCmd.PersistentFlags().BoolVar(&noCompression, "no-compression", false, "disable gzip/deflate compression of requests and responses")
+	RootCmd.PersistentFlags().BoolVar(&gzipRequests, "gzip-requests", false, "gzip request bodies of 64 KiB or more (the site must accept Content-Encoding: gzip)")
+	RootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "do not change the site: apply and diff only plan, other commands print the first call that would change something and stop")
+	RootCmd.PersistentFlags().StringVarP(&outputValue, "output", "o", "", "output format of list/get commands: json, yaml, table, wide, name, jsonpath=<expr> or go-template=<template>")
+	RootCmd.PersistentFlags().StringVar(&transportMode, "transport", "direct", "how to reach the site of a Kubernetes profile: direct (site URL) or port-forward (tunnel to the api service through the Kubernetes API)")
+	RootCmd.PersistentFlags().StringVar(&proxyURL, "proxy", "", "proxy URL for site connections (http://, https://, socks5://; \"direct\" for none); default: HTTPS_PROXY/NO_PROXY")
+	RootCmd.PersistentFlags().Float64Var(&rateLimit, "rate-limit", 0, "maximum API requests per second sent to the site, retries included; 0 means no limit")
+	RootCmd.PersistentFlags().IntVar(&maxInFlight, "max-in-flight", 0, "maximum API requests in progress at once; 0 means no limit")
+	RootCmd.PersistentFlags().IntVar(&batchConcurrency, "concurrency", client.DefaultBatchConcurrency, "number of API calls run at once by bulk operations (multi-ID deletes, imports)")
+	RootCmd.PersistentFlags().Float64Var(&batchRate, "batch-rate", 0, "maximum API calls per second issued by bulk operations; 0 means no limit")
 	RootCmd.Flags().BoolVarP(&showVersion, "version", "v", false, "show version")
 	RootCmd.Version = appVersion
 	// Bind global flags to viper so they can be accessed anywhere
+	RootCmd.PersistentFlags().StringVar(&recordPath, "record", "", "record every API call to this cassette file (secrets masked)")
+	RootCmd.PersistentFlags().StringVar(&replayPath, "replay", "", "answer API calls from this cassette file instead of the site")
+	_ = RootCmd.PersistentFlags().MarkHidden("record")
+	_ = RootCmd.PersistentFlags().MarkHidden("replay")
+
 	viper.BindPFlag("site-config", RootCmd.PersistentFlags().Lookup("site-config"))
 	viper.BindPFlag("site", RootCmd.PersistentFlags().Lookup("site"))
 
 	viper.BindPFlag("cluster", RootCmd.PersistentFlags().Lookup("cluster"))
 	viper.BindPFlag("namespace", RootCmd.PersistentFlags().Lookup("namespace"))
 	viper.BindPFlag("log-level", RootCmd.PersistentFlags().Lookup("log-level"))
+	viper.BindPFlag("timeout", RootCmd.PersistentFlags().Lookup("timeout"))
+	viper.BindPFlag("retries", RootCmd.PersistentFlags().Lookup("retries"))
+	viper.BindPFlag("retry-mutating", RootCmd.PersistentFlags().Lookup("retry-mutating"))
+	viper.BindPFlag("ca-file", RootCmd.PersistentFlags().Lookup("ca-file"))
+	viper.BindPFlag("client-cert", RootCmd.PersistentFlags().Lookup("client-cert"))
+	viper.BindPFlag("client-key", RootCmd.PersistentFlags().Lookup("client-key"))
+	viper.BindPFlag("insecure", RootCmd.PersistentFlags().Lookup("insecure"))
+	viper.BindPFlag("no-compression", RootCmd.PersistentFlags().Lookup("no-compression"))
+	viper.BindPFlag("gzip-requests", RootCmd.PersistentFlags().Lookup("gzip-requests"))
+	viper.BindPFlag("output", RootCmd.PersistentFlags().Lookup("output"))
+	viper.BindPFlag("dry-run", RootCmd.PersistentFlags().Lookup("dry-run"))
+	viper.BindPFlag("transport", RootCmd.PersistentFlags().Lookup("transport"))
+	viper.BindPFlag("proxy", RootCmd.PersistentFlags().Lookup("proxy"))
+	viper.BindPFlag("rate-limit", RootCmd.PersistentFlags().Lookup("rate-limit"))
+	viper.BindPFlag("max-in-flight", RootCmd.PersistentFlags().Lookup("max-in-flight"))
+	viper.BindPFlag("concurrency", RootCmd.PersistentFlags().Lookup("concurrency"))
+	viper.BindPFlag("batch-rate", RootCmd.PersistentFlags().Lookup("batch-rate"))
 }
@@ -3,6 +3,7 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	model "github.com/SecurityDo/ingext_api/model"
 	"github.com/spf13/cobra"
@@ -147,18 +148,33 @@ var addSourceCmd = &cobra.Command{
 	},
 }
 
+var sourceIDs []string
+
 var delSourceCmd = &cobra.Command{
-	Use:   "del-source",
-	Short: "Delete a stream source",
+	Use:   "del-source [id...]",
+	Short: "Delete one or more stream sources",
+	Long: `Delete stream sources by ID, given with --id (repeatable or comma separated)
+or as arguments. Several sources are deleted concurrently (see --concurrency
+and --batch-rate).`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("Deleting stream datasource...")
+		ids := append(append([]string{}, sourceIDs...), args...)
+		if len(ids) == 0 {
+			return fmt.Errorf("at least one source ID is required (--id)")
+		}
+		cmd.PrintErrln("Deleting stream datasource...")
 
-		err := AppAPI.DeleteDataSource(resourceID)
-		if err != nil {
-			return err
+		if len(ids) == 1 {
+			if err := AppAPI.DeleteDataSource(ids[0]); err != nil {
+				return err
+			}
+			cmd.PrintErrln("Stream source deleted successfully: ", ids[0])
+			return nil
 		}
-		cmd.PrintErrln("Stream source deleted successfully: ", resourceID)
-		return nil
+		deleted, err := AppAPI.DeleteDataSources(ids)
+		for _, id := range deleted {
+			cmd.PrintErrln("Stream source deleted successfully: ", id)
+		}
+		return err
 	},
 }
 
@@ -166,20 +182,23 @@ var listSourceCmd = &cobra.Command{
 	Use:   "list-source",
 	Short: "List all stream sources",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("Listing stream datasource...")
+		cmd.PrintErrln("Listing stream datasource...")
 
 		entries, err := AppAPI.ListDataSource()
 		if err != nil {
 			return err
 		}
-		if len(entries) == 0 {
-			cmd.Println("No stream source found.")
-			return nil
-		}
-		for _, entry := range entries {
-			cmd.PrintErrf("ID: %s, Name: %s, Type: %s\n", entry.ID, entry.Name, entry.Type)
-		}
-		return nil
+		return printList(cmd, entries, listOutput[*model.DataSourceConfig]{
+			columns: []column[*model.DataSourceConfig]{
+				{header: "ID", value: func(e *model.DataSourceConfig) string { return e.ID }},
+				{header: "NAME", value: func(e *model.DataSourceConfig) string { return e.Name }},
+				{header: "TYPE", value: func(e *model.DataSourceConfig) string { return e.Type }},
+				{header: "FORMAT", wide: true, value: func(e *model.DataSourceConfig) string { return e.Format }},
+				{header: "COMPRESSION", wide: true, value: func(e *model.DataSourceConfig) string { return e.Compression }},
+			},
+			name:  func(e *model.DataSourceConfig) string { return e.ID },
+			empty: "No stream source found.",
+		})
 	},
 }
 
@@ -202,20 +221,22 @@ var listSinkCmd = &cobra.Command{
 	Use:   "list-sink",
 	Short: "List all stream sinks",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Println("Listing stream datasink...")
+		cmd.PrintErrln("Listing stream datasink...")
 
 		entries, err := AppAPI.ListDataSink()
 		if err != nil {
 			return err
 		}
-		if len(entries) == 0 {
-			cmd.Println("No stream sink found.")
-			return nil
-		}
-		for _, entry := range entries {
-			cmd.PrintErrf("ID: %s, Name: %s, Type: %s\n", entry.ID, entry.Name, entry.Type)
-		}
-		return nil
+		return printList(cmd, entries, listOutput[*model.DataSinkConfig]{
+			columns: []column[*model.DataSinkConfig]{
+				{header: "ID", value: func(e *model.DataSinkConfig) string { return e.ID }},
+				{header: "NAME", value: func(e *model.DataSinkConfig) string { return e.Name }},
+				{header: "TYPE", value: func(e *model.DataSinkConfig) string { return e.Type }},
+				{header: "FLUSH COUNT", wide: true, value: func(e *model.DataSinkConfig) string { return strconv.FormatInt(e.FlushCount, 10) }},
+			},
+			name:  func(e *model.DataSinkConfig) string { return e.ID },
+			empty: "No stream sink found.",
+		})
 	},
 }
 
@@ -297,8 +318,7 @@ func init() {
 	_ = addSinkCmd.MarkFlagRequired("sink-type")
 	_ = addSinkCmd.MarkFlagRequired("name")
 
-	delSourceCmd.Flags().StringVar(&resourceID, "id", "", "data source ID")
-	_ = delSourceCmd.MarkFlagRequired("id")
+	delSourceCmd.Flags().StringSliceVar(&sourceIDs, "id", nil, "data source ID (repeatable)")
 
 	delSinkCmd.Flags().StringVar(&resourceID, "id", "", "data sink ID")
 	_ = delSinkCmd.MarkFlagRequired("id")
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SecurityDo/ingext_api/internal/topology"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphFormat  string
+	graphMetrics bool
+	graphWindow  time.Duration
+	graphErrors  bool
+)
+
+var streamGraphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Show the stream pipeline as a graph",
+	Long: `Draw the stream pipeline of the site: sources, the routers they are connected
+to, the pipes of each router with their processors, and the channels and sinks
+the pipes write to. Components referred to but not configured are marked
+"not found".
+
+  --format tree      ASCII tree from each source (default)
+  --format dot       Graphviz digraph, e.g. piped to 'dot -Tsvg'
+  --format mermaid   Mermaid flowchart, e.g. for a Markdown document
+
+--metrics annotates each component with its mean throughput over --window,
+and --errors with its current error and alert counts. With -o json or yaml the
+graph is printed as its nodes and edges instead.`,
+	Example: `  ingext stream graph
+  ingext stream graph --metrics --window 1h --errors
+  ingext stream graph --format dot | dot -Tsvg > pipeline.svg
+  ingext stream graph --format mermaid > pipeline.mmd
+  ingext stream graph -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !slices.Contains(topology.Formats, graphFormat) {
+			return fmt.Errorf("unknown graph format %q (want one of %s)", graphFormat, strings.Join(topology.Formats, ", "))
+		}
+		if graphMetrics && graphWindow <= 0 {
+			return fmt.Errorf("--window must be positive")
+		}
+		var window time.Duration
+		if graphMetrics {
+			window = graphWindow
+		}
+		g, err := AppAPI.StreamGraph(window)
+		if err != nil {
+			return err
+		}
+		if !graphErrors {
+			g.ClearErrors()
+		}
+		return printObject(cmd, g, func(w io.Writer) error {
+			return topology.Render(w, g, graphFormat)
+		})
+	},
+}
+
+func init() {
+	streamCmd.AddCommand(streamGraphCmd)
+
+	streamGraphCmd.Flags().StringVar(&graphFormat, "format", "tree", "graph format: "+strings.Join(topology.Formats, ", "))
+	streamGraphCmd.Flags().BoolVar(&graphMetrics, "metrics", false, "annotate components with their throughput over --window")
+	streamGraphCmd.Flags().DurationVar(&graphWindow, "window", 15*time.Minute, "time window of the throughput shown by --metrics")
+	streamGraphCmd.Flags().BoolVar(&graphErrors, "errors", false, "annotate components with their error and alert counts")
+}
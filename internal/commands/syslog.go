@@ -1,7 +1,6 @@
 package commands
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -22,12 +21,7 @@ var syslogGetCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		jsonBytes, err := json.MarshalIndent(resp, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal response: %w", err)
-		}
-		fmt.Println(string(jsonBytes))
-		return nil
+		return printObject(cmd, resp, nil)
 	},
 }
 
@@ -39,12 +33,7 @@ var syslogRegisterCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		jsonBytes, err := json.MarshalIndent(resp, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal response: %w", err)
-		}
-		fmt.Println(string(jsonBytes))
-		return nil
+		return printObject(cmd, resp, nil)
 	},
 }
 
@@ -56,12 +45,7 @@ var syslogUpdateCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		jsonBytes, err := json.MarshalIndent(resp, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal response: %w", err)
-		}
-		fmt.Println(string(jsonBytes))
-		return nil
+		return printObject(cmd, resp, nil)
 	},
 }
 
@@ -17,6 +17,10 @@ type Config struct {
 	Namespace string `mapstructure:"namespace"`
 }
 
+// ProfileConnectionKeys are the per-profile settings (clusters.<profile>.<key>)
+// that mirror a global flag of the same name.
+var ProfileConnectionKeys = []string{"ca-file", "client-cert", "client-key", "insecure", "transport", "proxy", "rate-limit", "max-in-flight"}
+
 // InitConfig reads in config file and ENV variables if set.
 func InitConfig() {
 	home, err := os.UserHomeDir()
@@ -31,7 +35,10 @@ func InitConfig() {
 	viper.SetConfigType("yaml")
 	viper.SetConfigName("config")
 
-	viper.AutomaticEnv() // read in environment variables that match
+	// read in environment variables that match, e.g. INGEXT_CLUSTER, INGEXT_CA_FILE
+	viper.SetEnvPrefix("INGEXT")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.AutomaticEnv()
 
 	// If a config file is found, read it in.
 	_ = viper.ReadInConfig()
@@ -58,6 +65,12 @@ func InitConfig() {
 			if v := viper.GetString(prefix + "context"); v != "" {
 				viper.Set("context", v)
 			}
+			// Connection settings: defaults only, so flags and env vars still win
+			for _, key := range ProfileConnectionKeys {
+				if viper.IsSet(prefix + key) {
+					viper.SetDefault(key, viper.Get(prefix+key))
+				}
+			}
 			// Set cluster and namespace from the composite key
 			viper.Set("cluster", clusterPart)
 			if namespacePart != "" {
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// Credential source types of a CredentialSpec.
+const (
+	CredentialEnv       = "env"
+	CredentialFile      = "file"
+	CredentialKube      = "kube"
+	CredentialKeyring   = "keyring"
+	CredentialExec      = "exec"
+	CredentialAgentFile = "agent-file"
+)
+
+// CredentialSpec configures one credential source of a profile
+// (clusters.<profile>.credentials in config.yaml). Only the fields of its
+// Type apply; SiteURL is the site of sources that only provide a token.
+type CredentialSpec struct {
+	Type    string `mapstructure:"type"`
+	SiteURL string `mapstructure:"siteURL"`
+	// file: site_credentials.json path and site; agent-file: token file path.
+	Path string `mapstructure:"path"`
+	Site string `mapstructure:"site"`
+	// kube: secret holding the token (app-secret/token by default).
+	Secret string `mapstructure:"secret"`
+	Key    string `mapstructure:"key"`
+	// keyring: entry service (ingext by default) and account (the profile name by default).
+	Service string `mapstructure:"service"`
+	Account string `mapstructure:"account"`
+	// exec: command run to print the credentials.
+	Command string            `mapstructure:"command"`
+	Args    []string          `mapstructure:"args"`
+	Env     map[string]string `mapstructure:"env"`
+}
+
+// ProfileCredentials returns the credential sources of the active profile, in
+// resolution order, or nil if it configures none.
+func ProfileCredentials() ([]CredentialSpec, error) {
+	current := viper.GetString("current-cluster")
+	if current == "" {
+		return nil, nil
+	}
+	key := "clusters." + current + ".credentials"
+	if !viper.IsSet(key) {
+		return nil, nil
+	}
+	var specs []CredentialSpec
+	if err := viper.UnmarshalKey(key, &specs); err != nil {
+		return nil, fmt.Errorf("invalid credentials of profile %s: %w", current, err)
+	}
+	for i, spec := range specs {
+		switch spec.Type {
+		case CredentialEnv, CredentialFile, CredentialKube, CredentialKeyring:
+		case CredentialExec:
+			if spec.Command == "" {
+				return nil, fmt.Errorf("profile %s: credentials[%d]: exec requires a command", current, i)
+			}
+		case CredentialAgentFile:
+			if spec.Path == "" {
+				return nil, fmt.Errorf("profile %s: credentials[%d]: agent-file requires a path", current, i)
+			}
+		default:
+			return nil, fmt.Errorf("profile %s: credentials[%d]: unknown type %q", current, i, spec.Type)
+		}
+	}
+	return specs, nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -35,9 +36,38 @@ func LoadSiteCredentials(path string) (*model.SiteCredentials, error) {
 	if len(creds.TokenMap) == 0 {
 		return nil, fmt.Errorf("site credentials file has no tokenMap or tokenMap is empty (after discarding keys starting with _)")
 	}
+	// Resolve relative certificate paths against the credentials file location
+	dir := filepath.Dir(path)
+	for _, s := range creds.Sites {
+		if s == nil {
+			continue
+		}
+		s.CAFile = resolvePath(dir, s.CAFile)
+		s.CertFile = resolvePath(dir, s.CertFile)
+		s.KeyFile = resolvePath(dir, s.KeyFile)
+	}
 	return &creds, nil
 }
 
+func resolvePath(dir, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+// SiteSettingsFor returns the settings for the site behind baseURL (as returned
+// by ResolveSite), or an empty SiteSettings if none are configured.
+func SiteSettingsFor(creds *model.SiteCredentials, baseURL string) *model.SiteSettings {
+	host := strings.TrimPrefix(baseURL, "https://")
+	if creds != nil {
+		if s := creds.Sites[host]; s != nil {
+			return s
+		}
+	}
+	return &model.SiteSettings{}
+}
+
 // ResolveSite returns base URL (https://hostname) and token for the given site.
 // If site is empty, the first site in tokenMap (sorted by key) is used.
 func ResolveSite(creds *model.SiteCredentials, site string) (baseURL, token string, err error) {
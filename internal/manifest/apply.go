@@ -0,0 +1,824 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	ingextAPI "github.com/SecurityDo/ingext_api/api"
+	"github.com/SecurityDo/ingext_api/client"
+	"github.com/SecurityDo/ingext_api/model"
+)
+
+// Action is what Apply did, or would do with Options.DryRun, with a resource.
+type Action string
+
+const (
+	Created    Action = "created"
+	Configured Action = "configured"
+	Unchanged  Action = "unchanged"
+	Deleted    Action = "deleted"
+)
+
+// Result is the outcome of applying one resource of a manifest. Pipes are
+// named "<router>/<pipe>".
+type Result struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	ID     string `json:"id,omitempty"`
+	Action Action `json:"action"`
+	// Diff lists the fields set on creation or changed by an update.
+	Diff []FieldDiff `json:"diff,omitempty"`
+}
+
+// FieldDiff is a field of a resource whose value on the site (Old) differs
+// from the manifest (New). Path is the dotted JSON path of the field; values
+// of secret fields are masked.
+type FieldDiff struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Site is the API services of the site a manifest is applied to or exported
+// from.
+type Site struct {
+	Platform     *ingextAPI.PlatformService
+	Datalake     *ingextAPI.DatalakeService
+	Application  *ingextAPI.ApplicationService
+	Notification *ingextAPI.NotificationService
+	Syslog       *ingextAPI.SyslogService
+}
+
+// Options control Apply.
+type Options struct {
+	// DryRun computes the results without changing the site. Resources that
+	// would be created get "<kind/name>" placeholder IDs.
+	DryRun bool
+	// Prune deletes the sources, routers, pipes, channels and sinks of the
+	// site that are not in the manifest. Other kinds of resources are never
+	// deleted.
+	Prune bool
+}
+
+// Apply makes the site match m. Resources missing from the site are created
+// and those with a field differing from m are updated; the others are left
+// untouched, so applying the same manifest again changes nothing. Resources
+// and fields that m does not mention are kept as they are, unless
+// opts.Prune is set.
+//
+// All names referenced by m are resolved before anything is changed. Apply
+// returns the results of the resources applied so far, also on error.
+func Apply(site *Site, m *Manifest, opts Options) ([]*Result, error) {
+	platform := site.Platform
+	live, err := platform.ListConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configs: %w", err)
+	}
+	processors, err := platform.ListProcessors()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processors: %w", err)
+	}
+	a := &applier{site: site, platform: platform, opts: opts, live: live, processors: processors, ids: map[string]string{}}
+	if err := a.resolve(m); err != nil {
+		return nil, err
+	}
+	steps := []func(*Manifest) error{
+		a.applyIntegrations,
+		a.applySchemas,
+		a.applyDatalakes,
+		a.applyProcessors,
+		a.applySinks,
+		a.applyChannels,
+		a.applyRouters,
+		a.applySources,
+		a.applyNotifications,
+		a.applyApplications,
+		a.applySyslog,
+	}
+	if opts.Prune {
+		steps = append(steps, a.prune)
+	}
+	for _, step := range steps {
+		if err := step(m); err != nil {
+			return a.results, err
+		}
+	}
+	return a.results, nil
+}
+
+type applier struct {
+	site       *Site
+	platform   *ingextAPI.PlatformService
+	opts       Options
+	live       *ingextAPI.ListConfigsResponse
+	processors []*model.FPLScript
+	// ids maps "<kind>/<name>" to the ID of the resource, for those
+	// referenced by or applied from the manifest.
+	ids     map[string]string
+	results []*Result
+}
+
+// resolve checks that every name referenced by m is a resource of m or of
+// the site, and records the IDs of those of the site.
+func (a *applier) resolve(m *Manifest) error {
+	declared := map[string]bool{}
+	for _, i := range m.Integrations {
+		declared["integration/"+i.Name] = true
+	}
+	for _, p := range m.Processors {
+		declared["processor/"+p.Name] = true
+	}
+	for _, s := range m.Sinks {
+		declared["sink/"+s.Name] = true
+	}
+	for _, c := range m.Channels {
+		declared["channel/"+c.Name] = true
+	}
+	for _, r := range m.Routers {
+		declared["router/"+r.Name] = true
+	}
+
+	var errs []error
+	ref := func(from, kind, name string) {
+		if name == "" || declared[kind+"/"+name] {
+			return
+		}
+		id, found, err := a.liveID(kind, name)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("%s: %w", from, err))
+		case !found:
+			errs = append(errs, fmt.Errorf("%s: %s %q is not in the manifest nor on the site", from, kind, name))
+		default:
+			a.ids[kind+"/"+name] = id
+		}
+	}
+	for _, d := range m.Datalakes {
+		ref("datalake "+d.Name, "integration", d.Integration)
+	}
+	for _, s := range m.Sinks {
+		ref("sink "+s.Name, "integration", s.Integration)
+	}
+	for _, c := range m.Channels {
+		for _, sink := range c.Sinks {
+			ref("channel "+c.Name, "sink", sink)
+		}
+	}
+	for _, r := range m.Routers {
+		for _, p := range r.Pipes {
+			from := "pipe " + r.Name + "/" + p.Name
+			for _, proc := range p.Processors {
+				ref(from, "processor", proc)
+			}
+			ref(from, "channel", p.Channel)
+			for _, sink := range p.Sinks {
+				ref(from, "sink", sink)
+			}
+		}
+	}
+	for _, s := range m.Sources {
+		ref("source "+s.Name, "router", s.Router)
+		ref("source "+s.Name, "integration", s.Integration)
+	}
+	return errors.Join(errs...)
+}
+
+// liveID returns the ID of the named resource of the site.
+func (a *applier) liveID(kind, name string) (string, bool, error) {
+	switch kind {
+	case "integration":
+		e, found, err := byName(kind, a.live.Integrations, name, func(e *model.Integration) string { return e.Name })
+		if !found {
+			return "", false, err
+		}
+		return e.ID, true, nil
+	case "processor":
+		_, found, err := byName(kind, a.processors, name, func(e *model.FPLScript) string { return e.Name })
+		return name, found, err
+	case "sink":
+		e, found, err := byName(kind, a.live.Sinks, name, func(e *model.DataSinkConfig) string { return e.Name })
+		if !found {
+			return "", false, err
+		}
+		return e.ID, true, nil
+	case "channel":
+		e, found, err := byName(kind, a.live.Channels, name, func(e *model.ChannelConfig) string { return e.Name })
+		if !found {
+			return "", false, err
+		}
+		return e.ID, true, nil
+	default: // router
+		e, found, err := byName(kind, a.live.Routers, name, func(e *model.RouterConfig) string { return e.Name })
+		if !found {
+			return "", false, err
+		}
+		return e.ID, true, nil
+	}
+}
+
+// byName returns the entry named name. Names are not unique on a site, so
+// several entries with the name are an error rather than a guess.
+func byName[T any](kind string, entries []T, name string, nameOf func(T) string) (entry T, found bool, err error) {
+	n := 0
+	for _, e := range entries {
+		if nameOf(e) == name {
+			entry = e
+			n++
+		}
+	}
+	if n > 1 {
+		var zero T
+		return zero, false, fmt.Errorf("%d %ss named %q on the site, rename or delete all but one", n, kind, name)
+	}
+	return entry, n == 1, nil
+}
+
+func (a *applier) applyIntegrations(m *Manifest) error {
+	for _, in := range m.Integrations {
+		live, found, err := byName("integration", a.live.Integrations, in.Name, func(e *model.Integration) string { return e.Name })
+		if err != nil {
+			return err
+		}
+		_, err = upsert(a, "integration", in.Name, in, live, found, entryOps[*model.Integration]{
+			add:    a.platform.AddIntegration,
+			update: a.platform.UpdateIntegration,
+			id:     func(e *model.Integration) string { return e.ID },
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *applier) applyProcessors(m *Manifest) error {
+	for _, p := range m.Processors {
+		_, found, err := byName("processor", a.processors, p.Name, func(e *model.FPLScript) string { return e.Name })
+		if err != nil {
+			return err
+		}
+		// Listed processors may come without their script, so compare
+		// against the full entry.
+		var live *model.FPLScript
+		if found {
+			if live, err = a.platform.GetProcessor(p.Name); err != nil {
+				return fmt.Errorf("failed to get processor %s: %w", p.Name, err)
+			}
+		}
+		_, err = upsert(a, "processor", p.Name, p, live, found, entryOps[*model.FPLScript]{
+			add:    func(e *model.FPLScript) (string, error) { return e.Name, a.platform.AddProcessor(e) },
+			update: a.platform.UpdateProcessor,
+			id:     func(e *model.FPLScript) string { return e.Name },
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *applier) applySinks(m *Manifest) error {
+	for _, s := range m.Sinks {
+		entry := s.DataSinkConfig
+		if s.Integration != "" {
+			if err := setSinkIntegration(&entry, a.ids["integration/"+s.Integration]); err != nil {
+				return fmt.Errorf("sink %s: %w", s.Name, err)
+			}
+		}
+		live, found, err := byName("sink", a.live.Sinks, s.Name, func(e *model.DataSinkConfig) string { return e.Name })
+		if err != nil {
+			return err
+		}
+		_, err = upsert(a, "sink", s.Name, &entry, live, found, entryOps[*model.DataSinkConfig]{
+			add: func(e *model.DataSinkConfig) (string, error) {
+				resp, err := a.platform.AddDataSink(e)
+				if err != nil {
+					return "", err
+				}
+				return resp.ID, nil
+			},
+			update: a.platform.UpdateDataSink,
+			id:     func(e *model.DataSinkConfig) string { return e.ID },
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *applier) applyChannels(m *Manifest) error {
+	for _, c := range m.Channels {
+		live, found, err := byName("channel", a.live.Channels, c.Name, func(e *model.ChannelConfig) string { return e.Name })
+		if err != nil {
+			return err
+		}
+		desired := &model.ChannelConfig{Name: c.Name, SinkIDs: a.idsOf("sink", c.Sinks)}
+		_, err = upsert(a, "channel", c.Name, desired, live, found, entryOps[*model.ChannelConfig]{
+			add: func(e *model.ChannelConfig) (string, error) {
+				resp, err := a.platform.AddChannel(e)
+				if err != nil {
+					return "", err
+				}
+				return resp.ID, nil
+			},
+			update: a.platform.UpdateChannel,
+			id:     func(e *model.ChannelConfig) string { return e.ID },
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *applier) applyRouters(m *Manifest) error {
+	for _, r := range m.Routers {
+		live, found, err := byName("router", a.live.Routers, r.Name, func(e *model.RouterConfig) string { return e.Name })
+		if err != nil {
+			return err
+		}
+		desired := map[string]interface{}{"name": r.Name}
+		switch {
+		case r.WorkerCount > 0:
+			desired["workerCount"] = r.WorkerCount
+		case !found:
+			desired["workerCount"] = DefaultWorkerCount
+		}
+		routerID, err := upsert(a, "router", r.Name, desired, live, found, entryOps[*model.RouterConfig]{
+			add: func(e *model.RouterConfig) (string, error) {
+				resp, err := a.platform.AddRouter(e)
+				if err != nil {
+					return "", err
+				}
+				return resp.ID, nil
+			},
+			update: a.platform.UpdateRouter,
+			id:     func(e *model.RouterConfig) string { return e.ID },
+		})
+		if err != nil {
+			return err
+		}
+		result := a.results[len(a.results)-1]
+
+		var pipeIDs []string
+		var livePipes []*model.StreamPipeConfig
+		if found {
+			pipeIDs = live.PipeIDs
+			for _, p := range a.live.Pipes {
+				if p.RouterID == routerID {
+					livePipes = append(livePipes, p)
+				}
+			}
+		}
+		order := make([]string, 0, len(r.Pipes))
+		for _, p := range r.Pipes {
+			livePipe, pipeFound, err := byName("pipe", livePipes, p.Name, func(e *model.StreamPipeConfig) string { return e.Name })
+			if err != nil {
+				return fmt.Errorf("router %s: %w", r.Name, err)
+			}
+			// A map rather than a StreamPipeConfig, so that empty
+			// processors and sinks are applied instead of omitted.
+			desired := map[string]interface{}{
+				"name":           p.Name,
+				"routerID":       routerID,
+				"matchAll":       p.MatchAll,
+				"selector":       p.Selector,
+				"processorNames": append([]string{}, p.Processors...),
+				"channelID":      a.ids["channel/"+p.Channel],
+				"sinkIDs":        a.idsOf("sink", p.Sinks),
+			}
+			pipeID, err := upsert(a, "pipe", r.Name+"/"+p.Name, desired, livePipe, pipeFound, entryOps[*model.StreamPipeConfig]{
+				add: func(e *model.StreamPipeConfig) (string, error) {
+					resp, err := a.platform.AddRouterPipe(&ingextAPI.RouterAddPipeReq{RouterID: routerID, PipeConfig: e})
+					if err != nil {
+						return "", err
+					}
+					return resp.ID, nil
+				},
+				update: func(e *model.StreamPipeConfig) error {
+					return a.platform.UpdatePipe(&ingextAPI.PipeUpdateReq{RouterID: routerID, PipeConfig: e})
+				},
+				id: func(e *model.StreamPipeConfig) string { return e.ID },
+			})
+			if err != nil {
+				return err
+			}
+			if !pipeFound {
+				pipeIDs = append(pipeIDs, pipeID)
+			}
+			order = append(order, pipeID)
+		}
+
+		// Pipes are evaluated in order: those of the manifest first, then
+		// the ones only on the site.
+		for _, id := range pipeIDs {
+			if !slices.Contains(order, id) {
+				order = append(order, id)
+			}
+		}
+		if !slices.Equal(order, pipeIDs) {
+			if !a.opts.DryRun {
+				if err := a.platform.UpdateRouterPipes(&ingextAPI.RouterUpdatePipesReq{RouterID: routerID, PipeIDs: order}); err != nil {
+					return fmt.Errorf("failed to reorder pipes of router %s: %w", r.Name, err)
+				}
+			}
+			result.changed(FieldDiff{Path: "pipeIDs", Old: pipeIDs, New: order})
+		}
+	}
+	return nil
+}
+
+func (a *applier) applySources(m *Manifest) error {
+	connections := map[string]string{}
+	for _, c := range a.live.Connections {
+		connections[c.SourceID] = c.RouterID
+	}
+	for _, s := range m.Sources {
+		entry := s.DataSourceConfig
+		if s.Integration != "" {
+			setSourceIntegration(&entry, a.ids["integration/"+s.Integration])
+		}
+		live, found, err := byName("source", a.live.Sources, s.Name, func(e *model.DataSourceConfig) string { return e.Name })
+		if err != nil {
+			return err
+		}
+		sourceID, err := upsert(a, "source", s.Name, &entry, live, found, entryOps[*model.DataSourceConfig]{
+			add: func(e *model.DataSourceConfig) (string, error) {
+				resp, err := a.platform.AddDataSource(e)
+				if err != nil {
+					return "", err
+				}
+				return resp.ID, nil
+			},
+			update: a.platform.UpdateDataSource,
+			id:     func(e *model.DataSourceConfig) string { return e.ID },
+		})
+		if err != nil {
+			return err
+		}
+		result := a.results[len(a.results)-1]
+
+		routerID := a.ids["router/"+s.Router]
+		if s.Router == "" || connections[sourceID] == routerID {
+			continue
+		}
+		if !a.opts.DryRun {
+			if err := a.platform.SetDataSourceRouter(&ingextAPI.SourceSetRouterReq{RouterID: routerID, DataSourceID: sourceID}); err != nil {
+				return fmt.Errorf("failed to connect source %s to router %s: %w", s.Name, s.Router, err)
+			}
+		}
+		diff := FieldDiff{Path: "router", New: s.Router}
+		if old := connections[sourceID]; old != "" {
+			diff.Old = old
+			if router, found := byID(a.live.Routers, old, func(e *model.RouterConfig) string { return e.ID }); found {
+				diff.Old = router.Name
+			}
+		}
+		result.changed(diff)
+	}
+	return nil
+}
+
+// idsOf returns the IDs of the named resources, never nil.
+func (a *applier) idsOf(kind string, names []string) []string {
+	ids := make([]string, len(names))
+	for i, name := range names {
+		ids[i] = a.ids[kind+"/"+name]
+	}
+	return ids
+}
+
+// entryOps are the API calls for one kind of resource. update is nil for
+// the kinds the site cannot update.
+type entryOps[T any] struct {
+	add    func(T) (string, error)
+	update func(T) error
+	id     func(T) string
+}
+
+// upsert creates the resource if it is not on the site, or updates the live
+// entry if desired sets a field to a different value. desired is any value
+// whose JSON encoding has the fields of T to apply. It returns the ID of the
+// resource and records the result.
+func upsert[T any](a *applier, kind, name string, desired interface{}, live T, found bool, ops entryOps[T]) (string, error) {
+	var base interface{}
+	if found {
+		base = live
+	}
+	var entry T
+	diff, err := overlay(base, desired, &entry)
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %w", kind, name, err)
+	}
+	id, action := "", Unchanged
+	switch {
+	case !found:
+		id, action = "<"+kind+"/"+name+">", Created
+		if !a.opts.DryRun {
+			if id, err = ops.add(entry); err != nil {
+				return "", fmt.Errorf("failed to create %s %s: %w", kind, name, err)
+			}
+		}
+	case len(diff) > 0:
+		id, action = ops.id(live), Configured
+		if ops.update == nil {
+			paths := make([]string, len(diff))
+			for i, d := range diff {
+				paths[i] = d.Path
+			}
+			return "", fmt.Errorf("%s %s differs from the manifest (%s) and cannot be updated: delete it first", kind, name, strings.Join(paths, ", "))
+		}
+		if !a.opts.DryRun {
+			if err := ops.update(entry); err != nil {
+				return "", fmt.Errorf("failed to update %s %s: %w", kind, name, err)
+			}
+		}
+	default:
+		id = ops.id(live)
+	}
+	a.ids[kind+"/"+name] = id
+	a.results = append(a.results, &Result{Kind: kind, Name: name, ID: id, Action: action, Diff: diff})
+	return id, nil
+}
+
+// changed records a change made to a resource besides its entry.
+func (r *Result) changed(diff FieldDiff) {
+	if r.Action == Unchanged {
+		r.Action = Configured
+	}
+	r.Diff = append(r.Diff, diff)
+}
+
+// prune deletes the resources of the site that m does not have nor refers
+// to, sources first so that nothing is left feeding a deleted router.
+func (a *applier) prune(m *Manifest) error {
+	// The resources of the site resolved from references are kept too.
+	keep := map[string]bool{}
+	for key := range a.ids {
+		keep[key] = true
+	}
+	for _, s := range m.Sources {
+		keep["source/"+s.Name] = true
+	}
+	for _, r := range m.Routers {
+		keep["router/"+r.Name] = true
+		for _, p := range r.Pipes {
+			keep["pipe/"+r.Name+"/"+p.Name] = true
+		}
+	}
+	for _, c := range m.Channels {
+		keep["channel/"+c.Name] = true
+	}
+	for _, s := range m.Sinks {
+		keep["sink/"+s.Name] = true
+	}
+
+	for _, s := range a.live.Sources {
+		if !keep["source/"+s.Name] {
+			if err := a.delete("source", s.Name, s.ID, func() error { return a.platform.DeleteDataSource(s.ID) }); err != nil {
+				return err
+			}
+		}
+	}
+	// Deleting a router deletes its pipes.
+	for _, r := range a.live.Routers {
+		if !keep["router/"+r.Name] {
+			if err := a.delete("router", r.Name, r.ID, func() error { return a.platform.DeleteRouter(r.ID) }); err != nil {
+				return err
+			}
+		}
+	}
+	for _, p := range a.live.Pipes {
+		router, found := byID(a.live.Routers, p.RouterID, func(e *model.RouterConfig) string { return e.ID })
+		if !found || !keep["router/"+router.Name] || keep["pipe/"+router.Name+"/"+p.Name] {
+			continue
+		}
+		err := a.delete("pipe", router.Name+"/"+p.Name, p.ID, func() error {
+			return a.platform.DeleteRouterPipe(&ingextAPI.RouterDeletePipeReq{RouterID: router.ID, PipeID: p.ID})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, c := range a.live.Channels {
+		if !keep["channel/"+c.Name] {
+			if err := a.delete("channel", c.Name, c.ID, func() error { return a.platform.DeleteChannel(c.ID) }); err != nil {
+				return err
+			}
+		}
+	}
+	for _, s := range a.live.Sinks {
+		if !keep["sink/"+s.Name] {
+			if err := a.delete("sink", s.Name, s.ID, func() error { return a.platform.DeleteDataSink(s.ID) }); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (a *applier) delete(kind, name, id string, del func() error) error {
+	if !a.opts.DryRun {
+		if err := del(); err != nil {
+			return fmt.Errorf("failed to delete %s %s: %w", kind, name, err)
+		}
+	}
+	a.results = append(a.results, &Result{Kind: kind, Name: name, ID: id, Action: Deleted})
+	return nil
+}
+
+// byID returns the entry with the given ID.
+func byID[T any](entries []T, id string, idOf func(T) string) (entry T, found bool) {
+	for _, e := range entries {
+		if idOf(e) == id {
+			return e, true
+		}
+	}
+	return entry, false
+}
+
+// writeOnly are the top-level fields a site may leave out of the entries it
+// returns, like secrets. They are only compared when the live entry has them.
+var writeOnly = map[string]bool{"secret": true}
+
+// overlay decodes into out the live entry with the fields set by desired
+// overlaid, and returns the fields that differ from live. Fields that
+// desired leaves unset and the "id" field are kept from live.
+func overlay(live, desired, out interface{}) ([]FieldDiff, error) {
+	base, err := toMap(live)
+	if err != nil {
+		return nil, err
+	}
+	want, err := toMap(desired)
+	if err != nil {
+		return nil, err
+	}
+	var diff []FieldDiff
+	for _, k := range sortedKeys(want) {
+		v := want[k]
+		if unset(k, v) || k == "id" {
+			continue
+		}
+		old, ok := base[k]
+		if !ok && writeOnly[k] && live != nil {
+			base[k] = v
+			continue
+		}
+		base[k] = merge(k, old, v, &diff)
+	}
+	b, err := json.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	return diff, json.Unmarshal(b, out)
+}
+
+// merge overlays v on old: objects are merged field by field, other values
+// replace old. The fields that differ from old are added to diff.
+func merge(path string, old, v interface{}, diff *[]FieldDiff) interface{} {
+	newObj, ok := v.(map[string]interface{})
+	oldObj, ok2 := old.(map[string]interface{})
+	if !ok || !ok2 {
+		if !equal(old, v) {
+			*diff = append(*diff, fieldDiff(path, old, v))
+		}
+		return v
+	}
+	out := make(map[string]interface{}, len(oldObj))
+	for k, x := range oldObj {
+		out[k] = x
+	}
+	for _, k := range sortedKeys(newObj) {
+		if x := newObj[k]; !unset(k, x) {
+			out[k] = merge(path+"."+k, oldObj[k], x, diff)
+		}
+	}
+	return out
+}
+
+// unset reports whether desired leaves the field k to the site: null, or an
+// empty secret, which manifests and snapshots leave out.
+func unset(k string, v interface{}) bool {
+	return v == nil || isZero(v) && client.DefaultRedactor().IsSensitiveKey(k)
+}
+
+// fieldDiff returns the diff of a field, masking the values of secret fields.
+func fieldDiff(path string, old, v interface{}) FieldDiff {
+	redactor := client.DefaultRedactor()
+	for _, key := range strings.Split(path, ".") {
+		if redactor.IsSensitiveKey(key) {
+			if !isZero(old) {
+				old = client.RedactedMask
+			}
+			return FieldDiff{Path: path, Old: old, New: client.RedactedMask}
+		}
+	}
+	if isZero(old) {
+		old = nil
+	}
+	return FieldDiff{Path: path, Old: old, New: v}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// equal compares decoded JSON values. Zero values equal missing ones, as
+// the site omits empty fields.
+func equal(a, b interface{}) bool {
+	if isZero(a) && isZero(b) {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func isZero(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case json.Number:
+		return v == "0"
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// toMap returns the JSON object encoding v, empty for nil. Numbers are kept
+// as json.Number so that large IDs survive the round trip.
+func toMap(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var m map[string]interface{}
+	if err := d.Decode(&m); err != nil {
+		return nil, err
+	}
+	if m == nil {
+		m = map[string]interface{}{}
+	}
+	return m, nil
+}
+
+// setSinkIntegration sets the integration ID of the plugin config of a sink.
+func setSinkIntegration(sink *model.DataSinkConfig, id string) error {
+	switch {
+	case sink.S3 != nil:
+		c := *sink.S3
+		c.IntegrationID, sink.S3 = id, &c
+	case sink.Firehose != nil:
+		c := *sink.Firehose
+		c.IntegrationID, sink.Firehose = id, &c
+	case sink.Lambda != nil:
+		c := *sink.Lambda
+		c.IntegrationID, sink.Lambda = id, &c
+	case sink.Kinesis != nil:
+		c := *sink.Kinesis
+		c.IntegrationID, sink.Kinesis = id, &c
+	case sink.PROM != nil:
+		c := *sink.PROM
+		c.IntegrationID, sink.PROM = id, &c
+	case sink.Loki != nil:
+		c := *sink.Loki
+		c.IntegrationID, sink.Loki = id, &c
+	default:
+		return fmt.Errorf("integration needs an s3, firehose, lambda, kinesis, prom or loki config")
+	}
+	return nil
+}
+
+// setSourceIntegration sets the integration ID of a kinesis source, or the
+// plugin ID of other sources.
+func setSourceIntegration(source *model.DataSourceConfig, id string) {
+	if source.Kinesis != nil {
+		c := *source.Kinesis
+		c.IntegrationID, source.Kinesis = id, &c
+		return
+	}
+	var c model.PluginSourceConfig
+	if source.Plugin != nil {
+		c = *source.Plugin
+	}
+	c.ID, source.Plugin = id, &c
+}
@@ -0,0 +1,334 @@
+package manifest_test
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/SecurityDo/ingext_api/api"
+	"github.com/SecurityDo/ingext_api/client"
+	"github.com/SecurityDo/ingext_api/fakeserver"
+	"github.com/SecurityDo/ingext_api/internal/manifest"
+	"github.com/SecurityDo/ingext_api/model"
+)
+
+const pipelineYAML = `
+integrations:
+  - name: archive-bucket
+    integration: S3Bucket
+    config: {bucket: my-archive}
+processors:
+  - name: parse-web
+    description: parse web logs
+    scriptText: "function process(e) { return e }"
+sinks:
+  - name: lake
+    type: dataLake
+    dataLake: {datalake: managed, datalakeIndex: web, schemaName: web}
+  - name: archive
+    type: s3
+    integration: archive-bucket
+    s3: {bucket: my-archive, objectPath: web/}
+channels:
+  - name: fanout
+    sinks: [lake, archive]
+routers:
+  - name: web
+    workerCount: 2
+    pipes:
+      - name: main
+        processors: [parse-web]
+        channel: fanout
+      - name: errors
+        selector: 'level == "error"'
+        sinks: [archive]
+sources:
+  - name: web-hec
+    type: hec
+    format: json
+    router: web
+`
+
+const siteYAML = `
+schemas:
+  - name: web
+    description: web logs
+    content: '{"type":"object","properties":{"msg":{"type":"string"}}}'
+datalakes:
+  - name: managed
+    managed: true
+    indexes:
+      - name: web
+        schema: web
+notifications:
+  - name: ops
+    integration: Email
+    action: alert
+    email: {to: [ops@example.com]}
+applications:
+  - name: demo-app
+    content: |
+      application:
+        apiVersion: v1
+        kind: Application
+        metadata:
+          name: demo-app
+        spec:
+          displayName: Demo
+syslog:
+  listeners: [tcp, udp]
+`
+
+func newSite(t *testing.T) (*fakeserver.Server, *manifest.Site) {
+	t.Helper()
+	srv, cli := fakeserver.NewTest(t)
+	return srv, &manifest.Site{
+		Platform:     api.NewPlatformService(cli),
+		Datalake:     api.NewDatalakeService(cli),
+		Application:  api.NewApplicationService(cli),
+		Notification: api.NewNotificationService(cli),
+		Syslog:       api.NewSyslogService(cli),
+	}
+}
+
+func parse(t *testing.T, body string) *manifest.Manifest {
+	t.Helper()
+	m, err := manifest.Parse([]byte("version: 1\n"+body), t.TempDir())
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return m
+}
+
+func apply(t *testing.T, site *manifest.Site, m *manifest.Manifest, opts manifest.Options) map[string]manifest.Action {
+	t.Helper()
+	results, err := manifest.Apply(site, m, opts)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	actions := map[string]manifest.Action{}
+	for _, r := range results {
+		actions[r.Kind+"/"+r.Name] = r.Action
+	}
+	return actions
+}
+
+func TestApplyIdempotent(t *testing.T) {
+	cases := []struct {
+		name    string
+		body    string
+		created int
+	}{
+		{"pipeline", pipelineYAML, 9},
+		{"site", siteYAML, 6},
+		{"both", pipelineYAML + siteYAML, 15},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, site := newSite(t)
+			m := parse(t, tc.body)
+
+			first := apply(t, site, m, manifest.Options{})
+			if len(first) != tc.created {
+				t.Errorf("first apply: %d results, want %d: %v", len(first), tc.created, first)
+			}
+			for name, action := range first {
+				if action != manifest.Created {
+					t.Errorf("first apply: %s %s, want created", name, action)
+				}
+			}
+			for _, opts := range []manifest.Options{{}, {DryRun: true}, {Prune: true}} {
+				for name, action := range apply(t, site, m, opts) {
+					if action != manifest.Unchanged {
+						t.Errorf("apply again %+v: %s %s, want unchanged", opts, name, action)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestApplyWorkerCount(t *testing.T) {
+	_, site := newSite(t)
+	apply(t, site, parse(t, "routers:\n  - name: web\n    workerCount: 4\n"), manifest.Options{})
+
+	// Without a workerCount, an existing router keeps its own and a new one
+	// gets the default.
+	actions := apply(t, site, parse(t, "routers:\n  - name: web\n  - name: new\n"), manifest.Options{})
+	if actions["router/web"] != manifest.Unchanged || actions["router/new"] != manifest.Created {
+		t.Errorf("actions = %v", actions)
+	}
+	live, err := site.Platform.ListConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"web": 4, "new": manifest.DefaultWorkerCount}
+	for _, r := range live.Routers {
+		if r.WorkerCount != want[r.Name] {
+			t.Errorf("router %s: workerCount = %d, want %d", r.Name, r.WorkerCount, want[r.Name])
+		}
+	}
+
+	actions = apply(t, site, parse(t, "routers:\n  - name: web\n    workerCount: 3\n"), manifest.Options{})
+	if actions["router/web"] != manifest.Configured {
+		t.Errorf("actions after changing workerCount = %v", actions)
+	}
+}
+
+func TestApplicationName(t *testing.T) {
+	cases := []struct {
+		name, content string
+		ok            bool
+	}{
+		{"same", "application: {metadata: {name: demo-app}}", true},
+		{"different", "application: {metadata: {name: other}}", false},
+		{"none", "application: {spec: {displayName: Demo}}", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := fmt.Sprintf("version: 1\napplications:\n  - name: demo-app\n    content: %q\n", tc.content)
+			_, err := manifest.Parse([]byte(body), t.TempDir())
+			if (err == nil) != tc.ok {
+				t.Errorf("Parse: %v, want ok %v", err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestApplyPrune(t *testing.T) {
+	cases := []struct {
+		name string
+		edit func(m *manifest.Manifest)
+		// deleted is in deletion order: sources, routers, pipes, channels,
+		// sinks.
+		deleted []string
+	}{
+		{"nothing removed", func(*manifest.Manifest) {}, []string{"sink/stray"}},
+		{"source", func(m *manifest.Manifest) { m.Sources = nil }, []string{"source/web-hec", "sink/stray"}},
+		{"pipe", func(m *manifest.Manifest) { m.Routers[0].Pipes = m.Routers[0].Pipes[:1] }, []string{"pipe/web/errors", "sink/stray"}},
+		{"router with its pipes", func(m *manifest.Manifest) { m.Sources, m.Routers = nil, nil }, []string{"source/web-hec", "router/web", "sink/stray"}},
+		{"channel", func(m *manifest.Manifest) {
+			m.Channels = nil
+			m.Routers[0].Pipes[0].Channel = ""
+		}, []string{"channel/fanout", "sink/stray"}},
+		// Resources of the site referred to by the manifest are kept.
+		{"sink referred to", func(m *manifest.Manifest) {
+			m.Routers[0].Pipes[1].Sinks = append(m.Routers[0].Pipes[1].Sinks, "stray")
+		}, nil},
+		{"channel referred to", func(m *manifest.Manifest) { m.Channels = nil }, []string{"sink/stray"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, site := newSite(t)
+			apply(t, site, parse(t, pipelineYAML+siteYAML), manifest.Options{})
+			// A sink of the site that no manifest declares.
+			if _, err := site.Platform.AddDataSink(&model.DataSinkConfig{Name: "stray", Type: "hec"}); err != nil {
+				t.Fatal(err)
+			}
+
+			// The site-wide resources are left out: they are never pruned.
+			m := parse(t, pipelineYAML)
+			tc.edit(m)
+			results, err := manifest.Apply(site, m, manifest.Options{Prune: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var deleted []string
+			for _, r := range results {
+				if r.Action == manifest.Deleted {
+					deleted = append(deleted, r.Kind+"/"+r.Name)
+				}
+			}
+			if !slices.Equal(deleted, tc.deleted) {
+				t.Errorf("deleted %v, want %v", deleted, tc.deleted)
+			}
+
+			// Pruning again deletes nothing.
+			for name, action := range apply(t, site, m, manifest.Options{Prune: true}) {
+				if action != manifest.Unchanged {
+					t.Errorf("prune again: %s %s", name, action)
+				}
+			}
+			if schemas, err := site.Datalake.ListSchema(); err != nil || len(schemas) != 1 {
+				t.Errorf("schemas after prune = %v, %v", schemas, err)
+			}
+		})
+	}
+}
+
+func TestApplyDryRun(t *testing.T) {
+	srv, site := newSite(t)
+	apply(t, site, parse(t, pipelineYAML), manifest.Options{})
+	before := export(t, site)
+
+	// Create, update and delete a resource of each step.
+	m := parse(t, pipelineYAML+siteYAML)
+	m.Sinks = append(m.Sinks, &manifest.Sink{DataSinkConfig: model.DataSinkConfig{
+		Name: "extra",
+		Type: "hec",
+		Hec:  &model.HecSinkConfig{URL: "https://splunk.example.com"},
+	}})
+	m.Routers[0].Pipes[1].Sinks = append(m.Routers[0].Pipes[1].Sinks, "extra")
+	m.Routers[0].WorkerCount = 8
+	m.Sources = nil
+
+	calls := len(srv.Calls())
+	results, err := manifest.Apply(site, m, manifest.Options{DryRun: true, Prune: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, call := range srv.Calls()[calls:] {
+		// DAO calls are checked by the export below.
+		if fn := path.Base(call); !strings.HasSuffix(fn, "_dao") && !client.IsReadOnlyCall(fn, nil) {
+			t.Errorf("dry run called %s", call)
+		}
+	}
+	if after := export(t, site); !reflect.DeepEqual(after, before) {
+		t.Errorf("dry run changed the site:\nbefore %+v\nafter  %+v", before, after)
+	}
+
+	byName := map[string]*manifest.Result{}
+	for _, r := range results {
+		byName[r.Kind+"/"+r.Name] = r
+	}
+	for name, want := range map[string]manifest.Action{
+		"sink/extra":      manifest.Created,
+		"schema/web":      manifest.Created,
+		"syslog/syslog":   manifest.Created,
+		"router/web":      manifest.Configured,
+		"pipe/web/errors": manifest.Configured,
+		"source/web-hec":  manifest.Deleted,
+		"sink/lake":       manifest.Unchanged,
+	} {
+		if r := byName[name]; r == nil || r.Action != want {
+			t.Errorf("%s: %+v, want %s", name, r, want)
+		}
+	}
+	// Resources to be created get placeholder IDs, also where referenced.
+	if r := byName["sink/extra"]; r == nil || r.ID != "<sink/extra>" {
+		t.Errorf("sink/extra: %+v", r)
+	}
+	found := false
+	for _, d := range byName["pipe/web/errors"].Diff {
+		if strings.Contains(fmt.Sprint(d.New), "<sink/extra>") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("pipe/web/errors diff does not refer to <sink/extra>: %+v", byName["pipe/web/errors"].Diff)
+	}
+}
+
+// export returns the configuration of the site, without the export details.
+func export(t *testing.T, site *manifest.Site) *manifest.Manifest {
+	t.Helper()
+	m, err := manifest.Export(site, "")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	m.Exported = nil
+	return m
+}
@@ -0,0 +1,562 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	ingextAPI "github.com/SecurityDo/ingext_api/api"
+	"github.com/SecurityDo/ingext_api/client"
+	"github.com/SecurityDo/ingext_api/model"
+	"sigs.k8s.io/yaml"
+)
+
+// Export returns a manifest of the configuration of the site: its
+// integrations, schemas, datalakes and indexes, processors, sinks,
+// channels, routers and pipes, sources, notification endpoints, application
+// templates and syslog listeners, with references by name. Secret fields
+// are left out and listed in Exported.OmittedSecrets. siteURL is recorded
+// in Exported.
+//
+// A site with two resources of a kind with the same name cannot be
+// exported, as the names would not resolve.
+func Export(site *Site, siteURL string) (*Manifest, error) {
+	live, err := site.Platform.ListConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configs: %w", err)
+	}
+	e := &exporter{
+		site:     site,
+		live:     live,
+		m:        &Manifest{Version: Version},
+		exported: &Exported{Site: siteURL, At: time.Now().UTC(), IDs: map[string]string{}},
+		names:    map[string]string{},
+	}
+	for _, i := range live.Integrations {
+		e.names[i.ID] = i.Name
+	}
+	for _, s := range live.Sinks {
+		e.names[s.ID] = s.Name
+	}
+	for _, c := range live.Channels {
+		e.names[c.ID] = c.Name
+	}
+	for _, r := range live.Routers {
+		e.names[r.ID] = r.Name
+	}
+
+	steps := []func() error{
+		e.exportIntegrations,
+		e.exportSchemas,
+		e.exportDatalakes,
+		e.exportProcessors,
+		e.exportSinks,
+		e.exportChannels,
+		e.exportRouters,
+		e.exportSources,
+		e.exportNotifications,
+		e.exportApplications,
+		e.exportSyslog,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+	if err := e.m.validate(); err != nil {
+		return nil, fmt.Errorf("cannot export the site: %w", err)
+	}
+	e.m.Exported = e.exported
+	return e.m, nil
+}
+
+type exporter struct {
+	site     *Site
+	live     *ingextAPI.ListConfigsResponse
+	m        *Manifest
+	exported *Exported
+	// names maps the IDs of integrations, sinks, channels and routers to
+	// their names.
+	names map[string]string
+}
+
+// name returns the name of the resource with the given ID, referenced by
+// from.
+func (e *exporter) name(from, kind, id string) (string, error) {
+	name, ok := e.names[id]
+	if !ok {
+		return "", fmt.Errorf("%s refers to %s %s, which is not on the site", from, kind, id)
+	}
+	return name, nil
+}
+
+func (e *exporter) exportIntegrations() error {
+	for _, i := range e.live.Integrations {
+		e.exported.IDs["integration/"+i.Name] = i.ID
+		if len(i.Secret) > 0 && string(i.Secret) != "null" {
+			e.omitted("integration", i.Name, "secret")
+		}
+		var config json.RawMessage
+		if len(i.Config) > 0 {
+			if err := e.omitSecrets("integration", i.Name, "config", i.Config, &config); err != nil {
+				return err
+			}
+		}
+		e.m.Integrations = append(e.m.Integrations, &Integration{
+			Name:        i.Name,
+			Integration: i.Integration,
+			Description: i.Description,
+			Config:      config,
+			Tags:        i.Tags,
+		})
+	}
+	return nil
+}
+
+func (e *exporter) exportSchemas() error {
+	schemas, err := e.site.Datalake.ListSchema()
+	if err != nil {
+		return fmt.Errorf("failed to list schemas: %w", err)
+	}
+	for _, sc := range schemas {
+		e.m.Schemas = append(e.m.Schemas, &Schema{Name: sc.Name, Description: sc.Description, Content: sc.Content})
+	}
+	return nil
+}
+
+func (e *exporter) exportDatalakes() error {
+	lakes, err := e.site.Datalake.ListDatalake()
+	if err != nil {
+		return fmt.Errorf("failed to list datalakes: %w", err)
+	}
+	indexes, err := e.site.Datalake.ListDatalakeIndex("")
+	if err != nil {
+		return fmt.Errorf("failed to list datalake indexes: %w", err)
+	}
+	for _, l := range lakes {
+		d := &Datalake{Name: l.Name, Managed: l.Managed}
+		if l.IntegrationID != "" {
+			if d.Integration, err = e.name("datalake "+l.Name, "integration", l.IntegrationID); err != nil {
+				return err
+			}
+		}
+		for _, i := range indexes {
+			if i.Datalake == l.Name {
+				d.Indexes = append(d.Indexes, &DatalakeIndex{Name: i.DatalakeIndex, Schema: i.SchemaName})
+			}
+		}
+		e.m.Datalakes = append(e.m.Datalakes, d)
+	}
+	return nil
+}
+
+func (e *exporter) exportProcessors() error {
+	processors, err := e.site.Platform.ListProcessors()
+	if err != nil {
+		return fmt.Errorf("failed to list processors: %w", err)
+	}
+	for _, p := range processors {
+		// Listed processors may come without their script.
+		full, err := e.site.Platform.GetProcessor(p.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get processor %s: %w", p.Name, err)
+		}
+		e.m.Processors = append(e.m.Processors, &Processor{
+			Name:        full.Name,
+			Type:        full.Type,
+			Group:       full.Group,
+			Description: full.Description,
+			ScriptText:  full.ScriptText,
+			Tags:        full.Tags,
+		})
+	}
+	return nil
+}
+
+func (e *exporter) exportSinks() error {
+	for _, live := range e.live.Sinks {
+		e.exported.IDs["sink/"+live.Name] = live.ID
+		s := &Sink{}
+		if err := e.omitSecrets("sink", live.Name, "", live, &s.DataSinkConfig); err != nil {
+			return err
+		}
+		s.ID = ""
+		if id := sinkIntegration(&s.DataSinkConfig); id != "" {
+			name, err := e.name("sink "+live.Name, "integration", id)
+			if err != nil {
+				return err
+			}
+			s.Integration = name
+			_ = setSinkIntegration(&s.DataSinkConfig, "")
+		}
+		e.m.Sinks = append(e.m.Sinks, s)
+	}
+	return nil
+}
+
+func (e *exporter) exportChannels() error {
+	for _, c := range e.live.Channels {
+		e.exported.IDs["channel/"+c.Name] = c.ID
+		channel := &Channel{Name: c.Name}
+		for _, id := range c.SinkIDs {
+			name, err := e.name("channel "+c.Name, "sink", id)
+			if err != nil {
+				return err
+			}
+			channel.Sinks = append(channel.Sinks, name)
+		}
+		e.m.Channels = append(e.m.Channels, channel)
+	}
+	return nil
+}
+
+// exportRouters exports the routers with their pipes, in evaluation order.
+func (e *exporter) exportRouters() error {
+	for _, r := range e.live.Routers {
+		e.exported.IDs["router/"+r.Name] = r.ID
+		router := &Router{Name: r.Name, WorkerCount: r.WorkerCount}
+		var pipes []*model.StreamPipeConfig
+		for _, id := range r.PipeIDs {
+			if p, found := byID(e.live.Pipes, id, func(p *model.StreamPipeConfig) string { return p.ID }); found {
+				pipes = append(pipes, p)
+			}
+		}
+		for _, p := range e.live.Pipes {
+			if p.RouterID == r.ID && !slices.Contains(r.PipeIDs, p.ID) {
+				pipes = append(pipes, p)
+			}
+		}
+		for _, p := range pipes {
+			e.exported.IDs["pipe/"+r.Name+"/"+p.Name] = p.ID
+			from := "pipe " + r.Name + "/" + p.Name
+			pipe := &Pipe{
+				Name:       p.Name,
+				MatchAll:   p.MatchAll,
+				Selector:   p.Selector,
+				Processors: p.ProcessorNames,
+			}
+			if p.ChannelID != "" {
+				name, err := e.name(from, "channel", p.ChannelID)
+				if err != nil {
+					return err
+				}
+				pipe.Channel = name
+			}
+			for _, id := range p.SinkIDs {
+				name, err := e.name(from, "sink", id)
+				if err != nil {
+					return err
+				}
+				pipe.Sinks = append(pipe.Sinks, name)
+			}
+			router.Pipes = append(router.Pipes, pipe)
+		}
+		e.m.Routers = append(e.m.Routers, router)
+	}
+	return nil
+}
+
+func (e *exporter) exportSources() error {
+	connections := map[string]string{}
+	for _, c := range e.live.Connections {
+		connections[c.SourceID] = c.RouterID
+	}
+	for _, live := range e.live.Sources {
+		e.exported.IDs["source/"+live.Name] = live.ID
+		s := &Source{}
+		if err := e.omitSecrets("source", live.Name, "", live, &s.DataSourceConfig); err != nil {
+			return err
+		}
+		s.ID = ""
+		if id := connections[live.ID]; id != "" {
+			name, err := e.name("source "+live.Name, "router", id)
+			if err != nil {
+				return err
+			}
+			s.Router = name
+		}
+		// The plugin ID of a source is only an integration when one has it.
+		if id := sourceIntegration(&s.DataSourceConfig); id != "" {
+			if name, ok := e.names[id]; ok && e.exported.IDs["integration/"+name] == id {
+				s.Integration = name
+				setSourceIntegration(&s.DataSourceConfig, "")
+			}
+		}
+		e.m.Sources = append(e.m.Sources, s)
+	}
+	return nil
+}
+
+func (e *exporter) exportNotifications() error {
+	endpoints, err := e.site.Notification.List()
+	if err != nil {
+		return fmt.Errorf("failed to list notification endpoints: %w", err)
+	}
+	e.m.Notifications = endpoints
+	return nil
+}
+
+func (e *exporter) exportApplications() error {
+	templates, err := e.site.Application.ListAppTemplates()
+	if err != nil {
+		return fmt.Errorf("failed to list application templates: %w", err)
+	}
+	for _, t := range templates.Entries {
+		entry, err := e.site.Application.GetAppTemplate(t.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get application template %s: %w", t.Name, err)
+		}
+		e.m.Applications = append(e.m.Applications, &Application{Name: t.Name, Content: entry.Content})
+	}
+	return nil
+}
+
+func (e *exporter) exportSyslog() error {
+	resp, err := e.site.Syslog.Get()
+	if err != nil {
+		return fmt.Errorf("failed to get syslog config: %w", err)
+	}
+	if resp != nil && resp.Config != nil {
+		e.m.Syslog = &Syslog{Listeners: syslogListeners(resp.Config)}
+	}
+	return nil
+}
+
+// omitSecrets decodes v into out without its secret fields, which are
+// recorded as omitted under path.
+func (e *exporter) omitSecrets(kind, name, path string, v interface{}, out interface{}) error {
+	var decoded interface{}
+	b, err := json.Marshal(v)
+	if err == nil {
+		err = json.Unmarshal(b, &decoded)
+	}
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", kind, name, err)
+	}
+	var omitted []string
+	decoded = withoutSecrets(path, decoded, &omitted)
+	for _, p := range omitted {
+		e.omitted(kind, name, p)
+	}
+	if b, err = json.Marshal(decoded); err != nil {
+		return fmt.Errorf("%s %s: %w", kind, name, err)
+	}
+	return json.Unmarshal(b, out)
+}
+
+func (e *exporter) omitted(kind, name, path string) {
+	e.exported.OmittedSecrets = append(e.exported.OmittedSecrets, kind+"/"+name+": "+path)
+}
+
+// withoutSecrets returns v without the fields whose key is sensitive, and
+// adds the paths of those that had a value to omitted.
+func withoutSecrets(path string, v interface{}, omitted *[]string) interface{} {
+	redactor := client.DefaultRedactor()
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for _, k := range sortedKeys(v) {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			if redactor.IsSensitiveKey(k) {
+				if !isZero(v[k]) {
+					*omitted = append(*omitted, p)
+				}
+				continue
+			}
+			out[k] = withoutSecrets(p, v[k], omitted)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, x := range v {
+			out[i] = withoutSecrets(fmt.Sprintf("%s[%d]", path, i), x, omitted)
+		}
+		return out
+	}
+	return v
+}
+
+// sinkIntegration returns the integration ID of the plugin config of a sink.
+func sinkIntegration(sink *model.DataSinkConfig) string {
+	switch {
+	case sink.S3 != nil:
+		return sink.S3.IntegrationID
+	case sink.Firehose != nil:
+		return sink.Firehose.IntegrationID
+	case sink.Lambda != nil:
+		return sink.Lambda.IntegrationID
+	case sink.Kinesis != nil:
+		return sink.Kinesis.IntegrationID
+	case sink.PROM != nil:
+		return sink.PROM.IntegrationID
+	case sink.Loki != nil:
+		return sink.Loki.IntegrationID
+	}
+	return ""
+}
+
+// sourceIntegration returns the integration ID of a kinesis source, or the
+// plugin ID of other sources.
+func sourceIntegration(source *model.DataSourceConfig) string {
+	if source.Kinesis != nil {
+		return source.Kinesis.IntegrationID
+	}
+	if source.Plugin != nil {
+		return source.Plugin.ID
+	}
+	return ""
+}
+
+// The files of a snapshot written by Write. Processor scripts, schemas and
+// application templates are written to files of their own, in the
+// directories of the same name.
+const (
+	exportFile       = "export.yaml"
+	siteFile         = "site.yaml"
+	pipelineFile     = "pipeline.yaml"
+	applicationsFile = "applications.yaml"
+)
+
+// Write writes m to dir as a snapshot that Load reads back: export.yaml
+// with the Exported record, site.yaml with the schemas, datalakes,
+// notification endpoints and syslog listeners, pipeline.yaml with the
+// integrations, processors, sinks, channels, routers and sources, and
+// applications.yaml with the application templates. dir is created if
+// needed and must be empty, so that no stale file is read back.
+func Write(m *Manifest, dir string) error {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s is not empty", dir)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	w := &snapshotWriter{dir: dir, used: map[string]bool{}}
+
+	site := &Manifest{Version: Version, Datalakes: m.Datalakes, Notifications: m.Notifications, Syslog: m.Syslog}
+	for _, sc := range m.Schemas {
+		file, err := w.content("schemas", sc.Name, ".json", sc.Content)
+		if err != nil {
+			return err
+		}
+		site.Schemas = append(site.Schemas, &Schema{Name: sc.Name, Description: sc.Description, ContentFile: file})
+	}
+	pipeline := &Manifest{
+		Version:      Version,
+		Integrations: m.Integrations,
+		Sinks:        m.Sinks,
+		Channels:     m.Channels,
+		Routers:      m.Routers,
+		Sources:      m.Sources,
+	}
+	for _, p := range m.Processors {
+		file, err := w.content("processors", p.Name, ".fpl", p.ScriptText)
+		if err != nil {
+			return err
+		}
+		c := *p
+		c.ScriptText, c.ScriptFile = "", file
+		pipeline.Processors = append(pipeline.Processors, &c)
+	}
+	applications := &Manifest{Version: Version}
+	for _, app := range m.Applications {
+		file, err := w.content("applications", app.Name, ".yaml", app.Content)
+		if err != nil {
+			return err
+		}
+		applications.Applications = append(applications.Applications, &Application{Name: app.Name, ContentFile: file})
+	}
+
+	files := []struct {
+		name string
+		m    *Manifest
+	}{
+		{exportFile, &Manifest{Version: Version, Exported: m.Exported}},
+		{siteFile, site},
+		{pipelineFile, pipeline},
+		{applicationsFile, applications},
+	}
+	var errs []error
+	for _, f := range files {
+		errs = append(errs, w.manifest(f.name, f.m))
+	}
+	return errors.Join(errs...)
+}
+
+type snapshotWriter struct {
+	dir string
+	// used holds the files written, to keep names apart once sanitized.
+	used map[string]bool
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// content writes text to a file of subdir named after name, and returns
+// its path relative to the snapshot.
+func (w *snapshotWriter) content(subdir, name, ext, text string) (string, error) {
+	base := strings.Trim(unsafeFileChars.ReplaceAllString(name, "_"), ".")
+	if base == "" {
+		base = "_"
+	}
+	file := filepath.Join(subdir, base+ext)
+	for n := 2; w.used[file]; n++ {
+		file = filepath.Join(subdir, fmt.Sprintf("%s-%d%s", base, n, ext))
+	}
+	w.used[file] = true
+	if err := os.MkdirAll(filepath.Join(w.dir, subdir), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(w.dir, file), []byte(text), 0o644); err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(file), nil
+}
+
+// manifest writes m as YAML, unless it has no resources.
+func (w *snapshotWriter) manifest(name string, m *Manifest) error {
+	doc, err := toMap(m)
+	if err != nil {
+		return err
+	}
+	if len(doc) == 1 {
+		return nil
+	}
+	// The IDs of sinks and sources and of their integrations were replaced
+	// by names and their secrets left out, but some of these fields are not
+	// omitempty.
+	for _, kind := range []string{"sinks", "sources"} {
+		entries, _ := doc[kind].([]interface{})
+		for _, entry := range entries {
+			dropUnset(entry)
+		}
+	}
+	b, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(w.dir, name), b, 0o644)
+}
+
+// dropUnset removes the empty "id", "integrationID" and secret fields of a
+// decoded JSON object and of the objects it holds.
+func dropUnset(v interface{}) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for k, x := range obj {
+		if x == "" && (k == "id" || k == "integrationID" || client.DefaultRedactor().IsSensitiveKey(k)) {
+			delete(obj, k)
+			continue
+		}
+		dropUnset(x)
+	}
+}
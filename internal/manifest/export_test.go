@@ -0,0 +1,81 @@
+package manifest_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/SecurityDo/ingext_api/internal/manifest"
+	"github.com/SecurityDo/ingext_api/model"
+)
+
+func TestExportImport(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+	}{
+		{"pipeline", pipelineYAML},
+		{"site", siteYAML},
+		{"both", pipelineYAML + siteYAML},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, src := newSite(t)
+			apply(t, src, parse(t, tc.body), manifest.Options{})
+			snapshot, err := manifest.Export(src, "https://src.example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir := filepath.Join(t.TempDir(), "snapshot")
+			if err := manifest.Write(snapshot, dir); err != nil {
+				t.Fatal(err)
+			}
+			if err := manifest.Write(snapshot, dir); err == nil {
+				t.Error("Write to a non-empty directory succeeded")
+			}
+			loaded, err := manifest.Load(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loaded.Exported == nil || loaded.Exported.Site != "https://src.example.com" {
+				t.Errorf("Exported = %+v", loaded.Exported)
+			}
+
+			_, dst := newSite(t)
+			// Shift the IDs of dst, so that references are resolved by
+			// name rather than by the IDs of src.
+			if _, err := dst.Platform.AddDataSink(&model.DataSinkConfig{Name: "tmp", Type: "hec"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := deleteSinks(dst); err != nil {
+				t.Fatal(err)
+			}
+			for name, action := range apply(t, dst, loaded, manifest.Options{}) {
+				if action != manifest.Created {
+					t.Errorf("import: %s %s, want created", name, action)
+				}
+			}
+			if got, want := export(t, dst), export(t, src); !reflect.DeepEqual(got, want) {
+				t.Errorf("imported site differs:\ngot  %+v\nwant %+v", got, want)
+			}
+			for name, action := range apply(t, dst, loaded, manifest.Options{}) {
+				if action != manifest.Unchanged {
+					t.Errorf("import again: %s %s, want unchanged", name, action)
+				}
+			}
+		})
+	}
+}
+
+func deleteSinks(site *manifest.Site) error {
+	sinks, err := site.Platform.ListDataSink()
+	if err != nil {
+		return err
+	}
+	for _, s := range sinks {
+		if err := site.Platform.DeleteDataSink(s.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,414 @@
+// Package manifest reads declarative pipeline manifests: YAML files naming
+// the sources, sinks, channels, routers and pipes, processors and
+// integrations of a site, and optionally its schemas, datalakes,
+// notification endpoints, application templates and syslog listeners.
+// Apply creates or updates the site configuration to match a manifest;
+// Export snapshots a site as a manifest.
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/SecurityDo/ingext_api/model"
+	"sigs.k8s.io/yaml"
+)
+
+// Version is the manifest format version written and accepted by this package.
+const Version = 1
+
+// DefaultProcessorType is the type of processors that do not set one.
+const DefaultProcessorType = "fpl_processor"
+
+// DefaultWorkerCount is the worker count of the routers created without one.
+// Existing routers keep theirs.
+const DefaultWorkerCount = 1
+
+// Manifest describes a pipeline. Resources refer to each other by name; the
+// names are resolved to IDs against the manifest and the live site.
+type Manifest struct {
+	Version int `json:"version,omitempty"`
+	// Exported is set in snapshots written by Export.
+	Exported      *Exported               `json:"exported,omitempty"`
+	Integrations  []*Integration          `json:"integrations,omitempty"`
+	Schemas       []*Schema               `json:"schemas,omitempty"`
+	Datalakes     []*Datalake             `json:"datalakes,omitempty"`
+	Processors    []*Processor            `json:"processors,omitempty"`
+	Sinks         []*Sink                 `json:"sinks,omitempty"`
+	Channels      []*Channel              `json:"channels,omitempty"`
+	Routers       []*Router               `json:"routers,omitempty"`
+	Sources       []*Source               `json:"sources,omitempty"`
+	Notifications []*model.EndpointConfig `json:"notifications,omitempty"`
+	Applications  []*Application          `json:"applications,omitempty"`
+	Syslog        *Syslog                 `json:"syslog,omitempty"`
+}
+
+// Exported records the site and time a snapshot was exported.
+type Exported struct {
+	Site string    `json:"site,omitempty"`
+	At   time.Time `json:"at"`
+	// IDs maps "<kind>/<name>" to the ID the resource had on the site.
+	IDs map[string]string `json:"ids,omitempty"`
+	// OmittedSecrets lists the secret fields left out of the snapshot, as
+	// "<kind>/<name>: <path>". They must be set again after an import.
+	OmittedSecrets []string `json:"omittedSecrets,omitempty"`
+}
+
+// Integration is a third-party connection (model.Integration).
+type Integration struct {
+	Name        string          `json:"name"`
+	Integration string          `json:"integration"`
+	Description string          `json:"description,omitempty"`
+	Config      json.RawMessage `json:"config,omitempty"`
+	Secret      json.RawMessage `json:"secret,omitempty"`
+	Tags        []*model.Tag    `json:"tags,omitempty"`
+}
+
+// Schema is a datalake schema. The JSON schema is given inline with content,
+// or with contentFile, relative to the manifest.
+type Schema struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Content     string `json:"content,omitempty"`
+	ContentFile string `json:"contentFile,omitempty"`
+}
+
+// Datalake is a datalake with its indexes. Integration names the integration
+// holding the storage of a lake not managed by the site.
+type Datalake struct {
+	Name        string           `json:"name"`
+	Managed     bool             `json:"managed,omitempty"`
+	Integration string           `json:"integration,omitempty"`
+	Indexes     []*DatalakeIndex `json:"indexes,omitempty"`
+}
+
+// DatalakeIndex is an index of a datalake, with the schema of its data.
+type DatalakeIndex struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema,omitempty"`
+}
+
+// Application is an application template. Its YAML definition is given
+// inline with content, or with contentFile, relative to the manifest; Name
+// is the name the definition declares.
+type Application struct {
+	Name        string `json:"name"`
+	Content     string `json:"content,omitempty"`
+	ContentFile string `json:"contentFile,omitempty"`
+}
+
+// SyslogListeners are the syslog listeners a site can run.
+var SyslogListeners = []string{"udp", "tcp", "tls", "tls-rfc6587"}
+
+// Syslog lists the syslog listeners enabled on the site.
+type Syslog struct {
+	Listeners []string `json:"listeners"`
+}
+
+// Processor is a processor script (model.FPLScript). The script is given
+// inline with scriptText, or with scriptFile, relative to the manifest.
+type Processor struct {
+	Name        string       `json:"name"`
+	Type        string       `json:"type,omitempty"`
+	Group       string       `json:"group,omitempty"`
+	Description string       `json:"description,omitempty"`
+	ScriptText  string       `json:"scriptText,omitempty"`
+	ScriptFile  string       `json:"scriptFile,omitempty"`
+	Tags        []*model.Tag `json:"tags,omitempty"`
+}
+
+// Sink is a data sink with the fields of model.DataSinkConfig. Integration
+// names the integration its plugin config refers to.
+type Sink struct {
+	model.DataSinkConfig
+	Integration string `json:"integration,omitempty"`
+}
+
+// Channel fans a pipe out to several sinks.
+type Channel struct {
+	Name  string   `json:"name"`
+	Sinks []string `json:"sinks,omitempty"`
+}
+
+// Router runs the pipes of the sources connected to it.
+type Router struct {
+	Name string `json:"name"`
+	// WorkerCount is left to the site when zero (see DefaultWorkerCount).
+	WorkerCount int     `json:"workerCount,omitempty"`
+	Pipes       []*Pipe `json:"pipes,omitempty"`
+}
+
+// Pipe sends the events of its router matching Selector (all of them if
+// MatchAll) through Processors to a channel and/or sinks.
+type Pipe struct {
+	Name       string   `json:"name"`
+	MatchAll   bool     `json:"matchAll,omitempty"`
+	Selector   string   `json:"selector,omitempty"`
+	Processors []string `json:"processors,omitempty"`
+	Channel    string   `json:"channel,omitempty"`
+	Sinks      []string `json:"sinks,omitempty"`
+}
+
+// Source is a data source with the fields of model.DataSourceConfig. Router
+// names the router it is connected to; Integration names the integration of
+// a plugin or kinesis source.
+type Source struct {
+	model.DataSourceConfig
+	Router      string `json:"router,omitempty"`
+	Integration string `json:"integration,omitempty"`
+}
+
+// Load reads the manifest at path. A directory is read as one manifest made
+// of its *.yaml and *.yml files, e.g. an exported snapshot.
+func Load(path string) (*Manifest, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if !info.IsDir() {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		m, err := Parse(b, filepath.Dir(path))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return m, nil
+	}
+
+	var files []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, _ := filepath.Glob(filepath.Join(path, pattern))
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no manifest files (*.yaml, *.yml) in %s", path)
+	}
+	sort.Strings(files)
+	all := &Manifest{Version: Version}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		m, err := decode(b, path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if m.Exported != nil {
+			if all.Exported != nil {
+				return nil, fmt.Errorf("%s: exported is already set by another file", file)
+			}
+			all.Exported = m.Exported
+		}
+		if m.Syslog != nil {
+			if all.Syslog != nil {
+				return nil, fmt.Errorf("%s: syslog is already set by another file", file)
+			}
+			all.Syslog = m.Syslog
+		}
+		all.Integrations = append(all.Integrations, m.Integrations...)
+		all.Schemas = append(all.Schemas, m.Schemas...)
+		all.Datalakes = append(all.Datalakes, m.Datalakes...)
+		all.Processors = append(all.Processors, m.Processors...)
+		all.Sinks = append(all.Sinks, m.Sinks...)
+		all.Channels = append(all.Channels, m.Channels...)
+		all.Routers = append(all.Routers, m.Routers...)
+		all.Sources = append(all.Sources, m.Sources...)
+		all.Notifications = append(all.Notifications, m.Notifications...)
+		all.Applications = append(all.Applications, m.Applications...)
+	}
+	if err := all.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return all, nil
+}
+
+// Parse decodes and validates a manifest. Processor script files are read
+// relative to dir.
+func Parse(b []byte, dir string) (*Manifest, error) {
+	m, err := decode(b, dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// decode decodes a manifest and fills in defaults.
+func decode(b []byte, dir string) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.UnmarshalStrict(b, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.Version == 0 {
+		m.Version = Version
+	}
+	if m.Version != Version {
+		return nil, fmt.Errorf("unsupported manifest version %d (want %d)", m.Version, Version)
+	}
+	for _, p := range m.Processors {
+		if p.Type == "" {
+			p.Type = DefaultProcessorType
+		}
+		if err := readContent(dir, &p.ScriptText, &p.ScriptFile, "scriptText", "scriptFile"); err != nil {
+			return nil, fmt.Errorf("processor %s: %w", p.Name, err)
+		}
+	}
+	for _, sc := range m.Schemas {
+		if err := readContent(dir, &sc.Content, &sc.ContentFile, "content", "contentFile"); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", sc.Name, err)
+		}
+	}
+	for _, app := range m.Applications {
+		if err := readContent(dir, &app.Content, &app.ContentFile, "content", "contentFile"); err != nil {
+			return nil, fmt.Errorf("application %s: %w", app.Name, err)
+		}
+	}
+	for _, r := range m.Routers {
+		for _, p := range r.Pipes {
+			if p.Selector == "" {
+				p.MatchAll = true
+			}
+		}
+	}
+	return &m, nil
+}
+
+// readContent reads *file, relative to dir, into *text and clears *file.
+// The names are those of the manifest fields, for errors.
+func readContent(dir string, text, file *string, textName, fileName string) error {
+	if *file == "" {
+		return nil
+	}
+	if *text != "" {
+		return fmt.Errorf("set %s or %s, not both", textName, fileName)
+	}
+	path := *file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	*text, *file = string(b), ""
+	return nil
+}
+
+// validate checks that every resource has a name, unique within its kind
+// (pipes: within their router).
+func (m *Manifest) validate() error {
+	checks := []struct {
+		kind  string
+		names []string
+	}{
+		{"integration", names(m.Integrations, func(i *Integration) string { return i.Name })},
+		{"schema", names(m.Schemas, func(s *Schema) string { return s.Name })},
+		{"datalake", names(m.Datalakes, func(d *Datalake) string { return d.Name })},
+		{"processor", names(m.Processors, func(p *Processor) string { return p.Name })},
+		{"sink", names(m.Sinks, func(s *Sink) string { return s.Name })},
+		{"channel", names(m.Channels, func(c *Channel) string { return c.Name })},
+		{"router", names(m.Routers, func(r *Router) string { return r.Name })},
+		{"source", names(m.Sources, func(s *Source) string { return s.Name })},
+		{"notification", names(m.Notifications, func(n *model.EndpointConfig) string { return n.Name })},
+		{"application", names(m.Applications, func(a *Application) string { return a.Name })},
+	}
+	for _, r := range m.Routers {
+		checks = append(checks, struct {
+			kind  string
+			names []string
+		}{"pipe of router " + r.Name, names(r.Pipes, func(p *Pipe) string { return p.Name })})
+	}
+	for _, d := range m.Datalakes {
+		checks = append(checks, struct {
+			kind  string
+			names []string
+		}{"index of datalake " + d.Name, names(d.Indexes, func(i *DatalakeIndex) string { return i.Name })})
+	}
+	for _, c := range checks {
+		seen := map[string]bool{}
+		for i, name := range c.names {
+			if name == "" {
+				return fmt.Errorf("%s #%d has no name", c.kind, i+1)
+			}
+			if seen[name] {
+				return fmt.Errorf("duplicate %s %q", c.kind, name)
+			}
+			seen[name] = true
+		}
+	}
+	for _, s := range m.Sinks {
+		if s.Type == "" {
+			return fmt.Errorf("sink %s has no type", s.Name)
+		}
+		if s.ID != "" {
+			return fmt.Errorf("sink %s: id is assigned by the site, remove it", s.Name)
+		}
+	}
+	for _, s := range m.Sources {
+		if s.Type == "" {
+			return fmt.Errorf("source %s has no type", s.Name)
+		}
+		if s.ID != "" {
+			return fmt.Errorf("source %s: id is assigned by the site, remove it", s.Name)
+		}
+	}
+	for _, i := range m.Integrations {
+		if i.Integration == "" {
+			return fmt.Errorf("integration %s has no integration type", i.Name)
+		}
+	}
+	for _, n := range m.Notifications {
+		if n.Integration == "" {
+			return fmt.Errorf("notification %s has no integration type", n.Name)
+		}
+	}
+	for _, sc := range m.Schemas {
+		if sc.Content == "" {
+			return fmt.Errorf("schema %s has no content", sc.Name)
+		}
+	}
+	for _, app := range m.Applications {
+		if app.Content == "" {
+			return fmt.Errorf("application %s has no content", app.Name)
+		}
+		// The site names a template after its definition, so a differing
+		// name would never match the template applied.
+		var template model.ApplicationTemplate
+		if err := yaml.Unmarshal([]byte(app.Content), &template); err != nil {
+			return fmt.Errorf("application %s: invalid content: %w", app.Name, err)
+		}
+		if template.ApplicationConfig == nil || template.ApplicationConfig.Name != app.Name {
+			var declared string
+			if template.ApplicationConfig != nil {
+				declared = template.ApplicationConfig.Name
+			}
+			return fmt.Errorf("application %s: content declares application.metadata.name %q, want %q", app.Name, declared, app.Name)
+		}
+	}
+	if m.Syslog != nil {
+		for _, l := range m.Syslog.Listeners {
+			if !slices.Contains(SyslogListeners, l) {
+				return fmt.Errorf("unknown syslog listener %q (want one of %v)", l, SyslogListeners)
+			}
+		}
+	}
+	return nil
+}
+
+func names[T any](items []T, name func(T) string) []string {
+	out := make([]string, len(items))
+	for i, item := range items {
+		out[i] = name(item)
+	}
+	return out
+}
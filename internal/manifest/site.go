@@ -0,0 +1,240 @@
+package manifest
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/SecurityDo/ingext_api/model"
+)
+
+// The site-wide resources of a manifest: schemas, datalakes and their
+// indexes, notification endpoints, application templates and the syslog
+// listeners. Their steps only list the site when the manifest has some.
+
+func (a *applier) applySchemas(m *Manifest) error {
+	if len(m.Schemas) == 0 {
+		return nil
+	}
+	schemas, err := a.site.Datalake.ListSchema()
+	if err != nil {
+		return fmt.Errorf("failed to list schemas: %w", err)
+	}
+	for _, sc := range m.Schemas {
+		live, found, err := byName("schema", schemas, sc.Name, func(e *model.SchemaEntry) string { return e.Name })
+		if err != nil {
+			return err
+		}
+		_, err = upsert(a, "schema", sc.Name, sc, live, found, entryOps[*model.SchemaEntry]{
+			add: func(e *model.SchemaEntry) (string, error) {
+				return e.Name, a.site.Datalake.AddSchema(e.Name, e.Description, e.Content)
+			},
+			update: func(e *model.SchemaEntry) error {
+				return a.site.Datalake.UpdateSchema(e.Name, e.Description, e.Content)
+			},
+			id: func(e *model.SchemaEntry) string { return e.Name },
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyDatalakes creates the missing datalakes and indexes. The site cannot
+// update them, so one that differs from the manifest is an error.
+func (a *applier) applyDatalakes(m *Manifest) error {
+	if len(m.Datalakes) == 0 {
+		return nil
+	}
+	lakes, err := a.site.Datalake.ListDatalake()
+	if err != nil {
+		return fmt.Errorf("failed to list datalakes: %w", err)
+	}
+	indexes, err := a.site.Datalake.ListDatalakeIndex("")
+	if err != nil {
+		return fmt.Errorf("failed to list datalake indexes: %w", err)
+	}
+	for _, d := range m.Datalakes {
+		live, found, err := byName("datalake", lakes, d.Name, func(e *model.Datalake) string { return e.Name })
+		if err != nil {
+			return err
+		}
+		desired := map[string]interface{}{
+			"name":          d.Name,
+			"managed":       d.Managed,
+			"integrationID": a.ids["integration/"+d.Integration],
+		}
+		_, err = upsert(a, "datalake", d.Name, desired, live, found, entryOps[*model.Datalake]{
+			add: func(e *model.Datalake) (string, error) {
+				return e.Name, a.site.Datalake.AddDatalake(e.Name, e.Managed, e.IntegrationID)
+			},
+			id: func(e *model.Datalake) string { return e.Name },
+		})
+		if err != nil {
+			return err
+		}
+
+		var liveIndexes []*model.DatalakeIndex
+		for _, i := range indexes {
+			if i.Datalake == d.Name {
+				liveIndexes = append(liveIndexes, i)
+			}
+		}
+		for _, i := range d.Indexes {
+			live, found, err := byName("index", liveIndexes, i.Name, func(e *model.DatalakeIndex) string { return e.DatalakeIndex })
+			if err != nil {
+				return fmt.Errorf("datalake %s: %w", d.Name, err)
+			}
+			desired := map[string]interface{}{"datalake": d.Name, "datalakeIndex": i.Name, "schemaName": i.Schema}
+			_, err = upsert(a, "index", d.Name+"/"+i.Name, desired, live, found, entryOps[*model.DatalakeIndex]{
+				add: func(e *model.DatalakeIndex) (string, error) {
+					return e.Datalake + "/" + e.DatalakeIndex, a.site.Datalake.AddDatalakeIndex(e.Datalake, e.DatalakeIndex, e.SchemaName)
+				},
+				id: func(e *model.DatalakeIndex) string { return e.Datalake + "/" + e.DatalakeIndex },
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (a *applier) applyNotifications(m *Manifest) error {
+	if len(m.Notifications) == 0 {
+		return nil
+	}
+	endpoints, err := a.site.Notification.List()
+	if err != nil {
+		return fmt.Errorf("failed to list notification endpoints: %w", err)
+	}
+	for _, n := range m.Notifications {
+		live, found, err := byName("notification", endpoints, n.Name, func(e *model.EndpointConfig) string { return e.Name })
+		if err != nil {
+			return err
+		}
+		_, err = upsert(a, "notification", n.Name, n, live, found, entryOps[*model.EndpointConfig]{
+			add: func(e *model.EndpointConfig) (string, error) {
+				if _, err := a.site.Notification.AddEndpoint(e); err != nil {
+					return "", err
+				}
+				return e.Name, nil
+			},
+			update: a.site.Notification.UpdateEndpoint,
+			id:     func(e *model.EndpointConfig) string { return e.Name },
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *applier) applyApplications(m *Manifest) error {
+	if len(m.Applications) == 0 {
+		return nil
+	}
+	templates, err := a.site.Application.ListAppTemplates()
+	if err != nil {
+		return fmt.Errorf("failed to list application templates: %w", err)
+	}
+	for _, app := range m.Applications {
+		_, found, err := byName("application", templates.Entries, app.Name, func(e *model.ApplicationTemplateConfig) string { return e.Name })
+		if err != nil {
+			return err
+		}
+		// Listed templates come without their content.
+		var live *model.AppTemplateEntry
+		if found {
+			if live, err = a.site.Application.GetAppTemplate(app.Name); err != nil {
+				return fmt.Errorf("failed to get application template %s: %w", app.Name, err)
+			}
+		}
+		name := app.Name
+		_, err = upsert(a, "application", name, map[string]interface{}{"content": app.Content}, live, found, entryOps[*model.AppTemplateEntry]{
+			add: func(e *model.AppTemplateEntry) (string, error) {
+				if _, err := a.site.Application.AddAppTemplate(e.Content); err != nil {
+					return "", err
+				}
+				return name, nil
+			},
+			update: func(e *model.AppTemplateEntry) error { return a.site.Application.UpdateAppTemplate(name, e.Content) },
+			id:     func(*model.AppTemplateEntry) string { return name },
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applySyslog registers the syslog listeners of the manifest, or updates
+// them when the site runs others.
+func (a *applier) applySyslog(m *Manifest) error {
+	if m.Syslog == nil {
+		return nil
+	}
+	resp, err := a.site.Syslog.Get()
+	if err != nil {
+		return fmt.Errorf("failed to get syslog config: %w", err)
+	}
+	var config *model.SyslogConfig
+	if resp != nil {
+		config = resp.Config
+	}
+	want := sortedListeners(m.Syslog.Listeners)
+	result := &Result{Kind: "syslog", Name: "syslog", Action: Unchanged}
+	switch live := syslogListeners(config); {
+	case config == nil:
+		result.Action = Created
+		result.Diff = []FieldDiff{{Path: "listeners", New: want}}
+		if !a.opts.DryRun {
+			if _, err := a.site.Syslog.Register(want); err != nil {
+				return fmt.Errorf("failed to register syslog listeners: %w", err)
+			}
+		}
+	case !slices.Equal(live, want):
+		result.Action = Configured
+		result.Diff = []FieldDiff{{Path: "listeners", Old: live, New: want}}
+		if !a.opts.DryRun {
+			if _, err := a.site.Syslog.Update(want); err != nil {
+				return fmt.Errorf("failed to update syslog listeners: %w", err)
+			}
+		}
+	}
+	a.results = append(a.results, result)
+	return nil
+}
+
+// syslogListeners returns the listeners enabled by config, in
+// SyslogListeners order.
+func syslogListeners(config *model.SyslogConfig) []string {
+	if config == nil {
+		return nil
+	}
+	enabled := map[string]bool{
+		"udp":         config.SyslogUDP,
+		"tcp":         config.SyslogTCP,
+		"tls":         config.SyslogTLS,
+		"tls-rfc6587": config.TLSRfc6587,
+	}
+	listeners := []string{}
+	for _, l := range SyslogListeners {
+		if enabled[l] {
+			listeners = append(listeners, l)
+		}
+	}
+	return listeners
+}
+
+// sortedListeners returns listeners without duplicates, in SyslogListeners
+// order.
+func sortedListeners(listeners []string) []string {
+	out := []string{}
+	for _, l := range SyslogListeners {
+		if slices.Contains(listeners, l) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
@@ -0,0 +1,236 @@
+package topology
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats are the names of the renderers of a graph.
+var Formats = []string{"tree", "dot", "mermaid"}
+
+// Render writes g to w in format, one of Formats.
+func Render(w io.Writer, g *Graph, format string) error {
+	switch format {
+	case "tree":
+		return WriteTree(w, g)
+	case "dot":
+		return WriteDOT(w, g)
+	case "mermaid":
+		return WriteMermaid(w, g)
+	}
+	return fmt.Errorf("unknown graph format %q (want one of %s)", format, strings.Join(Formats, ", "))
+}
+
+// WriteTree writes g as an ASCII tree from its sources, or any other node
+// nothing connects to. A node reached again is marked "(see above)" instead
+// of repeating what it connects to.
+func WriteTree(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+	if len(g.Nodes) == 0 {
+		fmt.Fprintln(bw, "(empty pipeline)")
+		return bw.Flush()
+	}
+	shown := map[string]bool{}
+	var walk func(n *Node, prefix, branch, indent string)
+	walk = func(n *Node, prefix, branch, indent string) {
+		children := g.children(n)
+		label := treeLabel(n)
+		if shown[n.Key] && len(children) > 0 {
+			fmt.Fprintf(bw, "%s%s%s (see above)\n", prefix, branch, label)
+			return
+		}
+		shown[n.Key] = true
+		fmt.Fprintf(bw, "%s%s%s\n", prefix, branch, label)
+		for i, c := range children {
+			if i == len(children)-1 {
+				walk(c, prefix+indent, "└── ", "    ")
+			} else {
+				walk(c, prefix+indent, "├── ", "│   ")
+			}
+		}
+	}
+	for _, n := range g.roots() {
+		walk(n, "", "", "")
+	}
+	// Nodes on a cycle only.
+	for _, n := range g.Nodes {
+		if !shown[n.Key] {
+			walk(n, "", "", "")
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteDOT writes g as a Graphviz digraph, e.g. for 'dot -Tsvg'.
+func WriteDOT(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph pipeline {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, `  node [fontname="Helvetica", fontsize=10];`)
+	for _, n := range g.Nodes {
+		attrs := []string{
+			"label=" + dotQuote(strings.Join(labelLines(n), "\n")),
+			"shape=" + dotShapes[n.Kind],
+		}
+		var style []string
+		if n.Kind == Processor {
+			style = append(style, "rounded")
+		}
+		if n.Missing {
+			style = append(style, "dashed")
+		}
+		if len(style) > 0 {
+			attrs = append(attrs, "style="+dotQuote(strings.Join(style, ",")))
+		}
+		if n.Errors > 0 {
+			attrs = append(attrs, "color=red")
+		} else if n.Alerts > 0 {
+			attrs = append(attrs, "color=orange")
+		}
+		fmt.Fprintf(bw, "  %s [%s];\n", dotQuote(n.Key), strings.Join(attrs, ", "))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(bw, "  %s -> %s;\n", dotQuote(e.From), dotQuote(e.To))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// WriteMermaid writes g as a Mermaid flowchart, e.g. for a Markdown
+// document.
+func WriteMermaid(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "flowchart LR")
+	ids := map[string]string{}
+	var failing, alerting, missing []string
+	for i, n := range g.Nodes {
+		id := "n" + strconv.Itoa(i+1)
+		ids[n.Key] = id
+		shape := mermaidShapes[n.Kind]
+		lines := labelLines(n)
+		for j, l := range lines {
+			lines[j] = mermaidEscape(l)
+		}
+		fmt.Fprintf(bw, "  %s%s\"%s\"%s\n", id, shape[0], strings.Join(lines, "<br/>"), shape[1])
+		switch {
+		case n.Missing:
+			missing = append(missing, id)
+		case n.Errors > 0:
+			failing = append(failing, id)
+		case n.Alerts > 0:
+			alerting = append(alerting, id)
+		}
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(bw, "  %s --> %s\n", ids[e.From], ids[e.To])
+	}
+	for _, class := range []struct {
+		name, style string
+		ids         []string
+	}{
+		{"errors", "stroke:#d00,stroke-width:2px", failing},
+		{"alerts", "stroke:#e80,stroke-width:2px", alerting},
+		{"missing", "stroke-dasharray:4 4", missing},
+	} {
+		if len(class.ids) > 0 {
+			fmt.Fprintf(bw, "  classDef %s %s\n", class.name, class.style)
+			fmt.Fprintf(bw, "  class %s %s\n", strings.Join(class.ids, ","), class.name)
+		}
+	}
+	return bw.Flush()
+}
+
+var dotShapes = map[Kind]string{
+	Source:    "invhouse",
+	Router:    "diamond",
+	Pipe:      "box",
+	Processor: "box",
+	Channel:   "hexagon",
+	Sink:      "cylinder",
+}
+
+// mermaidShapes are the delimiters of the node shape of each kind.
+var mermaidShapes = map[Kind][2]string{
+	Source:    {"([", "])"},
+	Router:    {"{", "}"},
+	Pipe:      {"[", "]"},
+	Processor: {"[[", "]]"},
+	Channel:   {"{{", "}}"},
+	Sink:      {"[(", ")]"},
+}
+
+// treeLabel is the line of n in a tree, e.g.
+// `sink archive (s3) [events 12.5/s, 2 errors]`.
+func treeLabel(n *Node) string {
+	label := string(n.Kind) + " " + n.Name
+	if n.Detail != "" {
+		label += " (" + n.Detail + ")"
+	}
+	if a := annotations(n); len(a) > 0 {
+		label += " [" + strings.Join(a, ", ") + "]"
+	}
+	return label
+}
+
+// labelLines are the lines of the label of n in a diagram: its name, its
+// kind and detail, then its annotations if any.
+func labelLines(n *Node) []string {
+	kind := string(n.Kind)
+	if n.Detail != "" {
+		kind += ": " + n.Detail
+	}
+	lines := []string{n.Name, kind}
+	if a := annotations(n); len(a) > 0 {
+		lines = append(lines, strings.Join(a, ", "))
+	}
+	return lines
+}
+
+func annotations(n *Node) []string {
+	var a []string
+	if n.Missing {
+		a = append(a, "not found")
+	}
+	for _, r := range n.Throughput {
+		a = append(a, r.Metric+" "+formatRate(r.PerSecond)+"/s")
+	}
+	if n.Errors > 0 {
+		a = append(a, plural(n.Errors, "error"))
+	}
+	if n.Alerts > 0 {
+		a = append(a, plural(n.Alerts, "alert"))
+	}
+	return a
+}
+
+func formatRate(v float64) string {
+	switch {
+	case v >= 100:
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	case v >= 1:
+		return strconv.FormatFloat(v, 'f', 1, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return strconv.Itoa(n) + " " + word + "s"
+}
+
+// dotQuote quotes s as a DOT string; newlines become line breaks.
+func dotQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
+
+// mermaidEscape replaces the characters that would end or mark up a Mermaid
+// label by entity codes.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer("#", "#35;", `"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
@@ -0,0 +1,135 @@
+package topology_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/SecurityDo/ingext_api/api"
+	"github.com/SecurityDo/ingext_api/internal/topology"
+	"github.com/SecurityDo/ingext_api/model"
+)
+
+// testGraph has a router fanning out to two pipes, a source connected to
+// nothing and names to escape.
+func testGraph() *topology.Graph {
+	g := topology.Build(&api.ListConfigsResponse{
+		Sources: []*model.DataSourceConfig{
+			{ID: "s1", Name: `web "hec"`, Type: "hec"},
+			{ID: "s2", Name: "orphan [old]", Type: "s3"},
+		},
+		Routers: []*model.RouterConfig{{ID: "r1", Name: "web", PipeIDs: []string{"p1", "p2"}}},
+		Pipes: []*model.StreamPipeConfig{
+			{ID: "p1", Name: "main", RouterID: "r1", MatchAll: true, ProcessorNames: []string{"parse"}, SinkIDs: []string{"k1"}},
+			{ID: "p2", Name: "errors", RouterID: "r1", Selector: `level == "error" && n < 3`, ChannelID: "c1"},
+		},
+		Channels:    []*model.ChannelConfig{{ID: "c1", Name: "fan{out}", SinkIDs: []string{"k1", "k2", "k9"}}},
+		Sinks:       []*model.DataSinkConfig{{ID: "k1", Name: "lake", Type: "dataLake"}, {ID: "k2", Name: "arch<ive> #2", Type: "s3"}},
+		Connections: []*model.RouterInput{{SourceID: "s1", RouterID: "r1"}},
+		ErrorStates: []*model.ComponentErrorState{
+			{ID: "k2", Errors: []*model.PluginNotification{{Subject: "access denied"}}},
+			{ID: "s2", Alerts: []*model.PluginNotification{{}, {}}},
+		},
+	})
+	for _, n := range g.Nodes {
+		if n.Key == "source/s1" {
+			n.SetThroughput([]*api.PlatformMetric{{Name: "events", Values: []float64{1000, 800}}}, 15*time.Minute)
+		}
+	}
+	return g
+}
+
+func TestRender(t *testing.T) {
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{"tree", `source web "hec" (hec) [events 2.0/s]
+└── router web
+    ├── pipe main (match all)
+    │   └── processor parse
+    │       └── sink lake (dataLake)
+    └── pipe errors (level == "error" && n < 3)
+        └── channel fan{out}
+            ├── sink lake (dataLake)
+            ├── sink arch<ive> #2 (s3) [1 error]
+            └── sink k9 [not found]
+source orphan [old] (s3) [2 alerts]
+`},
+		{"dot", `digraph pipeline {
+  rankdir=LR;
+  node [fontname="Helvetica", fontsize=10];
+  "source/s1" [label="web \"hec\"\nsource: hec\nevents 2.0/s", shape=invhouse];
+  "source/s2" [label="orphan [old]\nsource: s3\n2 alerts", shape=invhouse, color=orange];
+  "router/r1" [label="web\nrouter", shape=diamond];
+  "pipe/p1" [label="main\npipe: match all", shape=box];
+  "pipe/p2" [label="errors\npipe: level == \"error\" && n < 3", shape=box];
+  "channel/c1" [label="fan{out}\nchannel", shape=hexagon];
+  "sink/k1" [label="lake\nsink: dataLake", shape=cylinder];
+  "sink/k2" [label="arch<ive> #2\nsink: s3\n1 error", shape=cylinder, color=red];
+  "processor/p1/0" [label="parse\nprocessor", shape=box, style="rounded"];
+  "sink/k9" [label="k9\nsink\nnot found", shape=cylinder, style="dashed"];
+  "source/s1" -> "router/r1";
+  "router/r1" -> "pipe/p1";
+  "router/r1" -> "pipe/p2";
+  "pipe/p1" -> "processor/p1/0";
+  "processor/p1/0" -> "sink/k1";
+  "pipe/p2" -> "channel/c1";
+  "channel/c1" -> "sink/k1";
+  "channel/c1" -> "sink/k2";
+  "channel/c1" -> "sink/k9";
+}
+`},
+		{"mermaid", `flowchart LR
+  n1(["web #quot;hec#quot;<br/>source: hec<br/>events 2.0/s"])
+  n2(["orphan [old]<br/>source: s3<br/>2 alerts"])
+  n3{"web<br/>router"}
+  n4["main<br/>pipe: match all"]
+  n5["errors<br/>pipe: level == #quot;error#quot; && n #lt; 3"]
+  n6{{"fan{out}<br/>channel"}}
+  n7[("lake<br/>sink: dataLake")]
+  n8[("arch#lt;ive#gt; #35;2<br/>sink: s3<br/>1 error")]
+  n9[["parse<br/>processor"]]
+  n10[("k9<br/>sink<br/>not found")]
+  n1 --> n3
+  n3 --> n4
+  n3 --> n5
+  n4 --> n9
+  n9 --> n7
+  n5 --> n6
+  n6 --> n7
+  n6 --> n8
+  n6 --> n10
+  classDef errors stroke:#d00,stroke-width:2px
+  class n8 errors
+  classDef alerts stroke:#e80,stroke-width:2px
+  class n2 alerts
+  classDef missing stroke-dasharray:4 4
+  class n10 missing
+`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := topology.Render(&buf, testGraph(), tc.format); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+	if err := topology.Render(&bytes.Buffer{}, testGraph(), "svg"); err == nil {
+		t.Error("Render svg: no error")
+	}
+}
+
+func TestWriteTreeEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := topology.WriteTree(&buf, topology.Build(&api.ListConfigsResponse{})); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "(empty pipeline)\n" {
+		t.Errorf("got %q", got)
+	}
+}
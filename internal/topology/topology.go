@@ -0,0 +1,258 @@
+// Package topology builds the graph of the stream pipeline of a site from
+// its configs: sources feed routers, routers run pipes, pipes run their
+// processors and write to a channel and/or sinks, and channels fan out to
+// sinks. The graph renders as an ASCII tree, Graphviz DOT or Mermaid.
+package topology
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/SecurityDo/ingext_api/api"
+)
+
+// Kind is the kind of a pipeline component.
+type Kind string
+
+const (
+	Source    Kind = "source"
+	Router    Kind = "router"
+	Pipe      Kind = "pipe"
+	Processor Kind = "processor"
+	Channel   Kind = "channel"
+	Sink      Kind = "sink"
+)
+
+// Node is a component of the pipeline. Processors are a node per pipe that
+// runs them.
+type Node struct {
+	// Key identifies the node in the graph: "<kind>/<component id>", or
+	// "processor/<pipe id>/<position>".
+	Key         string `json:"key"`
+	Kind        Kind   `json:"kind"`
+	Name        string `json:"name"`
+	ComponentID string `json:"componentID,omitempty"`
+	// Detail is the type of a source or sink, or the selector of a pipe.
+	Detail string `json:"detail,omitempty"`
+	// Missing is set on the components referred to but not configured.
+	Missing bool `json:"missing,omitempty"`
+
+	Errors     int     `json:"errors,omitempty"`
+	Alerts     int     `json:"alerts,omitempty"`
+	Throughput []*Rate `json:"throughput,omitempty"`
+}
+
+// Rate is the mean rate of a metric of a component over a time window.
+type Rate struct {
+	Metric    string  `json:"metric"`
+	Unit      string  `json:"unit,omitempty"`
+	PerSecond float64 `json:"perSecond"`
+}
+
+// Edge is a connection from the node keyed From to the one keyed To.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Graph is the pipeline of a site. Nodes are in config order: sources,
+// routers, pipes, channels and sinks, then processors and the components
+// referred to but not configured.
+type Graph struct {
+	Nodes []*Node `json:"nodes"`
+	Edges []*Edge `json:"edges"`
+
+	nodes map[string]*Node
+	edges map[Edge]bool
+}
+
+// Build returns the graph of configs. Error states are counted on the
+// components they belong to.
+func Build(configs *api.ListConfigsResponse) *Graph {
+	g := &Graph{Nodes: []*Node{}, Edges: []*Edge{}, nodes: map[string]*Node{}, edges: map[Edge]bool{}}
+
+	for _, s := range configs.Sources {
+		g.add(&Node{Kind: Source, ComponentID: s.ID, Name: s.Name, Detail: s.Type})
+	}
+	for _, r := range configs.Routers {
+		g.add(&Node{Kind: Router, ComponentID: r.ID, Name: r.Name})
+	}
+	// Pipes in router order, then those of no listed router.
+	pipes := map[string]int{}
+	for i, p := range configs.Pipes {
+		pipes[p.ID] = i
+	}
+	for _, r := range configs.Routers {
+		for _, id := range r.PipeIDs {
+			if i, ok := pipes[id]; ok {
+				p := configs.Pipes[i]
+				g.add(&Node{Kind: Pipe, ComponentID: p.ID, Name: p.Name, Detail: selector(p.MatchAll, p.Selector)})
+			}
+		}
+	}
+	for _, p := range configs.Pipes {
+		g.add(&Node{Kind: Pipe, ComponentID: p.ID, Name: p.Name, Detail: selector(p.MatchAll, p.Selector)})
+	}
+	for _, c := range configs.Channels {
+		g.add(&Node{Kind: Channel, ComponentID: c.ID, Name: c.Name})
+	}
+	for _, s := range configs.Sinks {
+		g.add(&Node{Kind: Sink, ComponentID: s.ID, Name: s.Name, Detail: s.Type})
+	}
+
+	for _, c := range configs.Connections {
+		g.connect(g.ref(Source, c.SourceID), g.ref(Router, c.RouterID))
+	}
+	for _, r := range configs.Routers {
+		for _, id := range r.PipeIDs {
+			g.connect(g.ref(Router, r.ID), g.ref(Pipe, id))
+		}
+	}
+	for _, p := range configs.Pipes {
+		last := g.ref(Pipe, p.ID)
+		if p.RouterID != "" {
+			g.connect(g.ref(Router, p.RouterID), last)
+		}
+		for i, name := range p.ProcessorNames {
+			proc := g.add(&Node{Key: processorKey(p.ID, i), Kind: Processor, Name: name})
+			g.connect(last, proc)
+			last = proc
+		}
+		if p.ChannelID != "" {
+			g.connect(last, g.ref(Channel, p.ChannelID))
+		}
+		for _, id := range p.SinkIDs {
+			g.connect(last, g.ref(Sink, id))
+		}
+	}
+	for _, c := range configs.Channels {
+		for _, id := range c.SinkIDs {
+			g.connect(g.ref(Channel, c.ID), g.ref(Sink, id))
+		}
+	}
+
+	for _, state := range configs.ErrorStates {
+		for _, n := range g.Nodes {
+			if n.ComponentID != "" && n.ComponentID == state.ID {
+				n.Errors += len(state.Errors)
+				n.Alerts += len(state.Alerts)
+			}
+		}
+	}
+	return g
+}
+
+// Components returns the configured nodes that have metrics of their own:
+// all but processors and missing components.
+func (g *Graph) Components() []*Node {
+	var nodes []*Node
+	for _, n := range g.Nodes {
+		if n.Kind != Processor && !n.Missing {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// ClearErrors drops the error and alert counts of the nodes.
+func (g *Graph) ClearErrors() {
+	for _, n := range g.Nodes {
+		n.Errors, n.Alerts = 0, 0
+	}
+}
+
+// SetThroughput sets the throughput of n from its metrics over window:
+// the sum of the values of each metric divided by the window.
+func (n *Node) SetThroughput(metrics []*api.PlatformMetric, window time.Duration) {
+	n.Throughput = nil
+	for _, m := range metrics {
+		total := 0.0
+		for _, v := range m.Values {
+			total += v
+		}
+		name := m.Name
+		if name == "" {
+			name = m.Unit
+		}
+		n.Throughput = append(n.Throughput, &Rate{Metric: name, Unit: m.Unit, PerSecond: total / window.Seconds()})
+	}
+}
+
+// MetricInterval returns the metric interval used for window, aiming at
+// about 60 slots of at least a minute.
+func MetricInterval(window time.Duration) string {
+	minutes := int(window / time.Minute / 60)
+	if minutes < 1 {
+		minutes = 1
+	}
+	return (time.Duration(minutes) * time.Minute).String()
+}
+
+// add adds n unless a node with its key exists, and returns the node in the
+// graph.
+func (g *Graph) add(n *Node) *Node {
+	if n.Key == "" {
+		n.Key = string(n.Kind) + "/" + n.ComponentID
+	}
+	if existing, ok := g.nodes[n.Key]; ok {
+		return existing
+	}
+	g.nodes[n.Key] = n
+	g.Nodes = append(g.Nodes, n)
+	return n
+}
+
+// ref returns the node of the component of kind with id, adding a missing
+// one if it is not configured.
+func (g *Graph) ref(kind Kind, id string) *Node {
+	if n, ok := g.nodes[string(kind)+"/"+id]; ok {
+		return n
+	}
+	return g.add(&Node{Kind: kind, ComponentID: id, Name: id, Missing: true})
+}
+
+func (g *Graph) connect(from, to *Node) {
+	e := Edge{From: from.Key, To: to.Key}
+	if g.edges[e] {
+		return
+	}
+	g.edges[e] = true
+	g.Edges = append(g.Edges, &e)
+}
+
+// children returns the nodes n connects to, in edge order.
+func (g *Graph) children(n *Node) []*Node {
+	var nodes []*Node
+	for _, e := range g.Edges {
+		if e.From == n.Key {
+			nodes = append(nodes, g.nodes[e.To])
+		}
+	}
+	return nodes
+}
+
+// roots returns the nodes nothing connects to, in node order.
+func (g *Graph) roots() []*Node {
+	targets := map[string]bool{}
+	for _, e := range g.Edges {
+		targets[e.To] = true
+	}
+	var nodes []*Node
+	for _, n := range g.Nodes {
+		if !targets[n.Key] {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+func processorKey(pipeID string, i int) string {
+	return string(Processor) + "/" + pipeID + "/" + strconv.Itoa(i)
+}
+
+func selector(matchAll bool, selector string) string {
+	if matchAll {
+		return "match all"
+	}
+	return selector
+}
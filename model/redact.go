@@ -0,0 +1,44 @@
+package model
+
+import "log/slog"
+
+// RedactedValue replaces secrets when model values are logged or printed.
+const RedactedValue = "***REDACTED***"
+
+func redacted(s string) string {
+	if s == "" {
+		return ""
+	}
+	return RedactedValue
+}
+
+// LogValue keeps the HEC token out of slog output.
+func (s HecSecret) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("token", redacted(s.Token)))
+}
+
+// String keeps the HEC token out of fmt output.
+func (s HecSecret) String() string {
+	return "{token:" + redacted(s.Token) + "}"
+}
+
+// LogValue keeps the AWS secret key out of slog output.
+func (s AWSUserSecret) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("secretKey", redacted(s.SecretKey)))
+}
+
+// String keeps the AWS secret key out of fmt output.
+func (s AWSUserSecret) String() string {
+	return "{secretKey:" + redacted(s.SecretKey) + "}"
+}
+
+// LogValue logs the token entry without the token itself.
+func (e ApiTokenEntry) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", e.ID),
+		slog.String("name", e.Name),
+		slog.Bool("disabled", e.Disabled),
+		slog.Any("roles", e.Roles),
+		slog.String("token", redacted(e.Token)),
+	)
+}
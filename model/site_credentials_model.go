@@ -2,6 +2,24 @@ package model
 
 // SiteCredentials is the structure of site_credentials.json.
 // TokenMap maps site hostname (e.g. "demo.cloud.fluencysecurity.com") to API token.
+// Sites optionally holds per-site connection settings, keyed by the same hostname.
 type SiteCredentials struct {
-	TokenMap map[string]string `json:"tokenMap"`
+	TokenMap map[string]string        `json:"tokenMap"`
+	Sites    map[string]*SiteSettings `json:"sites,omitempty"`
+}
+
+// SiteSettings holds connection settings for one site in site_credentials.json.
+// Relative file paths are resolved against the directory of the credentials file.
+type SiteSettings struct {
+	CAFile   string `json:"caFile,omitempty"`
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+	Insecure bool   `json:"insecure,omitempty"`
+	// Proxy is the proxy URL (http, https, socks5, socks5h) used to reach the
+	// site, or "direct" to bypass proxies from the environment.
+	Proxy string `json:"proxy,omitempty"`
+	// RateLimit caps the requests per second sent to the site (0: no limit);
+	// MaxInFlight caps the requests in progress at once (0: no cap).
+	RateLimit   float64 `json:"rateLimit,omitempty"`
+	MaxInFlight int     `json:"maxInFlight,omitempty"`
 }